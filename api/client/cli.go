@@ -13,6 +13,7 @@ import (
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/engine-api/client"
 	"github.com/docker/go-connections/tlsconfig"
@@ -44,6 +45,9 @@ type DockerCli struct {
 	isTerminalOut bool
 	// client is the http client that performs all API operations
 	client client.APIClient
+	// events performs the operations of the events API that client does
+	// not support yet
+	events *eventsClient
 	// state holds the terminal state
 	state *term.State
 }
@@ -136,6 +140,11 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, clientFlags *cli.ClientF
 			customHeaders = map[string]string{}
 		}
 		customHeaders["User-Agent"] = "Docker-Client/" + dockerversion.Version + " (" + runtime.GOOS + ")"
+		if _, ok := customHeaders[api.CorrelationIDHeader]; !ok {
+			// the events caused by the requests of this command share
+			// the same correlation ID
+			customHeaders[api.CorrelationIDHeader] = stringid.GenerateNonCryptoID()
+		}
 
 		verStr := api.DefaultVersion.String()
 		if tmpStr := os.Getenv("DOCKER_API_VERSION"); tmpStr != "" {
@@ -153,6 +162,11 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, clientFlags *cli.ClientF
 		}
 		cli.client = client
 
+		cli.events, err = newEventsClient(host, verStr, clientTransport, customHeaders)
+		if err != nil {
+			return err
+		}
+
 		if cli.in != nil {
 			cli.inFd, cli.isTerminalIn = term.GetFdInfo(cli.in)
 		}
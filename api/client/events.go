@@ -24,6 +24,10 @@ func (cli *DockerCli) CmdEvents(args ...string) error {
 	cmd := Cli.Subcmd("events", nil, Cli.DockerCommands["events"].Description, true)
 	since := cmd.String([]string{"-since"}, "", "Show all events created since timestamp")
 	until := cmd.String([]string{"-until"}, "", "Stream events until this timestamp")
+	format := cmd.String([]string{"-format"}, "", "Format the events using a Go template rendered by the daemon")
+	tail := cmd.Int([]string{"-tail"}, 0, "Show only the last N past events")
+	anonymize := cmd.Bool([]string{"-anonymize"}, false, "Hash the names, IDs and labels of the events")
+	replay := cmd.String([]string{"-replay"}, "", "Replay the past events at their original pace multiplied by this speed")
 	flFilter := opts.NewListOpts(nil)
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 	cmd.Require(flag.Exact, 0)
@@ -42,18 +46,29 @@ func (cli *DockerCli) CmdEvents(args ...string) error {
 		}
 	}
 
-	options := types.EventsOptions{
-		Since:   *since,
-		Until:   *until,
-		Filters: eventFilterArgs,
+	options := eventsOptions{
+		EventsOptions: types.EventsOptions{
+			Since:   *since,
+			Until:   *until,
+			Filters: eventFilterArgs,
+		},
+		Format:    *format,
+		Tail:      *tail,
+		Replay:    *replay,
+		Anonymize: *anonymize,
 	}
 
-	responseBody, err := cli.client.Events(options)
+	responseBody, err := cli.events.events(options)
 	if err != nil {
 		return err
 	}
 	defer responseBody.Close()
 
+	if *format != "" {
+		// The events are already rendered by the daemon.
+		_, err = io.Copy(cli.out, responseBody)
+		return err
+	}
 	return streamEvents(responseBody, cli.out)
 }
 
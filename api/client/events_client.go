@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/filters"
+	timetypes "github.com/docker/engine-api/types/time"
+)
+
+// eventsOptions holds the parameters of docker events, including the ones
+// the engine-api client does not send yet.
+type eventsOptions struct {
+	types.EventsOptions
+	// Format is a Go template rendering each event on the server, the
+	// events are sent as JSON when it is empty.
+	Format string
+	// Tail is the largest number of past events returned, the most
+	// recent ones, all of them are returned when it is zero.
+	Tail int
+	// Replay is the speed the past events are replayed at, as a multiplier
+	// of their original pace, they are not replayed when it is empty.
+	Replay string
+	// Anonymize makes the daemon hash the names, IDs and labels of the
+	// events.
+	Anonymize bool
+}
+
+// eventsClient sends the requests of the events API that the engine-api
+// client does not support yet. It talks to the same host as the engine-api
+// client, with its transport, API version and HTTP headers.
+type eventsClient struct {
+	scheme     string
+	addr       string
+	basePath   string
+	version    string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// newEventsClient returns an events client for host. The transport must be
+// the one configured by client.NewClient for the same host.
+func newEventsClient(host, version string, transport *http.Transport, headers map[string]string) (*eventsClient, error) {
+	protoAddrParts := strings.SplitN(host, "://", 2)
+	if len(protoAddrParts) != 2 {
+		return nil, fmt.Errorf("unable to parse docker host `%s`", host)
+	}
+	c := &eventsClient{
+		scheme:     "http",
+		addr:       protoAddrParts[1],
+		version:    version,
+		headers:    headers,
+		httpClient: &http.Client{Transport: transport},
+	}
+	if protoAddrParts[0] == "tcp" {
+		parsed, err := url.Parse("tcp://" + c.addr)
+		if err != nil {
+			return nil, err
+		}
+		c.addr = parsed.Host
+		c.basePath = parsed.Path
+	}
+	if transport.TLSClientConfig != nil {
+		c.scheme = "https"
+	}
+	return c, nil
+}
+
+// events returns the stream of the events of the daemon, it's up to the
+// caller to close it.
+func (c *eventsClient) events(options eventsOptions) (io.ReadCloser, error) {
+	query, err := timeRange(options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Filters.Len() > 0 {
+		filterJSON, err := filters.ToParam(options.Filters)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filterJSON)
+	}
+	if options.Format != "" {
+		query.Set("format", options.Format)
+	}
+	if options.Tail > 0 {
+		query.Set("tail", strconv.Itoa(options.Tail))
+	}
+	if options.Replay != "" {
+		query.Set("replay", options.Replay)
+	}
+	if options.Anonymize {
+		query.Set("anonymize", "1")
+	}
+
+	return c.do("GET", "/events", query, nil)
+}
+
+// exportEvents returns a snapshot of the events journal of the daemon, as
+// a gzip compressed tar archive. Only the Since, Until and Anonymize
+// options are used. It's up to the caller to close the stream.
+func (c *eventsClient) exportEvents(options eventsOptions) (io.ReadCloser, error) {
+	query, err := timeRange(options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Anonymize {
+		query.Set("anonymize", "1")
+	}
+	return c.do("GET", "/system/events/export", query, nil)
+}
+
+// importEvents imports in the daemon a snapshot of the events exported by
+// exportEvents.
+func (c *eventsClient) importEvents(input io.Reader) (events.Snapshot, error) {
+	var snapshot events.Snapshot
+	body, err := c.do("POST", "/system/events/import", url.Values{}, input)
+	if err != nil {
+		return snapshot, err
+	}
+	defer body.Close()
+	err = json.NewDecoder(body).Decode(&snapshot)
+	return snapshot, err
+}
+
+// systemInfo is the system information, with the information on the events
+// that the engine-api types do not have yet.
+type systemInfo struct {
+	types.Info
+	EventsExporters []events.ExporterStatus `json:",omitempty"`
+	EventsMemory    events.Memory
+}
+
+// info returns the system information of the daemon.
+func (c *eventsClient) info() (systemInfo, error) {
+	var info systemInfo
+	body, err := c.do("GET", "/info", url.Values{}, nil)
+	if err != nil {
+		return info, err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&info); err != nil {
+		return info, fmt.Errorf("Error reading remote info: %v", err)
+	}
+	return info, nil
+}
+
+// timeRange returns the query parameters of the Since and Until options.
+func timeRange(options eventsOptions) (url.Values, error) {
+	query := url.Values{}
+	ref := time.Now()
+
+	if options.Since != "" {
+		ts, err := timetypes.GetTimestamp(options.Since, ref)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("since", ts)
+	}
+	if options.Until != "" {
+		ts, err := timetypes.GetTimestamp(options.Until, ref)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("until", ts)
+	}
+	return query, nil
+}
+
+// do sends a request to the daemon, and returns the body of its response
+// when it succeeds.
+func (c *eventsClient) do(method, path string, query url.Values, body io.Reader) (io.ReadCloser, error) {
+	apiPath := c.basePath + path
+	if c.version != "" {
+		apiPath = fmt.Sprintf("%s/v%s%s", c.basePath, strings.TrimPrefix(c.version, "v"), path)
+	}
+	if len(query) > 0 {
+		apiPath += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, apiPath, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil {
+		// the only requests with a body send archives
+		req.Header.Set("Content-Type", "application/x-tar")
+	}
+	req.URL.Host = c.addr
+	req.URL.Scheme = c.scheme
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "dial unix") {
+			return nil, client.ErrConnectionFailed
+		}
+		return nil, fmt.Errorf("An error occurred trying to connect: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return nil, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(resp.StatusCode), req.URL)
+		}
+		return nil, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(b))
+	}
+	return resp.Body, nil
+}
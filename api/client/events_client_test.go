@@ -0,0 +1,133 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/filters"
+)
+
+func TestEventsClient(t *testing.T) {
+	var requested *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r
+		if r.URL.Query().Get("format") == "bad" {
+			http.Error(w, "bad parameter: template: bad", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("web start\n"))
+	}))
+	defer server.Close()
+
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://") + "/base"
+	c, err := newEventsClient(host, "1.23", &http.Transport{}, map[string]string{"User-Agent": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := filters.NewArgs()
+	args.Add("type", "container")
+	body, err := c.events(eventsOptions{
+		EventsOptions: types.EventsOptions{Since: "1460000000", Filters: args},
+		Format:        "{{.Actor.ID}} {{.Action}}",
+		Tail:          20,
+		Replay:        "10x",
+		Anonymize:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "web start\n" {
+		t.Fatalf("Unexpected events %q", out)
+	}
+	if requested.URL.Path != "/base/v1.23/events" {
+		t.Fatalf("Unexpected path %s", requested.URL.Path)
+	}
+	q := requested.URL.Query()
+	if q.Get("since") != "1460000000" || q.Get("tail") != "20" || q.Get("replay") != "10x" || q.Get("anonymize") != "1" || q.Get("format") != "{{.Actor.ID}} {{.Action}}" || q.Get("filters") != `{"type":{"container":true}}` {
+		t.Fatalf("Unexpected query %v", q)
+	}
+	if requested.Header.Get("User-Agent") != "test" {
+		t.Fatalf("Expected the headers of the client, got %v", requested.Header)
+	}
+
+	_, err = c.events(eventsOptions{Format: "bad"})
+	if err == nil || err.Error() != "Error response from daemon: bad parameter: template: bad" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestEventsClientSnapshots(t *testing.T) {
+	var exported *http.Request
+	var imported []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.23/system/events/export", func(w http.ResponseWriter, r *http.Request) {
+		exported = r
+		w.Write([]byte("archive"))
+	})
+	mux.HandleFunc("/v1.23/system/events/import", func(w http.ResponseWriter, r *http.Request) {
+		imported, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"ID":"5f3c2a7d9e1b","Version":1}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := newEventsClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), "1.23", &http.Transport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := c.exportEvents(eventsOptions{EventsOptions: types.EventsOptions{Until: "1460000000"}, Anonymize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if q := exported.URL.Query(); q.Get("until") != "1460000000" || q.Get("anonymize") != "1" {
+		t.Fatalf("Unexpected query %v", q)
+	}
+
+	snapshot, err := c.importEvents(strings.NewReader("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.ID != "5f3c2a7d9e1b" || string(imported) != "archive" {
+		t.Fatalf("Unexpected snapshot %v imported from %q", snapshot, imported)
+	}
+}
+
+func TestEventsClientInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.23/info" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"ID":"I54V","Containers":3,"EventsExporters":[{"Name":"fluentd","Connected":true,"Exported":42}],"EventsMemory":{"Buffered":2048,"Queued":512}}`))
+	}))
+	defer server.Close()
+
+	c, err := newEventsClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), "1.23", &http.Transport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := c.info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "I54V" || info.Containers != 3 {
+		t.Fatalf("Unexpected info %v", info.Info)
+	}
+	if len(info.EventsExporters) != 1 || info.EventsExporters[0].Name != "fluentd" || info.EventsExporters[0].Exported != 42 {
+		t.Fatalf("Unexpected exporters %v", info.EventsExporters)
+	}
+	if info.EventsMemory.Buffered != 2048 || info.EventsMemory.Queued != 512 {
+		t.Fatalf("Unexpected memory %v", info.EventsMemory)
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	Cli "github.com/docker/docker/cli"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/engine-api/types"
+)
+
+// CmdEventsExport saves a snapshot of the events journal of the daemon
+// to a gzip compressed tar archive.
+//
+// The archive is written to STDOUT by default, or written to a file.
+//
+// Usage: docker events export [OPTIONS]
+func (cli *DockerCli) CmdEventsExport(args ...string) error {
+	cmd := Cli.Subcmd("events export", nil, "Export the events of the journal of the daemon (streamed to STDOUT by default)", true)
+	since := cmd.String([]string{"-since"}, "", "Export the events created since timestamp")
+	until := cmd.String([]string{"-until"}, "", "Export the events created until timestamp")
+	anonymize := cmd.Bool([]string{"-anonymize"}, false, "Hash the names, IDs and labels of the events")
+	outfile := cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	cmd.Require(flag.Exact, 0)
+
+	cmd.ParseFlags(args, true)
+
+	if *outfile == "" && cli.isTerminalOut {
+		return errors.New("Cowardly refusing to save to a terminal. Use the -o flag or redirect.")
+	}
+	if strings.HasSuffix(*outfile, ".zst") {
+		return errors.New("The snapshots are compressed with gzip, not zstd. Use a .tar.gz file.")
+	}
+
+	responseBody, err := cli.events.exportEvents(eventsOptions{
+		EventsOptions: types.EventsOptions{
+			Since: *since,
+			Until: *until,
+		},
+		Anonymize: *anonymize,
+	})
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+
+	if *outfile == "" {
+		_, err := io.Copy(cli.out, responseBody)
+		return err
+	}
+	return copyToFile(*outfile, responseBody)
+}
+
+// CmdEventsImport imports a snapshot of the events exported from a
+// daemon, and prints its ID.
+//
+// The archive is read from STDIN by default, or from a file.
+//
+// Usage: docker events import [OPTIONS]
+func (cli *DockerCli) CmdEventsImport(args ...string) error {
+	cmd := Cli.Subcmd("events import", nil, "Import a snapshot of the events exported from a daemon", true)
+	infile := cmd.String([]string{"i", "-input"}, "", "Read from a file, instead of STDIN")
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	var input io.Reader = cli.in
+	if *infile != "" {
+		file, err := os.Open(*infile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	snapshot, err := cli.events.importEvents(input)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", snapshot.ID)
+	return nil
+}
@@ -20,7 +20,7 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 
 	cmd.ParseFlags(args, true)
 
-	info, err := cli.client.Info()
+	info, err := cli.events.info()
 	if err != nil {
 		return err
 	}
@@ -74,6 +74,24 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	ioutils.FprintfIfNotEmpty(cli.out, "Name: %s\n", info.Name)
 	ioutils.FprintfIfNotEmpty(cli.out, "ID: %s\n", info.ID)
 
+	if len(info.EventsExporters) > 0 {
+		fmt.Fprintln(cli.out, "Events Exporters:")
+		for _, x := range info.EventsExporters {
+			state := "connected"
+			if !x.Connected {
+				state = fmt.Sprintf("disconnected (%s)", x.LastError)
+			}
+			fmt.Fprintf(cli.out, " %s: %s, %d exported, %d failed, %d queued, %d behind, %d dropped\n", x.Name, state, x.Exported, x.Failed, x.Queued, x.Behind, x.Dropped)
+		}
+	}
+	if m := info.EventsMemory; m.Buffered > 0 {
+		limit := "no limit"
+		if m.BufferLimit > 0 {
+			limit = "limit " + units.BytesSize(float64(m.BufferLimit))
+		}
+		fmt.Fprintf(cli.out, "Events Memory: %s buffered (%s), %s queued\n", units.BytesSize(float64(m.Buffered)), limit, units.BytesSize(float64(m.Queued)))
+	}
+
 	fmt.Fprintf(cli.out, "Debug mode (client): %v\n", utils.IsDebugEnabled())
 	fmt.Fprintf(cli.out, "Debug mode (server): %v\n", info.Debug)
 
@@ -29,6 +29,11 @@ const (
 	// NoBaseImageSpecifier is the symbol used by the FROM
 	// command to specify that no base image is to be used.
 	NoBaseImageSpecifier string = "scratch"
+
+	// CorrelationIDHeader is the header of the requests sharing the
+	// correlation ID of the events they cause, such as the requests
+	// a client sends for a single command.
+	CorrelationIDHeader string = "X-Docker-Correlation-Id"
 )
 
 // byPortInfo is a temporary type used to sort types.Port by its fields
@@ -18,6 +18,16 @@ import (
 // APIVersionKey is the client's requested API version.
 const APIVersionKey = "api-version"
 
+// RequestIDKey is the ID the server assigned to the request.
+const RequestIDKey = "docker-request-id"
+
+// CorrelationIDKey is the correlation ID of the request, set by the client
+// or equal to the request ID.
+const CorrelationIDKey = "docker-correlation-id"
+
+// UserKey is the user authenticated by the authorization plugins.
+const UserKey = "docker-user"
+
 // APIFunc is an adapter to allow the use of ordinary functions as Docker API endpoints.
 // Any function that has the appropriate signature can be register as a API endpoint (e.g. getVersion).
 type APIFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error
@@ -176,3 +186,67 @@ func VersionFromContext(ctx context.Context) (ver version.Version) {
 	}
 	return val.(version.Version)
 }
+
+// RequestIDFromContext returns the ID of the request, or an empty string
+// if it is not set.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// CorrelationIDFromContext returns the correlation ID of the request, or
+// an empty string if it is not set.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(CorrelationIDKey).(string)
+	return id
+}
+
+// UserFromContext returns the user authenticated by the authorization
+// plugins, or an empty string if there is none.
+func UserFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	user, _ := ctx.Value(UserKey).(string)
+	return user
+}
+
+// ClientIdentity returns the identity of the client sending r. It is the
+// common name of its TLS certificate when the client presented a verified
+// one, or its remote address otherwise. It is empty for clients connected
+// through a unix socket.
+func ClientIdentity(r *http.Request) string {
+	if name := certificateName(r); name != "" {
+		return name
+	}
+	if r.RemoteAddr == "@" {
+		return ""
+	}
+	return r.RemoteAddr
+}
+
+// TenantIdentity returns the identity of the tenant sending r: the user
+// authenticated by the authorization plugins, or the common name of the
+// verified TLS certificate of the client. It is empty when the client is
+// not identified.
+func TenantIdentity(ctx context.Context, r *http.Request) string {
+	if user := UserFromContext(ctx); user != "" {
+		return user
+	}
+	return certificateName(r)
+}
+
+// certificateName returns the common name of the verified TLS certificate
+// of the client sending r, if any.
+func certificateName(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	return ""
+}
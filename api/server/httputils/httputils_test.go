@@ -0,0 +1,29 @@
+package httputils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+)
+
+func TestClientIdentity(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	cases := []struct {
+		r        *http.Request
+		identity string
+	}{
+		{&http.Request{RemoteAddr: "@"}, ""},
+		{&http.Request{RemoteAddr: "10.0.0.2:4242"}, "10.0.0.2:4242"},
+		{&http.Request{RemoteAddr: "10.0.0.2:4242", TLS: &tls.ConnectionState{}}, "10.0.0.2:4242"},
+		{&http.Request{RemoteAddr: "10.0.0.2:4242", TLS: &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}}, "alice"},
+	}
+	for _, c := range cases {
+		if identity := ClientIdentity(c.r); identity != c.identity {
+			t.Fatalf("Expected identity %q, got %q", c.identity, identity)
+		}
+	}
+}
@@ -66,6 +66,11 @@ func debugRequestMiddleware(handler httputils.APIFunc) httputils.APIFunc {
 	}
 }
 
+// auditor logs the audit events of the requests the server rejects.
+type auditor interface {
+	LogAuditEvent(action, id, client string, attributes map[string]string)
+}
+
 // authorizationMiddleware perform authorization on the request.
 func (s *Server) authorizationMiddleware(handler httputils.APIFunc) httputils.APIFunc {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -78,11 +83,22 @@ func (s *Server) authorizationMiddleware(handler httputils.APIFunc) httputils.AP
 
 		if err := authCtx.AuthZRequest(w, r); err != nil {
 			logrus.Errorf("AuthZRequest for %s %s returned error: %s", r.Method, r.RequestURI, err)
+			if s.auditor != nil {
+				client := httputils.ClientIdentity(r)
+				s.auditor.LogAuditEvent("authz_denied", client, client, map[string]string{
+					"method": r.Method,
+					"uri":    r.RequestURI,
+					"error":  err.Error(),
+				})
+			}
 			return err
 		}
 
 		rw := authorization.NewResponseModifier(w)
 
+		if user != "" {
+			ctx = context.WithValue(ctx, httputils.UserKey, user)
+		}
 		if err := handler(ctx, rw, r, vars); err != nil {
 			logrus.Errorf("Handler for %s %s returned error: %s", r.Method, r.RequestURI, err)
 			return err
@@ -4,7 +4,9 @@ import (
 	"io"
 	"time"
 
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/version"
@@ -40,6 +42,7 @@ type stateBackend interface {
 	ContainerRestart(name string, seconds int) error
 	ContainerRm(name string, config *types.ContainerRmConfig) error
 	ContainerStart(name string, hostConfig *container.HostConfig) error
+	LogContainerStartAudit(name, client string) error
 	ContainerStop(name string, seconds int) error
 	ContainerUnpause(name string) error
 	ContainerUpdate(name string, hostConfig *container.HostConfig) ([]string, error)
@@ -49,7 +52,13 @@ type stateBackend interface {
 
 // monitorBackend includes functions to implement to provide containers monitoring functionality.
 type monitorBackend interface {
+	AttributeContainerEvents(target string, origin events.Origin) (done func())
 	ContainerChanges(name string) ([]archive.Change, error)
+	ContainerEvents(name string, config *daemon.ContainerEventsConfig) error
+	ContainerTimeline(name, owner string, acl *events.ACL) (eventtypes.Timeline, error)
+	ContainerUptime(name string, since, until time.Time, owner string, acl *events.ACL) (eventtypes.Uptime, error)
+	EventsTenantScoping() bool
+	EventsACL(identity string) *events.ACL
 	ContainerInspect(name string, size bool, version version.Version) (interface{}, error)
 	ContainerLogs(name string, config *daemon.ContainerLogsConfig) error
 	ContainerStats(name string, config *daemon.ContainerStatsConfig) error
@@ -1,8 +1,13 @@
 package container
 
 import (
+	"net/http"
+
+	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/server/router"
 	"github.com/docker/docker/api/server/router/local"
+	"github.com/docker/docker/daemon/events"
+	"golang.org/x/net/context"
 )
 
 // containerRouter is a router to talk with the container controller
@@ -29,38 +34,62 @@ func (r *containerRouter) Routes() []router.Route {
 func (r *containerRouter) initRoutes() {
 	r.routes = []router.Route{
 		// HEAD
-		local.NewHeadRoute("/containers/{name:.*}/archive", r.headContainersArchive),
+		local.NewHeadRoute("/containers/{name:.*}/archive", r.attributed(r.headContainersArchive)),
 		// GET
 		local.NewGetRoute("/containers/json", r.getContainersJSON),
-		local.NewGetRoute("/containers/{name:.*}/export", r.getContainersExport),
+		local.NewGetRoute("/containers/{name:.*}/export", r.attributed(r.getContainersExport)),
 		local.NewGetRoute("/containers/{name:.*}/changes", r.getContainersChanges),
 		local.NewGetRoute("/containers/{name:.*}/json", r.getContainersByName),
-		local.NewGetRoute("/containers/{name:.*}/top", r.getContainersTop),
+		local.NewGetRoute("/containers/{name:.*}/top", r.attributed(r.getContainersTop)),
 		local.NewGetRoute("/containers/{name:.*}/logs", r.getContainersLogs),
 		local.NewGetRoute("/containers/{name:.*}/stats", r.getContainersStats),
+		local.NewGetRoute("/containers/{name:.*}/events", r.getContainersEvents),
+		local.NewGetRoute("/containers/{name:.*}/timeline", r.getContainersTimeline),
+		local.NewGetRoute("/containers/{name:.*}/uptime", r.getContainersUptime),
 		local.NewGetRoute("/containers/{name:.*}/attach/ws", r.wsContainersAttach),
 		local.NewGetRoute("/exec/{id:.*}/json", r.getExecByID),
-		local.NewGetRoute("/containers/{name:.*}/archive", r.getContainersArchive),
+		local.NewGetRoute("/containers/{name:.*}/archive", r.attributed(r.getContainersArchive)),
 		// POST
-		local.NewPostRoute("/containers/create", r.postContainersCreate),
-		local.NewPostRoute("/containers/{name:.*}/kill", r.postContainersKill),
-		local.NewPostRoute("/containers/{name:.*}/pause", r.postContainersPause),
-		local.NewPostRoute("/containers/{name:.*}/unpause", r.postContainersUnpause),
-		local.NewPostRoute("/containers/{name:.*}/restart", r.postContainersRestart),
-		local.NewPostRoute("/containers/{name:.*}/start", r.postContainersStart),
-		local.NewPostRoute("/containers/{name:.*}/stop", r.postContainersStop),
+		local.NewPostRoute("/containers/create", r.attributed(r.postContainersCreate)),
+		local.NewPostRoute("/containers/{name:.*}/kill", r.attributed(r.postContainersKill)),
+		local.NewPostRoute("/containers/{name:.*}/pause", r.attributed(r.postContainersPause)),
+		local.NewPostRoute("/containers/{name:.*}/unpause", r.attributed(r.postContainersUnpause)),
+		local.NewPostRoute("/containers/{name:.*}/restart", r.attributed(r.postContainersRestart)),
+		local.NewPostRoute("/containers/{name:.*}/start", r.attributed(r.postContainersStart)),
+		local.NewPostRoute("/containers/{name:.*}/stop", r.attributed(r.postContainersStop)),
 		local.NewPostRoute("/containers/{name:.*}/wait", r.postContainersWait),
-		local.NewPostRoute("/containers/{name:.*}/resize", r.postContainersResize),
+		local.NewPostRoute("/containers/{name:.*}/resize", r.attributed(r.postContainersResize)),
 		local.NewPostRoute("/containers/{name:.*}/attach", r.postContainersAttach),
-		local.NewPostRoute("/containers/{name:.*}/copy", r.postContainersCopy),
-		local.NewPostRoute("/containers/{name:.*}/exec", r.postContainerExecCreate),
+		local.NewPostRoute("/containers/{name:.*}/copy", r.attributed(r.postContainersCopy)),
+		local.NewPostRoute("/containers/{name:.*}/exec", r.attributed(r.postContainerExecCreate)),
 		local.NewPostRoute("/exec/{name:.*}/start", r.postContainerExecStart),
 		local.NewPostRoute("/exec/{name:.*}/resize", r.postContainerExecResize),
-		local.NewPostRoute("/containers/{name:.*}/rename", r.postContainerRename),
-		local.NewPostRoute("/containers/{name:.*}/update", r.postContainerUpdate),
+		local.NewPostRoute("/containers/{name:.*}/rename", r.attributed(r.postContainerRename)),
+		local.NewPostRoute("/containers/{name:.*}/update", r.attributed(r.postContainerUpdate)),
 		// PUT
-		local.NewPutRoute("/containers/{name:.*}/archive", r.putContainersArchive),
+		local.NewPutRoute("/containers/{name:.*}/archive", r.attributed(r.putContainersArchive)),
 		// DELETE
-		local.NewDeleteRoute("/containers/{name:.*}", r.deleteContainers),
+		local.NewDeleteRoute("/containers/{name:.*}", r.attributed(r.deleteContainers)),
+	}
+}
+
+// attributed wraps a handler so that the events of the container targeted
+// by the request carry the origin of the request.
+func (r *containerRouter) attributed(handler httputils.APIFunc) httputils.APIFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, vars map[string]string) error {
+		target := vars["name"]
+		if target == "" {
+			// the container is created, by name if the request sets one
+			target = req.URL.Query().Get("name")
+		}
+		origin := events.Origin{
+			Client:        httputils.ClientIdentity(req),
+			User:          httputils.UserFromContext(ctx),
+			RequestID:     httputils.RequestIDFromContext(ctx),
+			CorrelationID: httputils.CorrelationIDFromContext(ctx),
+			Tenant:        httputils.TenantIdentity(ctx, req),
+		}
+		defer r.backend.AttributeContainerEvents(target, origin)()
+		return handler(ctx, w, req, vars)
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/docker/distribution/registry/api/errcode"
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/daemon/events"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/signal"
@@ -22,6 +23,7 @@ import (
 	"github.com/docker/docker/utils"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/filters"
 	timetypes "github.com/docker/engine-api/types/time"
 	"golang.org/x/net/context"
 	"golang.org/x/net/websocket"
@@ -87,6 +89,112 @@ func (s *containerRouter) getContainersStats(ctx context.Context, w http.Respons
 	return s.backend.ContainerStats(vars["name"], config)
 }
 
+func (s *containerRouter) getContainersEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	since, sinceNano, err := timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return err
+	}
+	until, untilNano, err := timetypes.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return err
+	}
+	args, err := filters.FromParam(r.Form.Get("filters"))
+	if err != nil {
+		return fmt.Errorf("bad parameter: invalid filters: %v", err)
+	}
+	ef, err := events.ParseFilter(args)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	output := ioutils.NewWriteFlusher(w)
+	defer output.Close()
+
+	var closeNotifier <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closeNotifier = notifier.CloseNotify()
+	}
+
+	var owner string
+	identity := httputils.TenantIdentity(ctx, r)
+	if s.backend.EventsTenantScoping() {
+		if owner = identity; owner == "" {
+			return derr.ErrorCodeEventsNoTenant
+		}
+	}
+
+	config := &daemon.ContainerEventsConfig{
+		Owner:     owner,
+		ACL:       s.backend.EventsACL(identity),
+		Since:     since,
+		SinceNano: sinceNano,
+		Until:     until,
+		UntilNano: untilNano,
+		Filter:    ef,
+		OutStream: output,
+		Stop:      closeNotifier,
+		Schema:    events.SchemaForAPIVersion(httputils.VersionFromContext(ctx)),
+	}
+
+	return s.backend.ContainerEvents(vars["name"], config)
+}
+
+func (s *containerRouter) getContainersTimeline(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var owner string
+	identity := httputils.TenantIdentity(ctx, r)
+	if s.backend.EventsTenantScoping() {
+		if owner = identity; owner == "" {
+			return derr.ErrorCodeEventsNoTenant
+		}
+	}
+
+	timeline, err := s.backend.ContainerTimeline(vars["name"], owner, s.backend.EventsACL(identity))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, timeline)
+}
+
+func (s *containerRouter) getContainersUptime(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	var since time.Time
+	if r.Form.Get("since") != "" {
+		sec, nsec, err := timetypes.ParseTimestamps(r.Form.Get("since"), 0)
+		if err != nil {
+			return err
+		}
+		since = time.Unix(sec, nsec)
+	}
+	until := time.Now()
+	if r.Form.Get("until") != "" {
+		sec, nsec, err := timetypes.ParseTimestamps(r.Form.Get("until"), 0)
+		if err != nil {
+			return err
+		}
+		until = time.Unix(sec, nsec)
+	}
+
+	var owner string
+	identity := httputils.TenantIdentity(ctx, r)
+	if s.backend.EventsTenantScoping() {
+		if owner = identity; owner == "" {
+			return derr.ErrorCodeEventsNoTenant
+		}
+	}
+
+	uptime, err := s.backend.ContainerUptime(vars["name"], since, until, owner, s.backend.EventsACL(identity))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, uptime)
+}
+
 func (s *containerRouter) getContainersLogs(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -182,6 +290,9 @@ func (s *containerRouter) postContainersStart(ctx context.Context, w http.Respon
 	if err := s.backend.ContainerStart(vars["name"], hostConfig); err != nil {
 		return err
 	}
+	if err := s.backend.LogContainerStartAudit(vars["name"], httputils.ClientIdentity(r)); err != nil {
+		logrus.Errorf("Error logging audit events of container %s: %v", vars["name"], err)
+	}
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
@@ -372,6 +483,20 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 	version := httputils.VersionFromContext(ctx)
 	adjustCPUShares := version.LessThan("1.19")
 
+	// The container of a tenant carries its owner in its labels, so that
+	// it keeps it across the restarts of the daemon.
+	if s.backend.EventsTenantScoping() {
+		if _, ok := config.Labels[events.OwnerLabel]; ok {
+			return derr.ErrorCodeEventsOwnerLabel.WithArgs(events.OwnerLabel)
+		}
+		if tenant := httputils.TenantIdentity(ctx, r); tenant != "" {
+			if config.Labels == nil {
+				config.Labels = make(map[string]string)
+			}
+			config.Labels[events.OwnerLabel] = tenant
+		}
+	}
+
 	ccr, err := s.backend.ContainerCreate(types.ContainerCreateConfig{
 		Name:             name,
 		Config:           config,
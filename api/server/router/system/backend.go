@@ -1,9 +1,13 @@
 package system
 
 import (
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	daemonevents "github.com/docker/docker/daemon/events"
 	"github.com/docker/engine-api/types"
-	"github.com/docker/engine-api/types/events"
-	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
 )
 
 // Backend is the methods that need to be implemented to provide
@@ -11,7 +15,25 @@ import (
 type Backend interface {
 	SystemInfo() (*types.Info, error)
 	SystemVersion() types.Version
-	SubscribeToEvents(since, sinceNano int64, ef filters.Args) ([]events.Message, chan interface{})
-	UnsubscribeFromEvents(chan interface{})
+	SubscribeToEvents(ctx context.Context, opts daemonevents.SubscribeOptions) ([]events.Message, <-chan events.Message)
+	DroppedEvents(<-chan events.Message) uint64
+	EventsMetrics() daemonevents.Metrics
+	EventsHistory(opts daemonevents.HistoryOptions) (events.History, error)
+	EventsSearch(opts daemonevents.SearchOptions) (events.History, error)
+	EventsDiff(opts daemonevents.DiffOptions) (events.Diff, error)
+	ExportEvents(w io.Writer, opts daemonevents.SnapshotOptions) error
+	ImportEvents(r io.Reader) (events.Snapshot, error)
+	EventsTenantScoping() bool
+	EventsACL(identity string) *daemonevents.ACL
+	EventsAdmin(identity string) bool
+	EventsAnonymizer() *daemonevents.Anonymizer
+	EventsPayloads() *daemonevents.PayloadCache
+	EventsSubscribers(owner string) []events.Subscriber
+	EventsExporters() []events.ExporterStatus
+	EventsStats(windows []time.Duration) []events.WindowStats
+	EventsMemory() events.Memory
+	EvictEventsSubscriber(id uint64, owner string) error
+	PauseEventsSubscriber(id uint64, limit int, owner string) error
+	ResumeEventsSubscriber(id uint64, owner string) error
 	AuthenticateToRegistry(authConfig *types.AuthConfig) (string, error)
 }
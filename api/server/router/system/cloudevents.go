@@ -0,0 +1,35 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/events"
+	daemonevents "github.com/docker/docker/daemon/events"
+)
+
+// cloudEventsEncoder writes each event as a CloudEvent in the structured
+// JSON encoding, followed by a new line. A batch of events is written as a
+// JSON array of CloudEvents, as in the batched encoding.
+type cloudEventsEncoder struct {
+	enc *json.Encoder
+}
+
+func newCloudEventsEncoder(w io.Writer) EventsEncoder {
+	return &cloudEventsEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *cloudEventsEncoder) Encode(v interface{}) error {
+	switch m := v.(type) {
+	case events.Message:
+		return e.enc.Encode(daemonevents.ToCloudEvent(m))
+	case []events.Message:
+		batch := make([]daemonevents.CloudEvent, 0, len(m))
+		for _, ev := range m {
+			batch = append(batch, daemonevents.ToCloudEvent(ev))
+		}
+		return e.enc.Encode(batch)
+	}
+	return fmt.Errorf("cannot encode %T as an event", v)
+}
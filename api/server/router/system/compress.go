@@ -0,0 +1,94 @@
+package system
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gzipFlushInterval is how long the compressed events stream may hold
+// written data before sending it to the client.
+const gzipFlushInterval = 100 * time.Millisecond
+
+var errGzipStreamClosed = errors.New("write to a closed compressed events stream")
+
+// acceptsGzip returns true if the client accepts responses compressed
+// with gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(encoding, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && kv[0] == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipStream compresses a stream of events. The compressed data is
+// flushed to the client shortly after each write, so that the events do
+// not wait for a full block to be compressed.
+type gzipStream struct {
+	mu      sync.Mutex
+	gz      *gzip.Writer
+	timer   *time.Timer
+	pending bool
+	closed  bool
+}
+
+func newGzipStream(w io.Writer) *gzipStream {
+	return &gzipStream{gz: gzip.NewWriter(w)}
+}
+
+func (s *gzipStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errGzipStreamClosed
+	}
+	n, err := s.gz.Write(p)
+	if err != nil || s.pending {
+		return n, err
+	}
+	s.pending = true
+	if s.timer == nil {
+		s.timer = time.AfterFunc(gzipFlushInterval, s.flush)
+	} else {
+		s.timer.Reset(gzipFlushInterval)
+	}
+	return n, nil
+}
+
+func (s *gzipStream) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || !s.pending {
+		return
+	}
+	s.pending = false
+	s.gz.Flush()
+}
+
+// Close writes the end of the compressed stream.
+func (s *gzipStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	return s.gz.Close()
+}
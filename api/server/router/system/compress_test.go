@@ -0,0 +1,122 @@
+package system
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// decompressed returns the data decompressed from the compressed stream
+// written so far, which may not be complete.
+func decompressed(data []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	out, _ := ioutil.ReadAll(zr)
+	return string(out)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0.5":        true,
+		"gzip;q=0":          false,
+		"identity, deflate": false,
+	} {
+		r := &http.Request{Header: http.Header{"Accept-Encoding": {value}}}
+		if acceptsGzip(r) != expected {
+			t.Fatalf("Expected %v for %q", expected, value)
+		}
+	}
+}
+
+func TestGzipStreamFlush(t *testing.T) {
+	var w syncBuffer
+	s := newGzipStream(&w)
+	defer s.Close()
+
+	// The events written are sent without closing the stream, once the
+	// flush interval elapsed.
+	for _, event := range []string{"start\n", "die\n"} {
+		if _, err := s.Write([]byte(event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for decompressed(w.Bytes()) != "start\ndie\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for the events flushed, got %q", decompressed(w.Bytes()))
+		}
+		time.Sleep(gzipFlushInterval / 10)
+	}
+
+	// The following events are flushed too.
+	if _, err := s.Write([]byte("destroy\n")); err != nil {
+		t.Fatal(err)
+	}
+	for decompressed(w.Bytes()) != "start\ndie\ndestroy\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for the events flushed, got %q", decompressed(w.Bytes()))
+		}
+		time.Sleep(gzipFlushInterval / 10)
+	}
+}
+
+func TestGzipStreamClose(t *testing.T) {
+	var w syncBuffer
+	s := newGzipStream(&w)
+
+	// Closing the stream sends the events pending, and the end of the
+	// compressed stream.
+	if _, err := s.Write([]byte("start\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := w.Bytes()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(zr)
+	if err != nil || string(out) != "start\n" {
+		t.Fatalf("Expected the complete stream, got %q, %v", out, err)
+	}
+
+	// Nothing is written after the end of the stream, even when the
+	// flush timer fires.
+	s.flush()
+	time.Sleep(2 * gzipFlushInterval)
+	if !bytes.Equal(w.Bytes(), data) {
+		t.Fatal("Expected nothing written after the stream is closed")
+	}
+	if _, err := s.Write([]byte("die\n")); err == nil {
+		t.Fatal("Expected an error writing to a closed stream")
+	}
+}
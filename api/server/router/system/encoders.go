@@ -0,0 +1,81 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/daemon/events/eventspb"
+	"github.com/ugorji/go/codec"
+)
+
+// EventsEncoder writes the events sent by the events endpoint in a format.
+type EventsEncoder interface {
+	// Encode writes an event message, or a batch of them as a slice of
+	// messages.
+	Encode(v interface{}) error
+}
+
+// NewEventsEncoderFunc returns an EventsEncoder writing to w.
+type NewEventsEncoderFunc func(w io.Writer) EventsEncoder
+
+// defaultEventsMediaType is the media type of the events sent to the
+// clients that don't accept any of the registered ones.
+const defaultEventsMediaType = "application/json"
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = make(map[string]NewEventsEncoderFunc)
+)
+
+// RegisterEventsEncoder makes the events endpoint send the events encoded
+// by the encoders created by f to the clients accepting mediaType.
+func RegisterEventsEncoder(mediaType string, f NewEventsEncoderFunc) error {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if _, exists := encoders[mediaType]; exists {
+		return fmt.Errorf("events encoder for %s is already registered", mediaType)
+	}
+	encoders[mediaType] = f
+	return nil
+}
+
+// negotiateEventsEncoder returns the media type and the encoder of the
+// first registered media type listed in the Accept header of the request,
+// or the default ones.
+func negotiateEventsEncoder(r *http.Request) (string, NewEventsEncoderFunc) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		t, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		if f, ok := encoders[t]; ok {
+			return t, f
+		}
+	}
+	return defaultEventsMediaType, encoders[defaultEventsMediaType]
+}
+
+func init() {
+	msgpackHandle := &codec.MsgpackHandle{}
+	msgpackHandle.WriteExt = true
+	cborHandle := &codec.CborHandle{}
+
+	for mediaType, f := range map[string]NewEventsEncoderFunc{
+		defaultEventsMediaType:  func(w io.Writer) EventsEncoder { return json.NewEncoder(w) },
+		eventStreamType:         func(w io.Writer) EventsEncoder { return eventStreamEncoder{w} },
+		eventspb.MediaType:      func(w io.Writer) EventsEncoder { return eventspb.NewEncoder(w) },
+		"application/x-msgpack": func(w io.Writer) EventsEncoder { return codec.NewEncoder(w, msgpackHandle) },
+		"application/cbor":      func(w io.Writer) EventsEncoder { return codec.NewEncoder(w, cborHandle) },
+	} {
+		if err := RegisterEventsEncoder(mediaType, f); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package system
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events/eventspb"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiateEventsEncoder(t *testing.T) {
+	for accept, expected := range map[string]string{
+		"":                                 defaultEventsMediaType,
+		"*/*":                              defaultEventsMediaType,
+		"text/html":                        defaultEventsMediaType,
+		"not a media type;;":               defaultEventsMediaType,
+		"application/json":                 "application/json",
+		"text/event-stream":                eventStreamType,
+		"application/x-msgpack":            "application/x-msgpack",
+		"application/cbor":                 "application/cbor",
+		eventspb.MediaType:                 eventspb.MediaType,
+		"text/html, application/x-msgpack": "application/x-msgpack",
+		"application/cbor;q=0.9, application/json":  "application/cbor",
+		" application/json ; charset=utf-8 ,text/*": "application/json",
+	} {
+		r := &http.Request{Header: http.Header{"Accept": {accept}}}
+		mediaType, f := negotiateEventsEncoder(r)
+		if mediaType != expected || f == nil {
+			t.Fatalf("Expected %s for %q, got %s", expected, accept, mediaType)
+		}
+	}
+
+	if err := RegisterEventsEncoder("application/json", nil); err == nil {
+		t.Fatal("Expected an error registering the same media type twice")
+	}
+}
+
+// roundTripEvents are an event, and a batch, encoded and decoded back.
+var roundTripEvents = []events.Message{
+	{
+		Message: eventtypes.Message{
+			Type:     events.ContainerEventType,
+			Action:   "start",
+			Actor:    eventtypes.Actor{ID: "cont", Attributes: map[string]string{"image": "busybox", "name": "web"}},
+			Time:     1,
+			TimeNano: 1000000001,
+		},
+		Sequence: 7,
+	},
+	{
+		Message: eventtypes.Message{
+			Type:     events.NetworkEventType,
+			Action:   "connect",
+			Actor:    eventtypes.Actor{ID: "net", Attributes: map[string]string{"container": "cont"}},
+			Time:     2,
+			TimeNano: 2000000002,
+		},
+		Sequence: 8,
+	},
+}
+
+// encodeEvents encodes the first round trip event alone, and the others
+// in a batch, with the encoder negotiated for mediaType.
+func encodeEvents(t *testing.T, mediaType string) *bytes.Buffer {
+	_, f := negotiateEventsEncoder(&http.Request{Header: http.Header{"Accept": {mediaType}}})
+	var buf bytes.Buffer
+	enc := f(&buf)
+	if err := enc.Encode(roundTripEvents[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(roundTripEvents[1:]); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestEventsEncodersRoundTrip(t *testing.T) {
+	for mediaType, h := range map[string]codec.Handle{
+		"application/x-msgpack": &codec.MsgpackHandle{},
+		"application/cbor":      &codec.CborHandle{},
+	} {
+		dec := codec.NewDecoder(encodeEvents(t, mediaType), h)
+		var ev events.Message
+		var batch []events.Message
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("Error decoding %s: %v", mediaType, err)
+		}
+		if err := dec.Decode(&batch); err != nil {
+			t.Fatalf("Error decoding a batch of %s: %v", mediaType, err)
+		}
+		if decoded := append([]events.Message{ev}, batch...); !reflect.DeepEqual(decoded, roundTripEvents) {
+			t.Fatalf("Expected the events decoded from %s, got %+v", mediaType, decoded)
+		}
+	}
+
+	// The events of a batch are written one after the other in protobuf.
+	dec := eventspb.NewDecoder(encodeEvents(t, eventspb.MediaType))
+	var decoded []events.Message
+	for {
+		ev, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, ev)
+	}
+	if !reflect.DeepEqual(decoded, roundTripEvents) {
+		t.Fatalf("Expected the events decoded from protobuf, got %+v", decoded)
+	}
+}
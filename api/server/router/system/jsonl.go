@@ -0,0 +1,88 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+const (
+	// jsonlStableFormat is the value of the format parameter selecting
+	// the stable JSON Lines encoding instead of a template.
+	jsonlStableFormat = "jsonl-stable"
+	// jsonlType is the media type of the events encoded as JSON Lines.
+	jsonlType = "application/x-ndjson"
+	// jsonlSchema identifies the fields of the stable JSON Lines
+	// encoding, it changes whenever a field is added, removed or moved.
+	jsonlSchema = "docker-events/1"
+)
+
+// jsonlHeader is the first line of a stable JSON Lines stream.
+type jsonlHeader struct {
+	Schema string `json:"schema"`
+}
+
+// jsonlEvent is the stable encoding of an event: the fields are always
+// written in this order, all of them even when they are zero, and the
+// attributes are sorted by name.
+type jsonlEvent struct {
+	Sequence   uint64            `json:"sequence"`
+	TimeNano   int64             `json:"timeNano"`
+	Type       string            `json:"type"`
+	Action     string            `json:"action"`
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes"`
+	Signature  string            `json:"signature"`
+}
+
+// jsonlEncoder writes the events as JSON Lines with a stable field order,
+// after a header line naming the schema of the events, so that streams can
+// be archived and compared line by line.
+type jsonlEncoder struct {
+	enc *json.Encoder
+	// err is the error writing the header, returned by Encode.
+	err error
+}
+
+// newJSONLEncoder writes the header as the stream starts, so that a stream
+// without any event still names its schema.
+func newJSONLEncoder(w io.Writer) EventsEncoder {
+	enc := json.NewEncoder(w)
+	return &jsonlEncoder{enc: enc, err: enc.Encode(jsonlHeader{Schema: jsonlSchema})}
+}
+
+func (e *jsonlEncoder) Encode(v interface{}) error {
+	if e.err != nil {
+		return e.err
+	}
+	switch m := v.(type) {
+	case events.Message:
+		return e.encode(m)
+	case []events.Message:
+		for _, ev := range m {
+			if err := e.encode(ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot encode %T as an event", v)
+}
+
+func (e *jsonlEncoder) encode(m events.Message) error {
+	attributes := m.Actor.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	return e.enc.Encode(jsonlEvent{
+		Sequence:   m.Sequence,
+		TimeNano:   m.TimeNano,
+		Type:       m.Type,
+		Action:     m.Action,
+		ID:         m.Actor.ID,
+		Attributes: attributes,
+		Signature:  m.Signature,
+	})
+}
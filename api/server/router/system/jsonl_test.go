@@ -0,0 +1,46 @@
+package system
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestJSONLEncoderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	newJSONLEncoder(&buf)
+	if expected := `{"schema":"docker-events/1"}` + "\n"; buf.String() != expected {
+		t.Fatalf("Expected only the header %q, got %q", expected, buf.String())
+	}
+}
+
+func TestJSONLEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := newJSONLEncoder(&buf)
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:     events.ContainerEventType,
+			Action:   "start",
+			Actor:    eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "web", "image": "busybox"}},
+			TimeNano: 42,
+		},
+		Sequence: 7,
+	}
+	if err := enc.Encode(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode([]events.Message{{Message: eventtypes.Message{Type: events.ImageEventType, Action: "pull"}, Sequence: 8}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"schema":"docker-events/1"}` + "\n" +
+		`{"sequence":7,"timeNano":42,"type":"container","action":"start","id":"cont","attributes":{"image":"busybox","name":"web"},"signature":""}` + "\n" +
+		`{"sequence":8,"timeNano":0,"type":"image","action":"pull","id":"","attributes":{},"signature":""}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	if err := enc.Encode("start"); err == nil {
+		t.Fatal("Expected an error encoding something else than an event")
+	}
+}
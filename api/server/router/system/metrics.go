@@ -0,0 +1,60 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	daemonevents "github.com/docker/docker/daemon/events"
+)
+
+// metricsPrefix is the namespace of the metrics exported by the daemon.
+const metricsPrefix = "engine_daemon_"
+
+// writeEventsMetrics writes the events metrics in the Prometheus
+// text exposition format.
+func writeEventsMetrics(w io.Writer, m daemonevents.Metrics) error {
+	bw := bufio.NewWriter(w)
+
+	writeMetric(bw, "events_published_total", "counter", "Number of events published.", m.Published)
+	writeMetric(bw, "events_dropped_total", "counter", "Number of events not delivered to subscribers.", m.Dropped)
+	writeMetric(bw, "events_subscribers", "gauge", "Number of events subscribers.", len(m.Subscribers))
+	writeMetric(bw, "events_buffered", "gauge", "Number of past events kept in memory.", m.Buffered)
+	writeMetric(bw, "events_buffer_size", "gauge", "Maximum number of past events kept in memory.", m.BufferSize)
+	writeMetric(bw, "events_buffered_bytes", "gauge", "Memory held by the past events kept in memory.", m.Memory.Buffered)
+	writeMetric(bw, "events_queued_bytes", "gauge", "Estimated memory held by the events waiting to be read by the subscribers.", m.Memory.Queued)
+	writeMetric(bw, "events_payload_cache_hits_total", "counter", "Number of encodings of the events sent shared between subscribers.", m.PayloadHits)
+	writeMetric(bw, "events_payload_cache_misses_total", "counter", "Number of events encoded for the subscribers.", m.PayloadMisses)
+	writeMetric(bw, "events_dispatch_queued", "gauge", "Number of events logged waiting to be published to the subscribers.", m.Dispatching)
+	writeMetric(bw, "events_dispatch_dropped_total", "counter", "Number of verbose events not published because too many events were waiting.", m.DispatchDropped)
+
+	subscribers := m.Subscribers
+	sort.Sort(byID(subscribers))
+	writeHeader(bw, "events_subscriber_dropped_total", "counter", "Number of events not delivered to a subscriber.")
+	for _, s := range subscribers {
+		fmt.Fprintf(bw, "%sevents_subscriber_dropped_total{subscriber=\"%d\"} %d\n", metricsPrefix, s.ID, s.Dropped)
+	}
+	writeHeader(bw, "events_subscriber_queued", "gauge", "Number of events waiting to be read by a subscriber.")
+	for _, s := range subscribers {
+		fmt.Fprintf(bw, "%sevents_subscriber_queued{subscriber=\"%d\"} %d\n", metricsPrefix, s.ID, s.Queued)
+	}
+
+	return bw.Flush()
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n", metricsPrefix, name, help)
+	fmt.Fprintf(w, "# TYPE %s%s %s\n", metricsPrefix, name, kind)
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value interface{}) {
+	writeHeader(w, name, kind, help)
+	fmt.Fprintf(w, "%s%s %d\n", metricsPrefix, name, value)
+}
+
+type byID []daemonevents.SubscriberStats
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
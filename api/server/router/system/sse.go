@@ -0,0 +1,49 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// eventStreamType is the media type of Server-Sent Events.
+const eventStreamType = "text/event-stream"
+
+// eventStreamEncoder writes events as Server-Sent Events. The ID of each
+// event is its sequence number, or the one of the last event of a batch,
+// so that clients reconnecting with the Last-Event-ID header resume after
+// it.
+type eventStreamEncoder struct {
+	w io.Writer
+}
+
+// Encode writes an event, or a batch of events, as a message.
+func (e eventStreamEncoder) Encode(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if seq := lastSequence(v); seq > 0 {
+		if _, err := fmt.Fprintf(e.w, "id: %d\n", seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(e.w, "data: %s\n\n", data)
+	return err
+}
+
+// lastSequence returns the sequence number of an event, or of the last
+// event of a batch.
+func lastSequence(v interface{}) uint64 {
+	switch m := v.(type) {
+	case events.Message:
+		return m.Sequence
+	case []events.Message:
+		if len(m) > 0 {
+			return m[len(m)-1].Sequence
+		}
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package system
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestEventStreamEncoder(t *testing.T) {
+	for _, c := range []struct {
+		v        interface{}
+		expected string
+	}{
+		{events.Message{Message: eventtypes.Message{Action: "start"}, Sequence: 7}, "id: 7\ndata: "},
+		{[]events.Message{{Message: eventtypes.Message{Action: "start"}, Sequence: 7}, {Message: eventtypes.Message{Action: "die"}, Sequence: 9}}, "id: 9\ndata: ["},
+		// The messages that are not events logged, such as the reports
+		// of the events dropped, have no sequence number, nor ID.
+		{events.Message{Message: eventtypes.Message{Action: "dropped"}}, "data: "},
+		{[]events.Message{}, "data: []\n\n"},
+	} {
+		var buf bytes.Buffer
+		if err := (eventStreamEncoder{&buf}).Encode(c.v); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte(c.expected)) || !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) || bytes.Count(buf.Bytes(), []byte("\n")) > 3 {
+			t.Fatalf("Expected a message starting with %q, got %q", c.expected, buf.String())
+		}
+	}
+}
+
+// getEventStream requests the event stream of s with the Last-Event-ID
+// header set to lastID, and returns the response.
+func getEventStream(t *testing.T, s *systemRouter, lastID string) *httptest.ResponseRecorder {
+	r, err := http.NewRequest("GET", "/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Accept", eventStreamType)
+	r.Header.Set("Last-Event-ID", lastID)
+	w := httptest.NewRecorder()
+	if err := s.getEvents(context.Background(), w, r, nil); err != nil {
+		httputils.WriteError(w, err)
+	}
+	return w
+}
+
+func TestEventStreamResume(t *testing.T) {
+	b := &fakeBackend{
+		past:   []events.Message{{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start"}, Sequence: 6}, {Message: eventtypes.Message{Type: events.ContainerEventType, Action: "die"}, Sequence: 7}},
+		stream: make(chan events.Message),
+	}
+	close(b.stream)
+	s := &systemRouter{backend: b}
+
+	// A client reconnecting resumes after the last event it received.
+	w := getEventStream(t, s, "5")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != eventStreamType {
+		t.Fatalf("Unexpected response %d %v", w.Code, w.Header())
+	}
+	if b.subscribed.ResumeAfter != 5 {
+		t.Fatalf("Expected the stream resumed after the event 5, got %d", b.subscribed.ResumeAfter)
+	}
+	lines := bytes.Split(w.Body.Bytes(), []byte("\n"))
+	if len(lines) != 7 || string(lines[0]) != "id: 6" || string(lines[3]) != "id: 7" {
+		t.Fatalf("Expected the events 6 and 7 with their IDs, got %q", w.Body.String())
+	}
+
+	if w := getEventStream(t, s, "last"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d for an invalid Last-Event-ID, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -23,6 +23,19 @@ func NewRouter(b Backend) router.Router {
 		local.NewOptionsRoute("/{anyroute:.*}", optionsHandler),
 		local.NewGetRoute("/_ping", pingHandler),
 		local.NewGetRoute("/events", r.getEvents),
+		local.NewGetRoute("/events/ws", r.getEventsWebsocket),
+		local.NewGetRoute("/events/history", r.getEventsHistory),
+		local.NewGetRoute("/events/search", r.getEventsSearch),
+		local.NewGetRoute("/events/diff", r.getEventsDiff),
+		local.NewGetRoute("/system/events/subscribers", r.getEventsSubscribers),
+		local.NewGetRoute("/system/events/exporters", r.getEventsExporters),
+		local.NewGetRoute("/system/events/stats", r.getEventsStats),
+		local.NewGetRoute("/system/events/export", r.getEventsExport),
+		local.NewPostRoute("/system/events/import", r.postEventsImport),
+		local.NewDeleteRoute("/system/events/subscribers/{id:[0-9]+}", r.deleteEventsSubscriber),
+		local.NewPostRoute("/system/events/subscribers/{id:[0-9]+}/pause", r.postEventsSubscriberPause),
+		local.NewPostRoute("/system/events/subscribers/{id:[0-9]+}/resume", r.postEventsSubscriberResume),
+		local.NewGetRoute("/metrics", r.getMetrics),
 		local.NewGetRoute("/info", r.getInfo),
 		local.NewGetRoute("/version", r.getVersion),
 		local.NewPostRoute("/auth", r.postAuth),
@@ -2,18 +2,25 @@ package system
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/events"
+	daemonevents "github.com/docker/docker/daemon/events"
+	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/engine-api/types"
-	"github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
 	timetypes "github.com/docker/engine-api/types/time"
 	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
 )
 
 func optionsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -26,13 +33,25 @@ func pingHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, va
 	return err
 }
 
+// systemInfo is the system information, with the information on the
+// events that engine-api does not have yet.
+type systemInfo struct {
+	*types.Info
+	EventsExporters []events.ExporterStatus `json:",omitempty"`
+	EventsMemory    events.Memory
+}
+
 func (s *systemRouter) getInfo(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	info, err := s.backend.SystemInfo()
 	if err != nil {
 		return err
 	}
 
-	return httputils.WriteJSON(w, http.StatusOK, info)
+	return httputils.WriteJSON(w, http.StatusOK, &systemInfo{
+		Info:            info,
+		EventsExporters: s.backend.EventsExporters(),
+		EventsMemory:    s.backend.EventsMemory(),
+	})
 }
 
 func (s *systemRouter) getVersion(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -46,28 +65,49 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 	if err := httputils.ParseForm(r); err != nil {
 		return err
 	}
-	since, sinceNano, err := timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	opts, err := eventsOptions(ctx, r)
 	if err != nil {
 		return err
 	}
-	until, untilNano, err := timetypes.ParseTimestamps(r.Form.Get("until"), -1)
-	if err != nil {
+	if opts.Owner, opts.ACL, err = s.eventsAccess(ctx, r); err != nil {
 		return err
 	}
-
-	timer := time.NewTimer(0)
-	timer.Stop()
-	if until > 0 || untilNano > 0 {
-		dur := time.Unix(until, untilNano).Sub(time.Now())
-		timer = time.NewTimer(dur)
+	if httputils.BoolValue(r, "anonymize") {
+		opts.anonymizer = s.backend.EventsAnonymizer()
 	}
-
-	ef, err := filters.FromParam(r.Form.Get("filters"))
-	if err != nil {
-		return err
+	mediaType, newEncoder := negotiateEventsEncoder(r)
+	switch format := r.Form.Get("format"); format {
+	case "":
+	case jsonlStableFormat:
+		mediaType, newEncoder = jsonlType, newJSONLEncoder
+	case daemonevents.CloudEventsFormat:
+		mediaType, newEncoder = daemonevents.CloudEventsType, newCloudEventsEncoder
+	default:
+		tmpl, err := parseEventsTemplate(format)
+		if err != nil {
+			return err
+		}
+		mediaType = templateType
+		newEncoder = func(w io.Writer) EventsEncoder {
+			return &templateEncoder{w: w, tmpl: tmpl}
+		}
+	}
+	if mediaType == eventStreamType && opts.ResumeAfter == 0 {
+		if id := r.Header.Get("Last-Event-ID"); id != "" {
+			opts.ResumeAfter, err = strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad parameter: invalid Last-Event-ID %q", id)
+			}
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Cache-Control", "no-cache")
+	compress := acceptsGzip(r)
+	if compress {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
 
 	// This is to ensure that the HTTP status code is sent immediately,
 	// so that it will not block the receiver.
@@ -76,45 +116,655 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 		flusher.Flush()
 	}
 
-	output := ioutils.NewWriteFlusher(w)
-	defer output.Close()
+	var output io.Writer
+	flusher := ioutils.NewWriteFlusher(w)
+	defer flusher.Close()
+	output = flusher
+	if compress {
+		gz := newGzipStream(flusher)
+		defer gz.Close()
+		output = gz
+	}
+
+	var closeNotify <-chan bool
+	if closeNotifier, ok := w.(http.CloseNotifier); ok {
+		closeNotify = closeNotifier.CloseNotify()
+	}
+
+	send := newEncoder(output).Encode
+	if mediaType == defaultEventsMediaType && opts.anonymizer == nil {
+		send = s.sharedJSON(opts.schema, send, func(b []byte) error {
+			_, err := output.Write(b)
+			return err
+		})
+	}
+	return s.streamEvents(ctx, opts, send, closeNotify)
+}
+
+// sharedJSON returns a function sending the events with write, encoded
+// once for all the subscribers receiving them in the form of schema, and
+// the batches and the other messages with send.
+func (s *systemRouter) sharedJSON(schema daemonevents.SchemaVersion, send func(interface{}) error, write func([]byte) error) func(interface{}) error {
+	payloads := s.backend.EventsPayloads()
+	return func(v interface{}) error {
+		ev, ok := v.(events.Message)
+		if !ok {
+			return send(v)
+		}
+		b, err := payloads.JSON(ev, schema)
+		if err != nil {
+			return err
+		}
+		return write(b)
+	}
+}
+
+// getEventsWebsocket streams the events as the JSON text messages of a
+// websocket, for the clients that can not read a chunked HTTP response.
+func (s *systemRouter) getEventsWebsocket(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	opts, err := eventsOptions(ctx, r)
+	if err != nil {
+		return err
+	}
+	if opts.Owner, opts.ACL, err = s.eventsAccess(ctx, r); err != nil {
+		return err
+	}
+	if httputils.BoolValue(r, "anonymize") {
+		opts.anonymizer = s.backend.EventsAnonymizer()
+	}
+
+	h := websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		// The client is not expected to send anything, the connection
+		// is closed when reading from it fails.
+		closed := make(chan bool)
+		go func() {
+			io.Copy(ioutil.Discard, ws)
+			close(closed)
+		}()
+
+		send := func(v interface{}) error {
+			return websocket.JSON.Send(ws, v)
+		}
+		if opts.anonymizer == nil {
+			// The messages are the JSON encodings of the events,
+			// without the newline.
+			send = s.sharedJSON(opts.schema, send, func(b []byte) error {
+				return websocket.Message.Send(ws, string(b[:len(b)-1]))
+			})
+		}
+		if err := s.streamEvents(ctx, opts, send, closed); err != nil {
+			logrus.Debugf("Error sending events to websocket: %v", err)
+		}
+	})
+	ws := websocket.Server{Handler: h, Handshake: nil}
+	ws.ServeHTTP(w, r)
+
+	return nil
+}
+
+// streamOptions holds the parameters of an events stream.
+type streamOptions struct {
+	daemonevents.SubscribeOptions
+	// reportDropped is true if the client must be told about the
+	// events it misses.
+	reportDropped bool
+	// batchSize and batchInterval group the events in arrays of at most
+	// batchSize events, sent at least every batchInterval. The events
+	// are sent one by one when both are zero.
+	batchSize     int
+	batchInterval time.Duration
+	// schema is the form of the events known by the client.
+	schema daemonevents.SchemaVersion
+	// anonymizer hashes the names, IDs and labels of the events sent, if
+	// it is set.
+	anonymizer *daemonevents.Anonymizer
+	// replay is the multiplier of the pace the past events are sent at,
+	// when they are replayed. The stream ends after them, without the
+	// new events.
+	replay float64
+}
+
+// maxBatchSize is the largest number of events sent in a single batch.
+const maxBatchSize = 10000
+
+// dropReportInterval is how often the events dropped are reported to the
+// subscribers that chose a policy, so that a subscriber is told about them
+// even when no event follows.
+var dropReportInterval = time.Second
+
+// parseEventsFilter parses the filters parameter of the events endpoints.
+func parseEventsFilter(param string) (*daemonevents.Filter, error) {
+	ef, err := filters.FromParam(param)
+	if err != nil {
+		return nil, fmt.Errorf("bad parameter: invalid filters: %v", err)
+	}
+	return daemonevents.ParseFilter(ef)
+}
+
+// eventsAccess returns the tenant sending r when the event stream is
+// scoped per tenant, and an empty string otherwise, and the access control
+// list of the client, if any.
+func (s *systemRouter) eventsAccess(ctx context.Context, r *http.Request) (string, *daemonevents.ACL, error) {
+	identity := httputils.TenantIdentity(ctx, r)
+	acl := s.backend.EventsACL(identity)
+	if !s.backend.EventsTenantScoping() {
+		return "", acl, nil
+	}
+	if identity == "" {
+		return "", nil, derr.ErrorCodeEventsNoTenant
+	}
+	return identity, acl, nil
+}
+
+// checkEventsAdmin returns an error unless the client sending r can manage
+// the events service.
+func (s *systemRouter) checkEventsAdmin(ctx context.Context, r *http.Request) error {
+	if !s.backend.EventsAdmin(httputils.TenantIdentity(ctx, r)) {
+		return derr.ErrorCodeEventsNotAdmin
+	}
+	return nil
+}
+
+// eventsManager returns the tenant whose subscribers the client sending r
+// manages when the event stream is scoped per tenant, or an empty string
+// when the client is an admin, which manages all the subscribers. The
+// other clients are denied.
+func (s *systemRouter) eventsManager(ctx context.Context, r *http.Request) (string, error) {
+	identity := httputils.TenantIdentity(ctx, r)
+	if s.backend.EventsAdmin(identity) {
+		return "", nil
+	}
+	if !s.backend.EventsTenantScoping() || identity == "" {
+		return "", derr.ErrorCodeEventsNotAdmin
+	}
+	return identity, nil
+}
+
+// eventsOptions parses the parameters of the events endpoints.
+func eventsOptions(ctx context.Context, r *http.Request) (streamOptions, error) {
+	var opts streamOptions
+	var err error
+
+	opts.schema = daemonevents.SchemaForAPIVersion(httputils.VersionFromContext(ctx))
+	opts.Client = httputils.ClientIdentity(r)
+
+	opts.Since, opts.SinceNano, err = timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return opts, err
+	}
+	opts.Until, opts.UntilNano, err = timetypes.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Filter, err = parseEventsFilter(r.Form.Get("filters"))
+	if err != nil {
+		return opts, err
+	}
+
+	// Clients that choose a delivery policy are told about the events
+	// they didn't receive.
+	opts.reportDropped = r.Form.Get("policy") != ""
+	opts.Policy, err = daemonevents.ParsePolicy(r.Form.Get("policy"))
+	if err != nil {
+		return opts, err
+	}
+	if t := r.Form.Get("timeout"); t != "" {
+		opts.Timeout, err = time.ParseDuration(t)
+		if err != nil || opts.Timeout <= 0 {
+			return opts, fmt.Errorf("bad parameter: timeout must be a duration greater than 0, got %q", t)
+		}
+	}
+	if ra := r.Form.Get("resume_after"); ra != "" {
+		opts.ResumeAfter, err = strconv.ParseUint(ra, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("bad parameter: invalid resume_after %q", ra)
+		}
+	}
+	if tail := r.Form.Get("tail"); tail != "" {
+		opts.Tail, err = strconv.Atoi(tail)
+		if err != nil || opts.Tail < 1 {
+			return opts, fmt.Errorf("bad parameter: tail must be a positive number, got %q", tail)
+		}
+	}
+	opts.Priority, err = daemonevents.ParsePriority(r.Form.Get("priority"))
+	if err != nil {
+		return opts, err
+	}
+	if sample := r.Form.Get("sample"); sample != "" {
+		opts.Sample, err = strconv.Atoi(sample)
+		if err != nil || opts.Sample < 1 {
+			return opts, fmt.Errorf("bad parameter: sample must be a positive number, got %q", sample)
+		}
+	}
+
+	if bs := r.Form.Get("batch_size"); bs != "" {
+		opts.batchSize, err = strconv.Atoi(bs)
+		if err != nil || opts.batchSize < 1 || opts.batchSize > maxBatchSize {
+			return opts, fmt.Errorf("bad parameter: batch_size must be between 1 and %d, got %q", maxBatchSize, bs)
+		}
+	}
+	if bi := r.Form.Get("batch_interval"); bi != "" {
+		opts.batchInterval, err = time.ParseDuration(bi)
+		if err != nil || opts.batchInterval <= 0 {
+			return opts, fmt.Errorf("bad parameter: invalid batch_interval %q", bi)
+		}
+	}
+	if opts.batchInterval > 0 && opts.batchSize == 0 {
+		opts.batchSize = maxBatchSize
+	}
+	if replay := r.Form.Get("replay"); replay != "" {
+		if opts.Since < 0 && opts.ResumeAfter == 0 {
+			return opts, fmt.Errorf("bad parameter: replay requires since or resume_after")
+		}
+		opts.replay, err = daemonevents.ParseReplaySpeed(replay)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// streamEvents subscribes to the events selected by opts, and sends them
+// until the until timestamp of opts, or the stop channel is closed.
+// Batches of events are sent as slices of messages.
+func (s *systemRouter) streamEvents(ctx context.Context, opts streamOptions, send func(interface{}) error, stop <-chan bool) error {
+	timer := time.NewTimer(0)
+	timer.Stop()
+	if opts.Until > 0 || opts.UntilNano > 0 {
+		dur := time.Unix(opts.Until, opts.UntilNano).Sub(time.Now())
+		timer = time.NewTimer(dur)
+	}
+	defer timer.Stop()
 
-	enc := json.NewEncoder(output)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	buffered, l := s.backend.SubscribeToEvents(ctx, opts.SubscribeOptions)
 
-	buffered, l := s.backend.SubscribeToEvents(since, sinceNano, ef)
-	defer s.backend.UnsubscribeFromEvents(l)
+	var (
+		batch []events.Message
+		tick  <-chan time.Time
+	)
+	if opts.batchInterval > 0 {
+		ticker := time.NewTicker(opts.batchInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := send(batch)
+		batch = nil
+		return err
+	}
+	// deliver sends ev, or adds it to the batch and sends the batch once
+	// it is full.
+	deliver := func(ev events.Message) error {
+		if opts.anonymizer != nil {
+			ev = opts.anonymizer.Anonymize(ev)
+		}
+		ev = daemonevents.Translate(ev, opts.schema)
+		if opts.batchSize == 0 {
+			return send(ev)
+		}
+		batch = append(batch, ev)
+		if len(batch) >= opts.batchSize {
+			return flush()
+		}
+		return nil
+	}
 
+	if opts.replay > 0 {
+		return daemonevents.Replay(buffered, opts.replay, func(ev events.Message) error {
+			if err := deliver(ev); err != nil {
+				return err
+			}
+			return flush()
+		}, stop)
+	}
 	for _, ev := range buffered {
-		if err := enc.Encode(ev); err != nil {
+		if err := deliver(ev); err != nil {
 			return err
 		}
 	}
+	if err := flush(); err != nil {
+		return err
+	}
 
-	var closeNotify <-chan bool
-	if closeNotifier, ok := w.(http.CloseNotifier); ok {
-		closeNotify = closeNotifier.CloseNotify()
+	var (
+		dropped  uint64
+		dropTick <-chan time.Time
+	)
+	if opts.reportDropped {
+		ticker := time.NewTicker(dropReportInterval)
+		defer ticker.Stop()
+		dropTick = ticker.C
+	}
+	reportDrops := func() error {
+		if !opts.reportDropped {
+			return nil
+		}
+		d := s.backend.DroppedEvents(l)
+		if d <= dropped {
+			return nil
+		}
+		count := d - dropped
+		dropped = d
+		return deliver(daemonevents.DroppedMessage(count))
 	}
 
 	for {
 		select {
-		case ev := <-l:
-			jev, ok := ev.(events.Message)
-			if !ok {
-				logrus.Warnf("unexpected event message: %q", ev)
-				continue
+		case ev, open := <-l:
+			if !open {
+				logrus.Debug("Events subscriber evicted, stop sending events")
+				return flush()
+			}
+			if err := reportDrops(); err != nil {
+				return err
 			}
-			if err := enc.Encode(jev); err != nil {
+			if err := deliver(ev); err != nil {
 				return err
 			}
+			if opts.batchInterval == 0 && len(l) == 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-tick:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-dropTick:
+			if err := reportDrops(); err != nil {
+				return err
+			}
+			if opts.batchInterval == 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
 		case <-timer.C:
-			return nil
-		case <-closeNotify:
+			return flush()
+		case <-stop:
 			logrus.Debug("Client disconnected, stop sending events")
 			return nil
 		}
 	}
 }
 
+func (s *systemRouter) getEventsHistory(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	since, sinceNano, err := timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return err
+	}
+	until, untilNano, err := timetypes.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return err
+	}
+	ef, err := parseEventsFilter(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+	owner, acl, err := s.eventsAccess(ctx, r)
+	if err != nil {
+		return err
+	}
+	var limit int
+	if l := r.Form.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("bad parameter: invalid limit %q", l)
+		}
+	}
+
+	history, err := s.backend.EventsHistory(daemonevents.HistoryOptions{
+		Since:     since,
+		SinceNano: sinceNano,
+		Until:     until,
+		UntilNano: untilNano,
+		Filter:    ef,
+		Limit:     limit,
+		Cursor:    r.Form.Get("cursor"),
+		Owner:     owner,
+		ACL:       acl,
+		Snapshot:  r.Form.Get("snapshot"),
+	})
+	if err != nil {
+		return err
+	}
+	schema := daemonevents.SchemaForAPIVersion(httputils.VersionFromContext(ctx))
+	anonymize := httputils.BoolValue(r, "anonymize")
+	for i, ev := range history.Events {
+		if anonymize {
+			ev = s.backend.EventsAnonymizer().Anonymize(ev)
+		}
+		history.Events[i] = daemonevents.Translate(ev, schema)
+	}
+	return httputils.WriteJSON(w, http.StatusOK, history)
+}
+
+func (s *systemRouter) getEventsDiff(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	since, sinceNano, err := timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return err
+	}
+	until, untilNano, err := timetypes.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return err
+	}
+	owner, acl, err := s.eventsAccess(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	diff, err := s.backend.EventsDiff(daemonevents.DiffOptions{
+		Since:     since,
+		SinceNano: sinceNano,
+		Until:     until,
+		UntilNano: untilNano,
+		Owner:     owner,
+		ACL:       acl,
+	})
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, diff)
+}
+
+func (s *systemRouter) getEventsSearch(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	query, err := daemonevents.ParseQuery(r.Form.Get("q"), time.Now())
+	if err != nil {
+		return err
+	}
+	owner, acl, err := s.eventsAccess(ctx, r)
+	if err != nil {
+		return err
+	}
+	var limit int
+	if l := r.Form.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("bad parameter: invalid limit %q", l)
+		}
+	}
+
+	history, err := s.backend.EventsSearch(daemonevents.SearchOptions{
+		Query:  query,
+		Limit:  limit,
+		Cursor: r.Form.Get("cursor"),
+		Owner:  owner,
+		ACL:    acl,
+	})
+	if err != nil {
+		return err
+	}
+	schema := daemonevents.SchemaForAPIVersion(httputils.VersionFromContext(ctx))
+	anonymize := httputils.BoolValue(r, "anonymize")
+	for i, ev := range history.Events {
+		if anonymize {
+			ev = s.backend.EventsAnonymizer().Anonymize(ev)
+		}
+		history.Events[i] = daemonevents.Translate(ev, schema)
+	}
+	return httputils.WriteJSON(w, http.StatusOK, history)
+}
+
+func (s *systemRouter) getEventsStats(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := s.checkEventsAdmin(ctx, r); err != nil {
+		return err
+	}
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	windows, err := daemonevents.ParseStatsWindows(r.Form.Get("windows"))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.EventsStats(windows))
+}
+
+func (s *systemRouter) getEventsSubscribers(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	owner, err := s.eventsManager(ctx, r)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.EventsSubscribers(owner))
+}
+
+func (s *systemRouter) getEventsExporters(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := s.checkEventsAdmin(ctx, r); err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.EventsExporters())
+}
+
+func (s *systemRouter) deleteEventsSubscriber(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	owner, err := s.eventsManager(ctx, r)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad parameter: invalid subscriber ID %q", vars["id"])
+	}
+	if err := s.backend.EvictEventsSubscriber(id, owner); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *systemRouter) postEventsSubscriberPause(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	owner, err := s.eventsManager(ctx, r)
+	if err != nil {
+		return err
+	}
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad parameter: invalid subscriber ID %q", vars["id"])
+	}
+	var limit int
+	if l := r.Form.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("bad parameter: limit must be a positive number, got %q", l)
+		}
+	}
+	if err := s.backend.PauseEventsSubscriber(id, limit, owner); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *systemRouter) postEventsSubscriberResume(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	owner, err := s.eventsManager(ctx, r)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad parameter: invalid subscriber ID %q", vars["id"])
+	}
+	if err := s.backend.ResumeEventsSubscriber(id, owner); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *systemRouter) getEventsExport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	since, sinceNano, err := timetypes.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return err
+	}
+	until, untilNano, err := timetypes.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return err
+	}
+	owner, acl, err := s.eventsAccess(ctx, r)
+	if err != nil {
+		return err
+	}
+	opts := daemonevents.SnapshotOptions{
+		Since:     since,
+		SinceNano: sinceNano,
+		Until:     until,
+		UntilNano: untilNano,
+		Owner:     owner,
+		ACL:       acl,
+	}
+	if httputils.BoolValue(r, "anonymize") {
+		opts.Anonymizer = s.backend.EventsAnonymizer()
+	}
+
+	w.Header().Set("Content-Type", "application/x-gzip")
+	output := ioutils.NewWriteFlusher(w)
+	defer output.Close()
+	return s.backend.ExportEvents(output, opts)
+}
+
+func (s *systemRouter) postEventsImport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := s.checkEventsAdmin(ctx, r); err != nil {
+		return err
+	}
+	snapshot, err := s.backend.ImportEvents(r.Body)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, snapshot)
+}
+
+func (s *systemRouter) getMetrics(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := s.checkEventsAdmin(ctx, r); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	return writeEventsMetrics(w, s.backend.EventsMetrics())
+}
+
 func (s *systemRouter) postAuth(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	var config *types.AuthConfig
 	err := json.NewDecoder(r.Body).Decode(&config)
@@ -0,0 +1,442 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/events"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/engine-api/types"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+// fakeBackend implements the methods of the backend managing the events
+// subscribers, the others panic. The subscribers are identified by their
+// ID, and owned by the tenants of owners. The subscribers to the events
+// receive the past events, then the events of stream.
+type fakeBackend struct {
+	Backend
+	admins  map[string]bool
+	scoped  bool
+	owners  map[uint64]string
+	evicted []uint64
+	past    []events.Message
+	stream  chan events.Message
+	dropped uint64
+	// subscribed are the options of the last subscription.
+	subscribed daemonevents.SubscribeOptions
+}
+
+func (b *fakeBackend) SubscribeToEvents(ctx context.Context, opts daemonevents.SubscribeOptions) ([]events.Message, <-chan events.Message) {
+	b.subscribed = opts
+	return b.past, b.stream
+}
+
+func (b *fakeBackend) EventsACL(identity string) *daemonevents.ACL {
+	return nil
+}
+
+func (b *fakeBackend) DroppedEvents(<-chan events.Message) uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+func (b *fakeBackend) EventsAdmin(identity string) bool {
+	return b.admins[identity]
+}
+
+func (b *fakeBackend) SystemInfo() (*types.Info, error) {
+	return &types.Info{ID: "daemon", NEventsListener: 2}, nil
+}
+
+func (b *fakeBackend) EventsExporters() []events.ExporterStatus {
+	return []events.ExporterStatus{{Name: "fluentd", Connected: true}}
+}
+
+func (b *fakeBackend) EventsMemory() events.Memory {
+	return events.Memory{Buffered: 4096}
+}
+
+func (b *fakeBackend) EventsTenantScoping() bool {
+	return b.scoped
+}
+
+func (b *fakeBackend) find(id uint64, owner string) error {
+	if o, ok := b.owners[id]; !ok || (owner != "" && o != owner) {
+		return fmt.Errorf("no such events subscriber: %d", id)
+	}
+	return nil
+}
+
+func (b *fakeBackend) EventsSubscribers(owner string) []events.Subscriber {
+	var list []events.Subscriber
+	for id, o := range b.owners {
+		if owner == "" || o == owner {
+			list = append(list, events.Subscriber{ID: id})
+		}
+	}
+	return list
+}
+
+func (b *fakeBackend) EvictEventsSubscriber(id uint64, owner string) error {
+	if err := b.find(id, owner); err != nil {
+		return err
+	}
+	b.evicted = append(b.evicted, id)
+	return nil
+}
+
+func (b *fakeBackend) PauseEventsSubscriber(id uint64, limit int, owner string) error {
+	return b.find(id, owner)
+}
+
+func (b *fakeBackend) ResumeEventsSubscriber(id uint64, owner string) error {
+	return b.find(id, owner)
+}
+
+// serve calls the handler of r as the user authenticated by the
+// authorization plugins, and returns the status code of the response.
+func serve(t *testing.T, handler func(context.Context, http.ResponseWriter, *http.Request, map[string]string) error, user, method string, vars map[string]string) int {
+	code, _ := serveBody(t, handler, user, method, vars)
+	return code
+}
+
+// serveBody is serve, returning the body of the response too.
+func serveBody(t *testing.T, handler func(context.Context, http.ResponseWriter, *http.Request, map[string]string) error, user, method string, vars map[string]string) (int, []byte) {
+	r, err := http.NewRequest(method, "/system/events/subscribers", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), httputils.UserKey, user)
+	w := httptest.NewRecorder()
+	if err := handler(ctx, w, r, vars); err != nil {
+		httputils.WriteError(w, err)
+	}
+	return w.Code, w.Body.Bytes()
+}
+
+func TestEventsSubscribersAdmin(t *testing.T) {
+	b := &fakeBackend{admins: map[string]bool{"operator": true}, owners: map[uint64]string{1: ""}}
+	s := &systemRouter{backend: b}
+	vars := map[string]string{"id": "1"}
+
+	for _, c := range []struct {
+		handler  func(context.Context, http.ResponseWriter, *http.Request, map[string]string) error
+		method   string
+		expected int
+	}{
+		{s.getEventsSubscribers, "GET", http.StatusOK},
+		{s.deleteEventsSubscriber, "DELETE", http.StatusNoContent},
+		{s.postEventsSubscriberPause, "POST", http.StatusNoContent},
+		{s.postEventsSubscriberResume, "POST", http.StatusNoContent},
+	} {
+		if code := serve(t, c.handler, "operator", c.method, vars); code != c.expected {
+			t.Fatalf("Expected %d for the admin, got %d", c.expected, code)
+		}
+		if code := serve(t, c.handler, "monitoring", c.method, vars); code != http.StatusForbidden {
+			t.Fatalf("Expected %d for a client that is not an admin, got %d", http.StatusForbidden, code)
+		}
+	}
+	if len(b.evicted) != 1 {
+		t.Fatalf("Expected the subscriber evicted once, got %v", b.evicted)
+	}
+}
+
+func TestEventsSubscribersTenants(t *testing.T) {
+	b := &fakeBackend{scoped: true, owners: map[uint64]string{1: "alice", 2: "bob"}}
+	s := &systemRouter{backend: b}
+
+	// Each tenant only sees its own subscribers.
+	for tenant, id := range map[string]uint64{"alice": 1, "bob": 2} {
+		code, body := serveBody(t, s.getEventsSubscribers, tenant, "GET", nil)
+		var list []events.Subscriber
+		if err := json.Unmarshal(body, &list); err != nil || code != http.StatusOK {
+			t.Fatalf("Unexpected response %d %s, %v", code, body, err)
+		}
+		if len(list) != 1 || list[0].ID != id {
+			t.Fatalf("Expected only the subscriber %d of %s, got %+v", id, tenant, list)
+		}
+	}
+
+	// A tenant cannot manage the subscribers of another tenant.
+	bob := map[string]string{"id": "2"}
+	for _, c := range []struct {
+		handler func(context.Context, http.ResponseWriter, *http.Request, map[string]string) error
+		method  string
+	}{
+		{s.deleteEventsSubscriber, "DELETE"},
+		{s.postEventsSubscriberPause, "POST"},
+		{s.postEventsSubscriberResume, "POST"},
+	} {
+		if code := serve(t, c.handler, "alice", c.method, bob); code != http.StatusNotFound {
+			t.Fatalf("Expected %d managing the subscriber of another tenant, got %d", http.StatusNotFound, code)
+		}
+		if code := serve(t, c.handler, "bob", c.method, bob); code != http.StatusNoContent {
+			t.Fatalf("Expected %d managing its own subscriber, got %d", http.StatusNoContent, code)
+		}
+		if code := serve(t, c.handler, "", c.method, bob); code != http.StatusForbidden {
+			t.Fatalf("Expected %d for a client that is not a tenant, got %d", http.StatusForbidden, code)
+		}
+	}
+	if len(b.evicted) != 1 || b.evicted[0] != 2 {
+		t.Fatalf("Expected only the subscriber of bob evicted, got %v", b.evicted)
+	}
+
+	// The statistics of the whole daemon are only for the admins.
+	for _, handler := range []func(context.Context, http.ResponseWriter, *http.Request, map[string]string) error{
+		s.getEventsStats,
+		s.getEventsExporters,
+		s.getMetrics,
+		s.postEventsImport,
+	} {
+		if code := serve(t, handler, "alice", "GET", nil); code != http.StatusForbidden {
+			t.Fatalf("Expected %d for a tenant, got %d", http.StatusForbidden, code)
+		}
+	}
+}
+
+func TestInfoEvents(t *testing.T) {
+	s := &systemRouter{backend: &fakeBackend{}}
+	code, body := serveBody(t, s.getInfo, "", "GET", nil)
+	if code != http.StatusOK {
+		t.Fatalf("Unexpected response %d %s", code, body)
+	}
+	var info struct {
+		ID              string
+		NEventsListener int
+		EventsExporters []events.ExporterStatus
+		EventsMemory    events.Memory
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "daemon" || info.NEventsListener != 2 {
+		t.Fatalf("Expected the system information, got %s", body)
+	}
+	if len(info.EventsExporters) != 1 || info.EventsExporters[0].Name != "fluentd" || info.EventsMemory.Buffered != 4096 {
+		t.Fatalf("Expected the information on the events, got %s", body)
+	}
+}
+
+// streamBatches runs streamEvents with opts, and returns the batches of
+// events it sends, closed once it returned, and the error it returned. The
+// events sent one by one are returned as batches of one event.
+func streamBatches(b *fakeBackend, opts streamOptions) (<-chan []events.Message, <-chan error) {
+	s := &systemRouter{backend: b}
+	batches := make(chan []events.Message, 100)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.streamEvents(context.Background(), opts, func(v interface{}) error {
+			switch m := v.(type) {
+			case events.Message:
+				batches <- []events.Message{m}
+			case []events.Message:
+				batches <- m
+			}
+			return nil
+		}, make(chan bool))
+		close(batches)
+	}()
+	return batches, errc
+}
+
+// nextBatch returns the next batch sent, or fails after 5 seconds.
+func nextBatch(t *testing.T, batches <-chan []events.Message) []events.Message {
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for a batch of events")
+	}
+	return nil
+}
+
+func newEvents(n int) []events.Message {
+	evs := make([]events.Message, n)
+	for i := range evs {
+		evs[i] = events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start"}, Sequence: uint64(i + 1)}
+	}
+	return evs
+}
+
+func TestStreamEventsBatchSize(t *testing.T) {
+	evs := newEvents(8)
+	b := &fakeBackend{past: evs[:5], stream: make(chan events.Message, 3)}
+	for _, ev := range evs[5:] {
+		b.stream <- ev
+	}
+	close(b.stream)
+	batches, errc := streamBatches(b, streamOptions{batchSize: 2, batchInterval: time.Hour})
+
+	// The past events are sent in full batches, the last one is sent
+	// before the new events.
+	var sizes []int
+	var last uint64
+	for batch := range batches {
+		sizes = append(sizes, len(batch))
+		for _, ev := range batch {
+			if ev.Sequence != last+1 {
+				t.Fatalf("Expected the event %d, got %d", last+1, ev.Sequence)
+			}
+			last = ev.Sequence
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(sizes) != "[2 2 1 2 1]" {
+		t.Fatalf("Expected batches of at most 2 events, got %v", sizes)
+	}
+}
+
+func TestStreamEventsBatchInterval(t *testing.T) {
+	b := &fakeBackend{stream: make(chan events.Message)}
+	batches, errc := streamBatches(b, streamOptions{batchSize: 100, batchInterval: 10 * time.Millisecond})
+
+	// A batch that is not full is sent once the interval elapsed.
+	for _, ev := range newEvents(2) {
+		b.stream <- ev
+	}
+	if batch := nextBatch(t, batches); len(batch) != 2 {
+		t.Fatalf("Expected the 2 events sent after the interval, got %d", len(batch))
+	}
+	close(b.stream)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if batch, open := <-batches; open {
+		t.Fatalf("Expected no empty batch sent, got %v", batch)
+	}
+}
+
+func TestStreamEventsBatchClose(t *testing.T) {
+	b := &fakeBackend{stream: make(chan events.Message)}
+	batches, errc := streamBatches(b, streamOptions{batchSize: 100, batchInterval: time.Hour})
+
+	// The batch is sent when the subscriber is evicted.
+	for _, ev := range newEvents(3) {
+		b.stream <- ev
+	}
+	select {
+	case batch := <-batches:
+		t.Fatalf("Unexpected batch sent before the interval, got %d events", len(batch))
+	default:
+	}
+	close(b.stream)
+	if batch := nextBatch(t, batches); len(batch) != 3 {
+		t.Fatalf("Expected the 3 events sent on close, got %d", len(batch))
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventsOptionsTailReplay(t *testing.T) {
+	for query, expected := range map[string]streamOptions{
+		"tail=20":                 {SubscribeOptions: daemonevents.SubscribeOptions{Tail: 20}},
+		"since=100&replay=10x":    {replay: 10},
+		"resume_after=7&replay=1": {replay: 1},
+		"since=100&replay=0.5":    {replay: 0.5},
+	} {
+		r, err := http.NewRequest("GET", "/events?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := httputils.ParseForm(r); err != nil {
+			t.Fatal(err)
+		}
+		opts, err := eventsOptions(context.Background(), r)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", query, err)
+		}
+		if opts.Tail != expected.Tail || opts.replay != expected.replay {
+			t.Fatalf("Expected the tail %d and the replay %v for %q, got %d and %v", expected.Tail, expected.replay, query, opts.Tail, opts.replay)
+		}
+	}
+
+	for _, query := range []string{
+		"tail=0",
+		"tail=-1",
+		"tail=last",
+		"replay=10x",
+		"since=100&replay=0",
+		"since=100&replay=fast",
+		"since=100&replay=1001",
+		"policy=drop-all",
+		"timeout=soon",
+		"timeout=0",
+		"timeout=-1s",
+		"priority=urgent",
+	} {
+		r, err := http.NewRequest("GET", "/events?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := httputils.ParseForm(r); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := eventsOptions(context.Background(), r); err == nil || !strings.HasPrefix(err.Error(), "bad parameter: ") {
+			t.Fatalf("Expected a bad parameter error for %q, got %v", query, err)
+		}
+	}
+}
+
+func TestStreamEventsReplay(t *testing.T) {
+	past := newEvents(3)
+	for i := range past {
+		past[i].TimeNano = int64(i) * int64(time.Second)
+	}
+	// The new events are not sent, the stream ends after the past ones.
+	b := &fakeBackend{past: past, stream: make(chan events.Message, 1)}
+	b.stream <- events.Message{Message: eventtypes.Message{Action: "new"}}
+	start := time.Now()
+	batches, errc := streamBatches(b, streamOptions{replay: 100, batchSize: 10, batchInterval: time.Hour})
+
+	// The events are replayed one by one at their pace, even in batches.
+	var replayed []events.Message
+	for batch := range batches {
+		if len(batch) != 1 {
+			t.Fatalf("Expected the events replayed one by one, got a batch of %d", len(batch))
+		}
+		replayed = append(replayed, batch[0])
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 3 || replayed[0].Sequence != 1 || replayed[2].Sequence != 3 {
+		t.Fatalf("Expected the 3 past events replayed in order, got %+v", replayed)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("Expected the 2 seconds between the events replayed in 20ms, took %s", d)
+	}
+}
+
+func TestStreamEventsReportDrops(t *testing.T) {
+	defer func(interval time.Duration) { dropReportInterval = interval }(dropReportInterval)
+	dropReportInterval = 10 * time.Millisecond
+
+	// The drops are reported even when no event follows them.
+	b := &fakeBackend{stream: make(chan events.Message)}
+	atomic.StoreUint64(&b.dropped, 3)
+	batches, errc := streamBatches(b, streamOptions{reportDropped: true})
+	batch := nextBatch(t, batches)
+	if len(batch) != 1 || batch[0].Action != "dropped" || batch[0].Actor.Attributes["count"] != "3" {
+		t.Fatalf("Expected 3 dropped events reported, got %+v", batch)
+	}
+
+	atomic.StoreUint64(&b.dropped, 5)
+	if batch := nextBatch(t, batches); batch[0].Actor.Attributes["count"] != "2" {
+		t.Fatalf("Expected 2 more dropped events reported, got %+v", batch)
+	}
+	close(b.stream)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,152 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+const (
+	// templateType is the media type of the events rendered by a template.
+	templateType = "text/plain; charset=utf-8"
+	// maxTemplateLength is the length of the longest template accepted.
+	maxTemplateLength = 4096
+	// maxRenderedLength is the length of the longest rendering of an
+	// event, the rendering stops there.
+	maxRenderedLength = 64 * 1024
+	// maxFormatWidth is the largest width or precision accepted by printf.
+	maxFormatWidth = 1024
+)
+
+// errRenderedTooLong stops the rendering of an event longer than
+// maxRenderedLength.
+var errRenderedTooLong = errors.New("rendered event is too long")
+
+// templateFuncs are the only functions available to the templates, besides
+// the builtin ones of text/template. printf replaces the builtin one to
+// bound the widths of its verbs, and no function builds a list, so that a
+// template cannot make the daemon allocate or loop beyond the size of the
+// event and of the template.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":   strings.Join,
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"title":  strings.Title,
+	"printf": boundedSprintf,
+	"truncate": func(n int, s string) string {
+		if n >= 0 && len(s) > n {
+			return s[:n]
+		}
+		return s
+	},
+}
+
+// boundedSprintf is fmt.Sprintf, for the formats whose widths and
+// precisions are at most maxFormatWidth.
+func boundedSprintf(format string, args ...interface{}) (string, error) {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0; i++ {
+			if format[i] == '*' {
+				return "", fmt.Errorf("printf: widths from arguments are not supported")
+			}
+			j := i
+			for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+				j++
+			}
+			if j > i {
+				if n, err := strconv.Atoi(format[i:j]); err != nil || n > maxFormatWidth {
+					return "", fmt.Errorf("printf: widths and precisions cannot be larger than %d", maxFormatWidth)
+				}
+				i = j - 1
+			}
+		}
+	}
+	return fmt.Sprintf(format, args...), nil
+}
+
+// parseEventsTemplate parses the template the client sent to render the
+// events. The template cannot define or include other templates.
+func parseEventsTemplate(format string) (*template.Template, error) {
+	if len(format) > maxTemplateLength {
+		return nil, fmt.Errorf("bad parameter: format cannot be longer than %d bytes", maxTemplateLength)
+	}
+	tmpl, err := template.New("").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("bad parameter: invalid format: %v", err)
+	}
+	if len(tmpl.Templates()) > 1 {
+		return nil, fmt.Errorf("bad parameter: format cannot define templates")
+	}
+	return tmpl, nil
+}
+
+// templateEncoder writes each event rendered by a template, followed by a
+// new line.
+type templateEncoder struct {
+	w    io.Writer
+	tmpl *template.Template
+	buf  limitedBuffer
+}
+
+// limitedBuffer is a buffer whose writes fail once it holds
+// maxRenderedLength bytes.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := maxRenderedLength - b.Len(); len(p) > n {
+		b.Buffer.Write(p[:n])
+		return n, errRenderedTooLong
+	}
+	return b.Buffer.Write(p)
+}
+
+func (e *templateEncoder) Encode(v interface{}) error {
+	switch m := v.(type) {
+	case events.Message:
+		return e.render(m)
+	case []events.Message:
+		for _, ev := range m {
+			if err := e.render(ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot encode %T as an event", v)
+}
+
+func (e *templateEncoder) render(ev events.Message) error {
+	e.buf.Reset()
+	if err := e.tmpl.Execute(&e.buf, ev); err != nil && !isRenderedTooLong(err) {
+		// The error is written in place of the event, so that the
+		// client sees why its template does not apply.
+		e.buf.Reset()
+		fmt.Fprintf(&e.buf.Buffer, "error: %v", err)
+	}
+	// An event rendered too long is cut at maxRenderedLength.
+	e.buf.Buffer.WriteByte('\n')
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// isRenderedTooLong returns true if err is errRenderedTooLong, which
+// text/template may wrap in an error naming the template.
+func isRenderedTooLong(err error) bool {
+	return err == errRenderedTooLong || strings.HasSuffix(err.Error(), errRenderedTooLong.Error())
+}
@@ -0,0 +1,73 @@
+package system
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestTemplateEncoder(t *testing.T) {
+	ev := events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "0123456789abcdef"}}}
+	cases := map[string]string{
+		"{{truncate 12 .Actor.ID}} {{.Action}}":    "0123456789ab start\n",
+		`{{printf "%-8s|%.3s" .Action .Actor.ID}}`: "start   |012\n",
+		`{{printf "100%%"}}`:                       "100%\n",
+	}
+	for format, expected := range cases {
+		tmpl, err := parseEventsTemplate(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := (&templateEncoder{w: &buf, tmpl: tmpl}).Encode(ev); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Fatalf("Expected %q rendered as %q, got %q", format, expected, buf.String())
+		}
+	}
+}
+
+func TestTemplateEncoderHostile(t *testing.T) {
+	// The templates cannot build lists to loop over.
+	if _, err := parseEventsTemplate(`{{range split (printf "%01000000d" 0) ""}}{{printf "%01000000d" 0}}{{end}}`); err == nil {
+		t.Fatal("Expected an error parsing a template using split")
+	}
+
+	for _, format := range []string{
+		`{{printf "%01000000d" 0}}`,
+		`{{printf "%.1000000f" 1.0}}`,
+		`{{printf "%*d" 1000000 0}}`,
+	} {
+		tmpl, err := parseEventsTemplate(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := (&templateEncoder{w: &buf, tmpl: tmpl}).Encode(events.Message{}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "error: ") || buf.Len() > 1024 {
+			t.Fatalf("Expected %q to fail, got %q", format, buf.String())
+		}
+	}
+
+	// The rendering stops at maxRenderedLength.
+	tmpl, err := parseEventsTemplate(strings.Repeat("{{.Actor.ID}}", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := events.Message{Message: eventtypes.Message{Actor: eventtypes.Actor{ID: strings.Repeat("x", 4096)}}}
+	var buf bytes.Buffer
+	enc := &templateEncoder{w: &buf, tmpl: tmpl}
+	if err := enc.Encode([]events.Message{ev, ev}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 3 || len(lines[0]) != maxRenderedLength || len(lines[1]) != maxRenderedLength || strings.Contains(lines[0], "error") {
+		t.Fatalf("Expected 2 events cut at %d bytes, got %d lines", maxRenderedLength, len(lines))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/server/router"
 	"github.com/docker/docker/api/server/router/build"
@@ -18,6 +19,7 @@ import (
 	"github.com/docker/docker/builder/dockerfile"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/pkg/authorization"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/utils"
 	"github.com/docker/go-connections/sockets"
 	"github.com/gorilla/mux"
@@ -28,6 +30,10 @@ import (
 // when a request is about to be served.
 const versionMatcher = "/v{version:[0-9.]+}"
 
+// maxCorrelationIDLength is the length of the longest correlation ID
+// accepted from clients.
+const maxCorrelationIDLength = 128
+
 // Config provides the configuration for the API server
 type Config struct {
 	Logging                  bool
@@ -47,6 +53,7 @@ type Server struct {
 	routers       []router.Router
 	authZPlugins  []authorization.Plugin
 	routerSwapper *routerSwapper
+	auditor       auditor
 }
 
 // Addr contains string representation of address and its protocol (tcp, unix...).
@@ -159,7 +166,14 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 		// apply to all requests. Data that is specific to the
 		// immediate function being called should still be passed
 		// as 'args' on the function call.
-		ctx := context.Background()
+		requestID := stringid.GenerateNonCryptoID()
+		correlationID := r.Header.Get(api.CorrelationIDHeader)
+		if correlationID == "" || len(correlationID) > maxCorrelationIDLength {
+			correlationID = requestID
+		}
+		w.Header().Set(api.CorrelationIDHeader, correlationID)
+		ctx := context.WithValue(context.Background(), httputils.RequestIDKey, requestID)
+		ctx = context.WithValue(ctx, httputils.CorrelationIDKey, correlationID)
 		handlerFunc := s.handleWithGlobalMiddlewares(handler)
 
 		vars := mux.Vars(r)
@@ -176,6 +190,7 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 
 // InitRouters initializes a list of routers for the server.
 func (s *Server) InitRouters(d *daemon.Daemon) {
+	s.auditor = d
 	s.addRouter(container.NewRouter(d))
 	s.addRouter(local.NewRouter(d))
 	s.addRouter(network.NewRouter(d))
@@ -0,0 +1,230 @@
+// Package events holds the types of the events API of the daemon that
+// github.com/docker/engine-api/types/events does not have yet. The
+// events themselves are the messages of engine-api, with the fields the
+// daemon adds to them.
+package events
+
+import "github.com/docker/engine-api/types/events"
+
+const (
+	// ContainerEventType is the event type that containers generate
+	ContainerEventType = events.ContainerEventType
+	// ImageEventType is the event type that images generate
+	ImageEventType = events.ImageEventType
+	// VolumeEventType is the event type that volumes generate
+	VolumeEventType = events.VolumeEventType
+	// NetworkEventType is the event type that networks generate
+	NetworkEventType = events.NetworkEventType
+	// DaemonEventType is the event type that daemon generate
+	DaemonEventType = "daemon"
+	// AuditEventType is the event type of privileged operations
+	AuditEventType = "audit"
+	// BuildEventType is the event type that builds generate
+	BuildEventType = "build"
+	// RegistryEventType is the event type of the interactions with the
+	// registries
+	RegistryEventType = "registry"
+)
+
+// Message is an event message of engine-api, with the fields the daemon
+// adds to the events.
+type Message struct {
+	events.Message
+
+	// Sequence is assigned by the daemon in increasing order, without
+	// gaps, to the events it generates.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	// Signature is the signature of the event by the daemon, when it
+	// signs the events, as the name of the algorithm and the signature
+	// encoded in base64, separated by a colon.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Subscriber describes a subscriber of the events stream.
+type Subscriber struct {
+	// ID identifies the subscriber until the daemon restarts.
+	ID uint64
+	// Client identifies the API client that subscribed, it is empty for
+	// the subscribers inside the daemon.
+	Client string `json:",omitempty"`
+	// Filters are the filters of the events sent to the subscriber.
+	Filters map[string][]string `json:",omitempty"`
+	// Policy is the delivery policy of the subscriber.
+	Policy string
+	// Created is the time the subscriber subscribed, in RFC 3339 format
+	// with nanoseconds.
+	Created string
+	// Queued is the number of events waiting to be received.
+	Queued int
+	// Dropped is the number of events not delivered to the subscriber.
+	Dropped uint64
+	// Paused is true if the delivery of events to the subscriber is
+	// paused.
+	Paused bool `json:",omitempty"`
+	// Timeout is how long the subscriber is currently waited for when it
+	// is not ready to receive an event, before applying its policy.
+	Timeout string `json:",omitempty"`
+	// Priority is the minimum priority of the events sent to the
+	// subscriber, it is not set when all the events are sent.
+	Priority string `json:",omitempty"`
+}
+
+// Memory is the memory held by the events in the daemon, in bytes.
+type Memory struct {
+	// Buffered is the memory held by the past events kept, and
+	// BufferLimit the largest memory they can hold, 0 when they are not
+	// limited by memory.
+	Buffered    int64
+	BufferLimit int64 `json:",omitempty"`
+	// Queued estimates the memory held by the events waiting to be
+	// read by the subscribers.
+	Queued int64
+}
+
+// ExporterStatus is the state of an exporter the daemon sends its events
+// to.
+type ExporterStatus struct {
+	// Name identifies the exporter, it is its type.
+	Name string
+	// Connected is true unless the last event failed to be exported.
+	Connected bool
+	// LastError is the error of the last event that failed to be
+	// exported, and LastErrorTime when it failed, in RFC 3339 format
+	// with nanoseconds.
+	LastError     string `json:",omitempty"`
+	LastErrorTime string `json:",omitempty"`
+	// LastExportTime is when the last event was exported, in RFC 3339
+	// format with nanoseconds.
+	LastExportTime string `json:",omitempty"`
+	// Exported and Failed are the number of events exported, and that
+	// failed to be exported.
+	Exported uint64
+	Failed   uint64
+	// Queued is the number of events waiting in the queue of the
+	// exporter.
+	Queued int
+	// Behind is the number of events logged but not exported yet,
+	// including the queued ones.
+	Behind int
+	// Dropped is the number of events dropped because the queue was
+	// full.
+	Dropped uint64
+	// Sequence is the sequence number of the last event exported.
+	Sequence uint64 `json:",omitempty"`
+}
+
+// History is a page of past events.
+type History struct {
+	Events []Message
+	// Cursor is the position of the next page of events, it is empty
+	// when there are no more events.
+	Cursor string `json:",omitempty"`
+}
+
+// ChangedObject is an object created or removed between two time points.
+type ChangedObject struct {
+	ID   string
+	Name string `json:",omitempty"`
+}
+
+// Changes are the objects of a type created and removed between two time
+// points. The objects created and removed in between are in neither.
+type Changes struct {
+	Created []ChangedObject
+	Removed []ChangedObject
+}
+
+// Diff are the net changes of the objects of the daemon between two time
+// points.
+type Diff struct {
+	Containers Changes
+	Images     Changes
+	Volumes    Changes
+	Networks   Changes
+}
+
+// StateSpan is a period of time a container spent in a state.
+type StateSpan struct {
+	// State is created, running, paused, restarting or exited.
+	State string
+	// Since and Until are the times the container entered and left the
+	// state, in RFC 3339 format with nanoseconds. Until is empty when
+	// the container is still in the state.
+	Since string
+	Until string `json:",omitempty"`
+	// Duration is the time spent in the state, until now when the
+	// container is still in it.
+	Duration string
+	// ExitCode is the exit code of the container, when it exited.
+	ExitCode string `json:",omitempty"`
+}
+
+// Timeline are the states a container went through, from the oldest.
+type Timeline struct {
+	ID     string
+	States []StateSpan
+	// Removed is true if the container was removed after the last state.
+	Removed bool `json:",omitempty"`
+}
+
+// Uptime is the availability of a container during a period of time.
+type Uptime struct {
+	ID string
+	// Since and Until are the period the availability is measured over,
+	// in RFC 3339 format with nanoseconds. It starts when the container
+	// was created, if later than requested, and ends when it was
+	// removed, if earlier. They are empty when the container has no
+	// state during the period.
+	Since string `json:",omitempty"`
+	Until string `json:",omitempty"`
+	// Availability is the percentage of the period the container was
+	// running.
+	Availability float64
+	// Restarts is the number of times the container started again after
+	// it exited during the period.
+	Restarts int
+	// Outages is the number of periods the container was not running,
+	// and LongestOutage the duration of the longest one.
+	Outages       int
+	LongestOutage string
+}
+
+// WindowStats are the number of events logged during a time window.
+type WindowStats struct {
+	// Window is the duration of the window, ending now.
+	Window string
+	// Total is the number of events of the window.
+	Total uint64
+	// Counts are the number of events of the window by type and by
+	// action.
+	Counts map[string]map[string]uint64
+	// Shed is the number of verbose events dropped during the window
+	// because too many events were waiting to be published, they are not
+	// counted in Total.
+	Shed uint64 `json:",omitempty"`
+}
+
+// Snapshot describes a portion of the events journal of a daemon exported
+// to be imported in another one.
+type Snapshot struct {
+	// ID identifies the snapshot in the daemon it is imported in, it is
+	// not set when it is exported.
+	ID string `json:",omitempty"`
+	// Version is the version of the format of the snapshot.
+	Version int
+	// Schema is the version of the form of the events of the snapshot.
+	Schema int
+	// Created is the time the snapshot was exported, in RFC 3339 format
+	// with nanoseconds.
+	Created string
+	// Since and Until are the timestamps the events were exported
+	// between, they are not set when the export was not bounded.
+	Since string `json:",omitempty"`
+	Until string `json:",omitempty"`
+	// Events is the number of events of the snapshot, and FirstSequence
+	// and LastSequence the sequence numbers of the first and last ones.
+	Events        int
+	FirstSequence uint64
+	LastSequence  uint64
+}
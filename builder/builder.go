@@ -132,6 +132,13 @@ type Backend interface {
 	CopyOnBuild(containerID string, destPath string, src FileInfo, decompress bool) error
 }
 
+// EventLogger abstracts the logging of the events of a build.
+type EventLogger interface {
+	// LogBuildEvent generates an event about the build identified by
+	// buildID.
+	LogBuildEvent(buildID, action string, attributes map[string]string)
+}
+
 // Image represents a Docker image used by the builder.
 type Image interface {
 	ImageID() string
@@ -68,6 +68,7 @@ type Builder struct {
 	cancelled        chan struct{}
 	cancelOnce       sync.Once
 	allowedBuildArgs map[string]bool // list of build-time args that are allowed for expansion/substitution and passing to commands in 'run'.
+	step             int             // 1-based index of the step being dispatched, 0 before the first one.
 
 	// TODO: remove once docker.Commit can receive a tag
 	id string
@@ -160,7 +161,19 @@ func (bm *BuildManager) Build(config *types.ImageBuildOptions, context builder.C
 	if err != nil {
 		return "", err
 	}
+	b.logEvent("start", map[string]string{
+		"tags":       strings.Join(config.Tags, ","),
+		"dockerfile": config.Dockerfile,
+	})
 	img, err := b.build(config, context, stdout, stderr, out, clientGone)
+	if err != nil {
+		b.logStepEvent("failure", map[string]string{"error": err.Error()})
+	} else {
+		b.logEvent("success", map[string]string{
+			"image": img,
+			"tags":  strings.Join(config.Tags, ","),
+		})
+	}
 	return img, err
 
 }
@@ -220,12 +233,14 @@ func (b *Builder) build(config *types.ImageBuildOptions, context builder.Context
 		default:
 			// Not cancelled yet, keep going...
 		}
+		b.step = i + 1
 		if err := b.dispatch(i, n); err != nil {
 			if b.options.ForceRemove {
 				b.clearTmp()
 			}
 			return "", err
 		}
+		b.logStepEvent("step", map[string]string{"image": b.image})
 		shortImgID = stringid.TruncateID(b.image)
 		fmt.Fprintf(b.Stdout, " ---> %s\n", shortImgID)
 		if b.options.Remove {
@@ -259,6 +274,29 @@ func (b *Builder) build(config *types.ImageBuildOptions, context builder.Context
 	return b.image, nil
 }
 
+// logEvent generates an event about the build, when the backend supports
+// it. The attributes without a value are left out.
+func (b *Builder) logEvent(action string, attributes map[string]string) {
+	if l, ok := b.docker.(builder.EventLogger); ok {
+		for k, v := range attributes {
+			if v == "" {
+				delete(attributes, k)
+			}
+		}
+		l.LogBuildEvent(b.id, action, attributes)
+	}
+}
+
+// logStepEvent generates an event about the step being dispatched, with
+// its number, such as 2/5, and its Dockerfile instruction.
+func (b *Builder) logStepEvent(action string, attributes map[string]string) {
+	if b.step > 0 && b.dockerfile != nil && b.step <= len(b.dockerfile.Children) {
+		attributes["step"] = fmt.Sprintf("%d/%d", b.step, len(b.dockerfile.Children))
+		attributes["instruction"] = b.dockerfile.Children[b.step-1].Original
+	}
+	b.logEvent(action, attributes)
+}
+
 // Cancel cancels an ongoing Dockerfile build.
 func (b *Builder) Cancel() {
 	b.cancelOnce.Do(func() {
@@ -0,0 +1,94 @@
+package dockerfile
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/builder"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+)
+
+// eventsBackend only implements the parts of the backend used by builds
+// from scratch of images made of cached LABEL steps.
+type eventsBackend struct {
+	builder.Backend
+	events []buildEvent
+}
+
+type buildEvent struct {
+	id         string
+	action     string
+	attributes map[string]string
+}
+
+func (b *eventsBackend) LogBuildEvent(buildID, action string, attributes map[string]string) {
+	b.events = append(b.events, buildEvent{buildID, action, attributes})
+}
+
+func (b *eventsBackend) GetCachedImageOnBuild(parentID string, cfg *container.Config) (string, error) {
+	if _, ok := cfg.Labels["miss"]; ok {
+		return "", nil
+	}
+	return "sha256:cached", nil
+}
+
+func (b *eventsBackend) ContainerCreate(types.ContainerCreateConfig) (types.ContainerCreateResponse, error) {
+	return types.ContainerCreateResponse{}, errors.New("cannot create containers")
+}
+
+// dockerfileContext is a build context holding only a Dockerfile.
+type dockerfileContext string
+
+func (c dockerfileContext) Close() error {
+	return nil
+}
+
+func (c dockerfileContext) Stat(path string) (string, builder.FileInfo, error) {
+	if path != "Dockerfile" {
+		return "", nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	return path, nil, nil
+}
+
+func (c dockerfileContext) Open(path string) (io.ReadCloser, error) {
+	if path != "Dockerfile" {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(strings.NewReader(string(c))), nil
+}
+
+func (c dockerfileContext) Walk(root string, walkFn builder.WalkFunc) error {
+	return nil
+}
+
+func TestBuildEvents(t *testing.T) {
+	backend := &eventsBackend{}
+	bm := NewBuildManager(backend)
+	context := dockerfileContext("FROM scratch\nLABEL hit=1\nLABEL miss=1\n")
+	config := &types.ImageBuildOptions{Tags: []string{"test:latest"}}
+	if _, err := bm.Build(config, context, ioutil.Discard, ioutil.Discard, ioutil.Discard, nil); err == nil {
+		t.Fatal("Expected the build to fail on the cache miss")
+	}
+
+	if len(backend.events) == 0 {
+		t.Fatal("Expected build events")
+	}
+	id := backend.events[0].id
+	expected := []buildEvent{
+		{id, "start", map[string]string{"tags": "test:latest"}},
+		{id, "step", map[string]string{"step": "1/3", "instruction": "FROM scratch"}},
+		{id, "cache_hit", map[string]string{"step": "2/3", "instruction": "LABEL hit=1", "image": "sha256:cached"}},
+		{id, "step", map[string]string{"step": "2/3", "instruction": "LABEL hit=1", "image": "sha256:cached"}},
+		{id, "cache_miss", map[string]string{"step": "3/3", "instruction": "LABEL miss=1"}},
+		{id, "failure", map[string]string{"step": "3/3", "instruction": "LABEL miss=1", "error": "cannot create containers"}},
+	}
+	if !reflect.DeepEqual(backend.events, expected) {
+		t.Fatalf("Expected events\n%v\ngot\n%v", expected, backend.events)
+	}
+}
@@ -476,12 +476,14 @@ func (b *Builder) probeCache() (bool, error) {
 	if len(cache) == 0 {
 		logrus.Debugf("[BUILDER] Cache miss: %s", b.runConfig.Cmd)
 		b.cacheBusted = true
+		b.logStepEvent("cache_miss", map[string]string{})
 		return false, nil
 	}
 
 	fmt.Fprintf(b.Stdout, " ---> Using cache\n")
 	logrus.Debugf("[BUILDER] Use cached version: %s", b.runConfig.Cmd)
 	b.image = string(cache)
+	b.logStepEvent("cache_hit", map[string]string{"image": b.image})
 
 	return true, nil
 }
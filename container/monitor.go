@@ -3,6 +3,7 @@ package container
 import (
 	"io"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -26,6 +27,9 @@ const (
 type supervisor interface {
 	// LogContainerEvent generates events related to a given container
 	LogContainerEvent(*Container, string)
+	// LogContainerEventWithAttributes generates events related to a given
+	// container with specific attributes
+	LogContainerEventWithAttributes(*Container, string, map[string]string)
 	// Cleanup ensures that the container is properly unmounted
 	Cleanup(*Container)
 	// StartLogging starts the logging driver for the container
@@ -57,6 +61,10 @@ type containerMonitor struct {
 	// start in a row
 	failureCount int
 
+	// gaveUp is set when the restart policy stopped restarting the
+	// container because it failed too many times in a row
+	gaveUp bool
+
 	// shouldStop signals the monitor that the next time the container exits it is
 	// either because docker or the user asked for the container to be stopped
 	shouldStop bool
@@ -220,6 +228,11 @@ func (m *containerMonitor) start() error {
 		if m.shouldRestart(exitStatus.ExitCode) {
 			m.container.SetRestartingLocking(&exitStatus)
 			m.logEvent("die")
+			if m.timeIncrement > defaultTimeIncrement {
+				m.logRestartEvent("restart_backoff", exitStatus.ExitCode)
+			} else {
+				m.logRestartEvent("restart_scheduled", exitStatus.ExitCode)
+			}
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -235,6 +248,9 @@ func (m *containerMonitor) start() error {
 		}
 
 		m.logEvent("die")
+		if m.gaveUp {
+			m.logRestartEvent("restart_giveup", exitStatus.ExitCode)
+		}
 		m.resetContainer(true)
 		return err
 	}
@@ -291,6 +307,7 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 		if max := m.restartPolicy.MaximumRetryCount; max != 0 && m.failureCount > max {
 			logrus.Debugf("stopping restart of container %s because maximum failure could of %d has been reached",
 				stringid.TruncateID(m.container.ID), max)
+			m.gaveUp = true
 			return false
 		}
 
@@ -398,3 +415,21 @@ func (m *containerMonitor) resetContainer(lock bool) {
 func (m *containerMonitor) logEvent(action string) {
 	m.supervisor.LogContainerEvent(m.container, action)
 }
+
+// logRestartEvent logs a decision of the restart policy, with the number of
+// the restart attempt and the delay before it, or the number of failures
+// when the policy gave up on the container.
+func (m *containerMonitor) logRestartEvent(action string, exitCode int) {
+	attributes := map[string]string{
+		"policy":   m.restartPolicy.Name,
+		"exitCode": strconv.Itoa(exitCode),
+	}
+	if m.gaveUp {
+		attributes["failures"] = strconv.Itoa(m.failureCount)
+		attributes["maxRetries"] = strconv.Itoa(m.restartPolicy.MaximumRetryCount)
+	} else {
+		attributes["attempt"] = strconv.Itoa(m.container.RestartCount + 1)
+		attributes["delay"] = (time.Duration(m.timeIncrement) * time.Millisecond).String()
+	}
+	m.supervisor.LogContainerEventWithAttributes(m.container, action, attributes)
+}
@@ -0,0 +1,80 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/engine-api/types/container"
+)
+
+type monitorEvent struct {
+	action     string
+	attributes map[string]string
+}
+
+// fakeSupervisor runs the container once per exit code, and records the
+// events of the monitor.
+type fakeSupervisor struct {
+	exitCodes []int
+	// long are the runs lasting long enough to reset the restart delay.
+	long   map[int]bool
+	events []monitorEvent
+}
+
+func (s *fakeSupervisor) LogContainerEvent(c *Container, action string) {
+	s.events = append(s.events, monitorEvent{action: action})
+}
+
+func (s *fakeSupervisor) LogContainerEventWithAttributes(c *Container, action string, attributes map[string]string) {
+	s.events = append(s.events, monitorEvent{action, attributes})
+}
+
+func (s *fakeSupervisor) Cleanup(*Container) {}
+
+func (s *fakeSupervisor) StartLogging(*Container) error { return nil }
+
+func (s *fakeSupervisor) Run(c *Container, pipes *execdriver.Pipes, startCallback execdriver.DriverCallback) (execdriver.ExitStatus, error) {
+	run := c.RestartCount
+	if s.long[run] {
+		c.monitor.lastStartTime = time.Now().Add(-time.Minute)
+	}
+	return execdriver.ExitStatus{ExitCode: s.exitCodes[run]}, nil
+}
+
+func (s *fakeSupervisor) IsShuttingDown() bool { return false }
+
+func TestMonitorRestartEvents(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-monitor-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := NewBaseContainer("container_id", root)
+	c.Config = &container.Config{}
+	c.HostConfig = &container.HostConfig{}
+	c.Command = &execdriver.Command{}
+	s := &fakeSupervisor{exitCodes: []int{1, 1, 1}, long: map[int]bool{0: true}}
+	if err := c.StartMonitor(s, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []monitorEvent{
+		{action: "start"},
+		{action: "die"},
+		{"restart_scheduled", map[string]string{"policy": "on-failure", "exitCode": "1", "attempt": "1", "delay": "100ms"}},
+		{action: "start"},
+		{action: "die"},
+		{"restart_backoff", map[string]string{"policy": "on-failure", "exitCode": "1", "attempt": "2", "delay": "200ms"}},
+		{action: "start"},
+		{action: "die"},
+		{"restart_giveup", map[string]string{"policy": "on-failure", "exitCode": "1", "failures": "3", "maxRetries": "2"}},
+	}
+	if !reflect.DeepEqual(s.events, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, s.events)
+	}
+}
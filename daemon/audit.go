@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"strconv"
+	"strings"
+
+	daemonevents "github.com/docker/docker/daemon/events"
+)
+
+// LogAuditEvent generates an audit event about the object id, on behalf of
+// the API client identified by client.
+func (daemon *Daemon) LogAuditEvent(action, id, client string, attributes map[string]string) {
+	if client != "" {
+		attributes["client"] = client
+	}
+	daemon.EventsService.Publish(daemonevents.AuditTopic, action, id, attributes)
+}
+
+// LogContainerStartAudit generates the audit events of the privileges the
+// container started on behalf of client was given: privileged mode, bind
+// mounts of host directories, added capabilities and host devices.
+func (daemon *Daemon) LogContainerStartAudit(name, client string) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	container.Lock()
+	hostConfig := container.HostConfig
+	var binds []map[string]string
+	for _, m := range container.MountPoints {
+		if m.Name != "" || m.Source == "" {
+			continue
+		}
+		binds = append(binds, map[string]string{
+			"source":      m.Source,
+			"destination": m.Destination,
+			"rw":          strconv.FormatBool(m.RW),
+		})
+	}
+	container.Unlock()
+
+	attributes := func() map[string]string {
+		return map[string]string{
+			"container": container.ID,
+			"name":      strings.TrimLeft(container.Name, "/"),
+			"image":     container.Config.Image,
+		}
+	}
+
+	if hostConfig.Privileged {
+		daemon.LogAuditEvent("privileged", container.ID, client, attributes())
+	}
+	for _, b := range binds {
+		a := attributes()
+		for k, v := range b {
+			a[k] = v
+		}
+		daemon.LogAuditEvent("bind_mount", container.ID, client, a)
+	}
+	if caps := hostConfig.CapAdd.Slice(); len(caps) > 0 {
+		a := attributes()
+		a["capabilities"] = strings.Join(caps, ",")
+		daemon.LogAuditEvent("cap_add", container.ID, client, a)
+	}
+	for _, d := range hostConfig.Devices {
+		a := attributes()
+		a["pathOnHost"] = d.PathOnHost
+		a["pathInContainer"] = d.PathInContainer
+		a["permissions"] = d.CgroupPermissions
+		daemon.LogAuditEvent("device", container.ID, client, a)
+	}
+	return nil
+}
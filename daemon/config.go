@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/discovery"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -52,24 +53,56 @@ type CommonTLSOptions struct {
 // It includes json tags to deserialize configuration from a file
 // using the same names that the flags in the command line uses.
 type CommonConfig struct {
-	AuthorizationPlugins []string            `json:"authorization-plugins,omitempty"` // AuthorizationPlugins holds list of authorization plugins
-	AutoRestart          bool                `json:"-"`
-	Context              map[string][]string `json:"-"`
-	DisableBridge        bool                `json:"-"`
-	DNS                  []string            `json:"dns,omitempty"`
-	DNSOptions           []string            `json:"dns-opts,omitempty"`
-	DNSSearch            []string            `json:"dns-search,omitempty"`
-	ExecOptions          []string            `json:"exec-opts,omitempty"`
-	ExecRoot             string              `json:"exec-root,omitempty"`
-	GraphDriver          string              `json:"storage-driver,omitempty"`
-	GraphOptions         []string            `json:"storage-opts,omitempty"`
-	Labels               []string            `json:"labels,omitempty"`
-	Mtu                  int                 `json:"mtu,omitempty"`
-	Pidfile              string              `json:"pidfile,omitempty"`
-	RawLogs              bool                `json:"raw-logs,omitempty"`
-	Root                 string              `json:"graph,omitempty"`
-	SocketGroup          string              `json:"group,omitempty"`
-	TrustKeyPath         string              `json:"-"`
+	AuthorizationPlugins []string                `json:"authorization-plugins,omitempty"` // AuthorizationPlugins holds list of authorization plugins
+	AutoRestart          bool                    `json:"-"`
+	Context              map[string][]string     `json:"-"`
+	DisableBridge        bool                    `json:"-"`
+	DNS                  []string                `json:"dns,omitempty"`
+	DNSOptions           []string                `json:"dns-opts,omitempty"`
+	DNSSearch            []string                `json:"dns-search,omitempty"`
+	ExecOptions          []string                `json:"exec-opts,omitempty"`
+	EventsACLs           []events.ACLConfig      `json:"events-acls,omitempty"`
+	EventsBufferMemory   string                  `json:"events-buffer-memory,omitempty"`
+	EventsBufferSize     int                     `json:"events-buffer-size,omitempty"`
+	EventsCompactAfter   string                  `json:"events-compact-after,omitempty"`
+	EventsCPUThreshold   int                     `json:"events-cpu-threshold,omitempty"`
+	EventsDeadLetters    string                  `json:"events-dead-letters,omitempty"`
+	EventsDedupWindow    int                     `json:"events-dedup-window,omitempty"`
+	EventsDisabled       []string                `json:"events-disabled,omitempty"`
+	EventsDiskThreshold  int                     `json:"events-disk-threshold,omitempty"`
+	EventsHooksDir       string                  `json:"events-hooks-dir,omitempty"`
+	EventsHooksUser      string                  `json:"events-hooks-user,omitempty"`
+	EventsJournal        bool                    `json:"events-journal,omitempty"`
+	EventsJournalMaxSize string                  `json:"events-journal-max-size,omitempty"`
+	EventsMemThreshold   int                     `json:"events-memory-threshold,omitempty"`
+	EventsNetworkVerbose bool                    `json:"events-network-verbose,omitempty"`
+	EventsOOMWarning     int                     `json:"events-oom-warning,omitempty"`
+	EventsPlugins        []string                `json:"events-plugins,omitempty"`
+	EventsRedaction      []events.RedactionRule  `json:"events-redaction,omitempty"`
+	EventsRelay          string                  `json:"events-relay,omitempty"`
+	EventsRelayGroup     string                  `json:"events-relay-group,omitempty"`
+	EventsTypes          []string                `json:"events-types,omitempty"`
+	EventsExporters      []events.ExporterConfig `json:"events-exporters,omitempty"`
+	EventsRateLimit      int                     `json:"events-rate-limit,omitempty"`
+	EventsRateWindow     int                     `json:"events-rate-window,omitempty"`
+	EventsRules          []events.RuleConfig     `json:"events-rules,omitempty"`
+	EventsRetention      int                     `json:"events-retention,omitempty"`
+	EventsRetentionMax   int                     `json:"events-retention-max,omitempty"`
+	EventsSigning        string                  `json:"events-signing,omitempty"`
+	EventsSink           string                  `json:"events-sink,omitempty"`
+	EventsTenantScoping  bool                    `json:"events-tenant-scoping,omitempty"`
+	EventsHysteresis     int                     `json:"events-threshold-hysteresis,omitempty"`
+	EventsWebhooks       []events.WebhookConfig  `json:"events-webhooks,omitempty"`
+	ExecRoot             string                  `json:"exec-root,omitempty"`
+	GraphDriver          string                  `json:"storage-driver,omitempty"`
+	GraphOptions         []string                `json:"storage-opts,omitempty"`
+	Labels               []string                `json:"labels,omitempty"`
+	Mtu                  int                     `json:"mtu,omitempty"`
+	Pidfile              string                  `json:"pidfile,omitempty"`
+	RawLogs              bool                    `json:"raw-logs,omitempty"`
+	Root                 string                  `json:"graph,omitempty"`
+	SocketGroup          string                  `json:"group,omitempty"`
+	TrustKeyPath         string                  `json:"-"`
 
 	// ClusterStore is the storage backend used for the cluster information. It is used by both
 	// multihost networking (to store networks and endpoints information) and by the node discovery
@@ -116,6 +149,31 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.StringVar(&config.GraphDriver, []string{"s", "-storage-driver"}, "", usageFn("Storage driver to use"))
 	cmd.IntVar(&config.Mtu, []string{"#mtu", "-mtu"}, 0, usageFn("Set the containers network MTU"))
 	cmd.BoolVar(&config.RawLogs, []string{"-raw-logs"}, false, usageFn("Full timestamps without ANSI coloring"))
+	cmd.StringVar(&config.EventsBufferMemory, []string{"-events-buffer-memory"}, "", usageFn("Maximum memory held by the past events kept in memory, such as 64m"))
+	cmd.IntVar(&config.EventsBufferSize, []string{"-events-buffer-size"}, 64, usageFn("Number of past events kept in memory for docker events --since"))
+	cmd.StringVar(&config.EventsCompactAfter, []string{"-events-compact-after"}, "", usageFn("Age, such as 720h, after which the events of the containers in the journal are compacted into summaries"))
+	cmd.IntVar(&config.EventsCPUThreshold, []string{"-events-cpu-threshold"}, 0, usageFn("Percentage of one CPU above which a container generates a cpu_high event, 0 to disable"))
+	cmd.StringVar(&config.EventsDeadLetters, []string{"-events-dead-letters"}, "", usageFn("Path of the file storing the events the exporters and the webhooks fail to deliver"))
+	cmd.IntVar(&config.EventsDedupWindow, []string{"-events-dedup-window"}, 0, usageFn("Milliseconds during which the events repeating the previous event of an object are dropped, 0 to disable"))
+	cmd.IntVar(&config.EventsDiskThreshold, []string{"-events-disk-threshold"}, 0, usageFn("Percentage of the filesystem of the Docker root above which the daemon generates a disk_high event, 0 to disable"))
+	cmd.StringVar(&config.EventsHooksDir, []string{"-events-hooks-dir"}, "", usageFn("Directory of the hooks the labels of the containers run on their events"))
+	cmd.StringVar(&config.EventsHooksUser, []string{"-events-hooks-user"}, "nobody", usageFn("User running the hooks of the containers"))
+	cmd.BoolVar(&config.EventsJournal, []string{"-events-journal"}, false, usageFn("Keep a journal of events on disk that survives daemon restarts"))
+	cmd.StringVar(&config.EventsJournalMaxSize, []string{"-events-journal-max-size"}, "", usageFn("Size of the events journal, such as 1g, beyond which its oldest events are dropped"))
+	cmd.IntVar(&config.EventsMemThreshold, []string{"-events-memory-threshold"}, 0, usageFn("Percentage of its memory limit above which a container generates a memory_high event, 0 to disable"))
+	cmd.BoolVar(&config.EventsNetworkVerbose, []string{"-events-network-verbose"}, false, usageFn("Generate an event when a container endpoint joins or leaves a network"))
+	cmd.IntVar(&config.EventsOOMWarning, []string{"-events-oom-warning"}, 0, usageFn("Percentage of its memory limit above which a container generates an oom_warning event, 0 to disable"))
+	cmd.Var(opts.NewNamedListOptsRef("events-plugins", &config.EventsPlugins, nil), []string{"-events-plugin"}, usageFn("Set events plugins annotating every event"))
+	cmd.IntVar(&config.EventsRateLimit, []string{"-events-rate-limit"}, 0, usageFn("Maximum number of events logged per object during the rate window, 0 to disable"))
+	cmd.IntVar(&config.EventsRateWindow, []string{"-events-rate-window"}, 60, usageFn("Length in seconds of the window of the events rate limit"))
+	cmd.StringVar(&config.EventsRelay, []string{"-events-relay"}, "", usageFn("Path of a unix socket relaying the events to local agents"))
+	cmd.StringVar(&config.EventsRelayGroup, []string{"-events-relay-group"}, "", usageFn("Group for the unix socket of the events relay"))
+	cmd.IntVar(&config.EventsRetention, []string{"-events-retention"}, 0, usageFn("Seconds during which past events are kept in memory beyond the buffer size, 0 to disable"))
+	cmd.IntVar(&config.EventsRetentionMax, []string{"-events-retention-max"}, 10000, usageFn("Maximum number of past events kept in memory by the events retention"))
+	cmd.BoolVar(&config.EventsTenantScoping, []string{"-events-tenant-scoping"}, false, usageFn("Only send to each tenant the events of the containers, images and volumes it owns"))
+	cmd.IntVar(&config.EventsHysteresis, []string{"-events-threshold-hysteresis"}, 5, usageFn("Percentage points below a threshold a container gets back to before generating a cpu_normal or memory_normal event"))
+	cmd.StringVar(&config.EventsSigning, []string{"-events-signing"}, "", usageFn("Sign the events with the trust key of the daemon, trust, or with an HMAC secret, hmac:<path>"))
+	cmd.StringVar(&config.EventsSink, []string{"-events-sink"}, "", usageFn("Mirror events to the system log, syslog or journald"))
 	// FIXME: why the inconsistency between "hosts" and "sockets"?
 	cmd.Var(opts.NewListOptsRef(&config.DNS, opts.ValidateIPAddress), []string{"#dns", "-dns"}, usageFn("DNS server to use"))
 	cmd.Var(opts.NewNamedListOptsRef("dns-opts", &config.DNSOptions, nil), []string{"-dns-opt"}, usageFn("DNS options to use"))
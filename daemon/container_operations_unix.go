@@ -813,6 +813,7 @@ func (daemon *Daemon) connectToNetwork(container *container.Container, idOrName
 	if err := ep.Join(sb, joinOptions...); err != nil {
 		return err
 	}
+	daemon.logEndpointEvent(n, ep, container.ID, "endpoint_join")
 
 	if err := container.UpdateJoinInfo(n, ep); err != nil {
 		return derr.ErrorCodeJoinInfo.WithArgs(err)
@@ -846,7 +847,7 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, n li
 			return fmt.Errorf("container %s is not connected to the network %s", container.ID, n.Name())
 		}
 	} else {
-		if err := disconnectFromNetwork(container, n, false); err != nil {
+		if err := daemon.disconnectFromNetwork(container, n, false); err != nil {
 			return err
 		}
 	}
@@ -862,7 +863,7 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, n li
 	return nil
 }
 
-func disconnectFromNetwork(container *container.Container, n libnetwork.Network, force bool) error {
+func (daemon *Daemon) disconnectFromNetwork(container *container.Container, n libnetwork.Network, force bool) error {
 	var (
 		ep   libnetwork.Endpoint
 		sbox libnetwork.Sandbox
@@ -900,6 +901,7 @@ func disconnectFromNetwork(container *container.Container, n libnetwork.Network,
 	if err := ep.Leave(sbox); err != nil {
 		return fmt.Errorf("container %s failed to leave network %s: %v", container.ID, n.Name(), err)
 	}
+	daemon.logEndpointEvent(n, ep, container.ID, "endpoint_leave")
 
 	if err := ep.Delete(false); err != nil {
 		return fmt.Errorf("endpoint delete failed for container %s on network %s: %v", container.ID, n.Name(), err)
@@ -1014,8 +1016,15 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 		return
 	}
 
+	endpoints := sb.Endpoints()
 	if err := sb.Delete(); err != nil {
 		logrus.Errorf("Error deleting sandbox id %s for container %s: %v", sid, container.ID, err)
+	} else {
+		for _, ep := range endpoints {
+			if nw, err := daemon.FindNetwork(ep.Network()); err == nil {
+				daemon.logEndpointEvent(nw, ep, container.ID, "endpoint_leave")
+			}
+		}
 	}
 
 	attributes := map[string]string{
@@ -11,10 +11,12 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -22,6 +24,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/api"
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/events"
@@ -30,8 +33,6 @@ import (
 	"github.com/docker/docker/daemon/execdriver/execdrivers"
 	"github.com/docker/engine-api/types"
 	containertypes "github.com/docker/engine-api/types/container"
-	eventtypes "github.com/docker/engine-api/types/events"
-	"github.com/docker/engine-api/types/filters"
 	networktypes "github.com/docker/engine-api/types/network"
 	registrytypes "github.com/docker/engine-api/types/registry"
 	"github.com/docker/engine-api/types/strslice"
@@ -70,6 +71,7 @@ import (
 	"github.com/docker/docker/volume/local"
 	"github.com/docker/docker/volume/store"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
 	lntypes "github.com/docker/libnetwork/types"
 	"github.com/docker/libtrust"
@@ -117,9 +119,16 @@ type Daemon struct {
 	configStore               *Config
 	execDriver                execdriver.Driver
 	statsCollector            *statsCollector
+	resourceWatcher           *resourceWatcher
 	defaultLogConfig          containertypes.LogConfig
 	RegistryService           *registry.Service
 	EventsService             *events.Events
+	eventsWebhooks            []*events.Webhook
+	eventsForwarders          []*events.Forwarder
+	eventsRelay               *events.Relay
+	eventsRules               *events.Rules
+	eventsHooks               *events.LabelHooks
+	eventsDeadLetters         *events.DeadLetters
 	netController             libnetwork.NetworkController
 	volumes                   *store.VolumeStore
 	discoveryWatcher          discoveryReloader
@@ -297,7 +306,11 @@ func (daemon *Daemon) restore() error {
 
 	var migrateLegacyLinks bool
 	restartContainers := make(map[*container.Container]chan struct{})
+	// wasRunning are the containers that were running when the daemon
+	// stopped, and that are killed when they are registered.
+	wasRunning := make(map[*container.Container]bool)
 	for _, c := range containers {
+		wasRunning[c] = c.IsRunning()
 		if err := daemon.registerName(c); err != nil {
 			logrus.Errorf("Failed to register container %s: %s", c.ID, err)
 			continue
@@ -364,6 +377,9 @@ func (daemon *Daemon) restore() error {
 			}
 			if err := daemon.containerStart(c); err != nil {
 				logrus.Errorf("Failed to start container %s: %s", c.ID, err)
+				daemon.logRestoreEvent(c, "restart_failed", wasRunning[c], map[string]string{"error": err.Error()})
+			} else {
+				daemon.logRestoreEvent(c, "restarted", wasRunning[c], map[string]string{})
 			}
 			close(chNotify)
 		}(c, notifier)
@@ -371,6 +387,12 @@ func (daemon *Daemon) restore() error {
 	}
 	group.Wait()
 
+	for c, running := range wasRunning {
+		if _, restarted := restartContainers[c]; running && !restarted && daemon.containers.Get(c.ID) != nil {
+			daemon.logRestoreEvent(c, "dead", true, map[string]string{"exitCode": strconv.Itoa(c.ExitCode)})
+		}
+	}
+
 	// any containers that were started above would already have had this done,
 	// however we need to now prepare the mountpoints for the rest of the containers as well.
 	// This shouldn't cause any issue running on the containers that already had this run.
@@ -548,16 +570,119 @@ func (daemon *Daemon) GetByName(name string) (*container.Container, error) {
 	return e, nil
 }
 
-// SubscribeToEvents returns the currently record of events, a channel to stream new events from, and a function to cancel the stream of events.
-func (daemon *Daemon) SubscribeToEvents(since, sinceNano int64, filter filters.Args) ([]eventtypes.Message, chan interface{}) {
-	ef := events.NewFilter(filter)
-	return daemon.EventsService.SubscribeTopic(since, sinceNano, ef)
+// SubscribeToEvents returns the currently record of events, and a channel to stream new events from until ctx is done.
+func (daemon *Daemon) SubscribeToEvents(ctx context.Context, opts events.SubscribeOptions) ([]eventtypes.Message, <-chan eventtypes.Message) {
+	return daemon.EventsService.SubscribeWithOptions(ctx, opts)
+}
+
+// EventsMetrics returns the delivery statistics of the events service.
+func (daemon *Daemon) EventsMetrics() events.Metrics {
+	return daemon.EventsService.Metrics()
+}
+
+// EventsACL returns the access control list of the events of a client
+// identity, or nil when the client can see all events.
+func (daemon *Daemon) EventsACL(identity string) *events.ACL {
+	return daemon.EventsService.ACL(identity)
+}
+
+// EventsAdmin returns true if the client identity can manage the events
+// service.
+func (daemon *Daemon) EventsAdmin(identity string) bool {
+	return daemon.EventsService.Admin(identity)
+}
+
+// EventsAnonymizer returns the anonymizer of the events the clients ask to
+// anonymize.
+func (daemon *Daemon) EventsAnonymizer() *events.Anonymizer {
+	return daemon.EventsService.Anonymizer()
+}
+
+// EventsTenantScoping returns true if the event stream is scoped per tenant.
+func (daemon *Daemon) EventsTenantScoping() bool {
+	return daemon.EventsService.TenantScoping()
+}
+
+// EventsHistory returns a page of past events read from the events journal.
+func (daemon *Daemon) EventsHistory(opts events.HistoryOptions) (eventtypes.History, error) {
+	return daemon.EventsService.History(opts)
 }
 
-// UnsubscribeFromEvents stops the event subscription for a client by closing the
-// channel where the daemon sends events to.
-func (daemon *Daemon) UnsubscribeFromEvents(listener chan interface{}) {
-	daemon.EventsService.Evict(listener)
+// EventsSearch returns a page of the events of the journal matching a
+// search.
+func (daemon *Daemon) EventsSearch(opts events.SearchOptions) (eventtypes.History, error) {
+	return daemon.EventsService.Search(opts)
+}
+
+// EventsDiff returns the objects created and removed between two time
+// points, derived from the events journal.
+func (daemon *Daemon) EventsDiff(opts events.DiffOptions) (eventtypes.Diff, error) {
+	return daemon.EventsService.Diff(opts)
+}
+
+// ExportEvents writes a snapshot of the events of the journal to w.
+func (daemon *Daemon) ExportEvents(w io.Writer, opts events.SnapshotOptions) error {
+	return daemon.EventsService.ExportSnapshot(w, opts)
+}
+
+// ImportEvents imports a snapshot of the events of another daemon, whose
+// events can then be read from the history.
+func (daemon *Daemon) ImportEvents(r io.Reader) (eventtypes.Snapshot, error) {
+	return daemon.EventsService.ImportSnapshot(r)
+}
+
+// EventsStats returns the number of events logged by type and action
+// during each window.
+func (daemon *Daemon) EventsStats(windows []time.Duration) []eventtypes.WindowStats {
+	return daemon.EventsService.Stats(windows)
+}
+
+// EventsMemory returns the memory held by the events.
+func (daemon *Daemon) EventsMemory() eventtypes.Memory {
+	return daemon.EventsService.Memory()
+}
+
+// EventsPayloads returns the cache of the encodings of the events sent to
+// the subscribers.
+func (daemon *Daemon) EventsPayloads() *events.PayloadCache {
+	return daemon.EventsService.Payloads()
+}
+
+// EventsSubscribers returns the description of every subscriber of the
+// tenant owner, or of all the subscribers when owner is empty.
+func (daemon *Daemon) EventsSubscribers(owner string) []eventtypes.Subscriber {
+	return daemon.EventsService.Subscribers(owner)
+}
+
+// EventsExporters returns the state of the exporters and of the system log
+// sink the events are sent to.
+func (daemon *Daemon) EventsExporters() []eventtypes.ExporterStatus {
+	exporters := []eventtypes.ExporterStatus{}
+	for _, forwarder := range daemon.eventsForwarders {
+		exporters = append(exporters, forwarder.Status())
+	}
+	return exporters
+}
+
+// EvictEventsSubscriber ends the stream of events of a subscriber.
+func (daemon *Daemon) EvictEventsSubscriber(id uint64, owner string) error {
+	return daemon.EventsService.EvictSubscriber(id, owner)
+}
+
+// PauseEventsSubscriber stops the delivery of events to a subscriber,
+// queuing up to limit events until it is resumed.
+func (daemon *Daemon) PauseEventsSubscriber(id uint64, limit int, owner string) error {
+	return daemon.EventsService.PauseSubscriber(id, limit, owner)
+}
+
+// ResumeEventsSubscriber resumes the delivery of events to a subscriber.
+func (daemon *Daemon) ResumeEventsSubscriber(id uint64, owner string) error {
+	return daemon.EventsService.ResumeSubscriber(id, owner)
+}
+
+// DroppedEvents returns the number of events that were not delivered to the listener.
+func (daemon *Daemon) DroppedEvents(listener <-chan eventtypes.Message) uint64 {
+	return daemon.EventsService.Dropped(listener)
 }
 
 // GetLabels for a container or image id
@@ -747,8 +872,90 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
-	eventsService := events.New()
-
+	eventsService := events.New(config.EventsBufferSize)
+	if config.EventsJournal {
+		journal, err := events.NewJournal(filepath.Join(config.Root, "events"))
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't create events journal: %v", err)
+		}
+		eventsService.SetJournal(journal)
+		if config.EventsJournalMaxSize != "" {
+			max, err := units.RAMInBytes(config.EventsJournalMaxSize)
+			if err != nil || max <= 0 {
+				return nil, fmt.Errorf("Invalid events journal max size %q", config.EventsJournalMaxSize)
+			}
+			journal.SetMaxSize(max)
+		} else {
+			logrus.Warnf("The events journal has no maximum size, set --events-journal-max-size to limit the disk space it takes")
+		}
+		if config.EventsCompactAfter != "" {
+			age, err := time.ParseDuration(config.EventsCompactAfter)
+			if err != nil || age <= 0 {
+				return nil, fmt.Errorf("Invalid events compaction age %q", config.EventsCompactAfter)
+			}
+			eventsService.SetJournalCompaction(age)
+		}
+	}
+	if len(config.EventsPlugins) > 0 {
+		eventsService.SetAnnotators(events.NewAnnotatorPlugins(config.EventsPlugins))
+	}
+	if config.EventsDedupWindow > 0 {
+		eventsService.SetDedupWindow(time.Duration(config.EventsDedupWindow) * time.Millisecond)
+	}
+	if config.EventsRateLimit > 0 {
+		eventsService.SetRateLimit(config.EventsRateLimit, time.Duration(config.EventsRateWindow)*time.Second)
+	}
+	if config.EventsRetention > 0 {
+		eventsService.SetRetention(time.Duration(config.EventsRetention)*time.Second, config.EventsRetentionMax)
+	}
+	if config.EventsBufferMemory != "" {
+		limit, err := units.RAMInBytes(config.EventsBufferMemory)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid events buffer memory %q: %v", config.EventsBufferMemory, err)
+		}
+		eventsService.SetMemoryLimit(limit)
+	}
+	if config.EventsTenantScoping {
+		if err := eventsService.SetTenantScoping(true, filepath.Join(config.Root, "events", "owners.json")); err != nil {
+			return nil, fmt.Errorf("Couldn't load the owners of the events objects: %v", err)
+		}
+	}
+	if len(config.EventsACLs) > 0 {
+		acls, err := events.NewACLs(config.EventsACLs)
+		if err != nil {
+			return nil, err
+		}
+		eventsService.SetACLs(acls)
+	}
+	if len(config.EventsTypes) > 0 || len(config.EventsDisabled) > 0 {
+		classes, err := events.NewEventClasses(config.EventsTypes, config.EventsDisabled)
+		if err != nil {
+			return nil, err
+		}
+		eventsService.SetEventClasses(classes)
+	}
+	if len(config.EventsRedaction) > 0 {
+		redactor, err := events.NewRedactor(config.EventsRedaction)
+		if err != nil {
+			return nil, err
+		}
+		eventsService.SetRedactor(redactor)
+	}
+	if config.EventsSigning != "" {
+		signer, err := events.NewSigner(config.EventsSigning, trustKey)
+		if err != nil {
+			return nil, err
+		}
+		eventsService.SetSigner(signer)
+	}
+	if config.EventsDeadLetters != "" {
+		deadLetters, err := events.OpenDeadLetters(config.EventsDeadLetters)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't open events dead letters: %v", err)
+		}
+		eventsService.SetDeadLetters(deadLetters)
+		d.eventsDeadLetters = deadLetters
+	}
 	referenceStore, err := reference.NewReferenceStore(filepath.Join(imageRoot, "repositories.json"))
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't create Tag store repositories: %s", err)
@@ -788,6 +995,7 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	}
 
 	d.ID = trustKey.PublicKey().KeyID()
+	eventsService.SetNode(nodeIdentity(d.ID, config.Labels))
 	d.repository = daemonRepo
 	d.containers = container.NewMemoryStore()
 	d.execCommands = exec.NewStore()
@@ -817,14 +1025,79 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 	go d.execCommandGC()
+	if config.EventsCPUThreshold > 0 || config.EventsMemThreshold > 0 || config.EventsOOMWarning > 0 || config.EventsDiskThreshold > 0 {
+		d.resourceWatcher = d.newResourceWatcher(config)
+	}
+
+	if err := d.startEventsConsumers(config); err != nil {
+		return nil, err
+	}
 
 	if err := d.restore(); err != nil {
 		return nil, err
 	}
 
+	d.LogDaemonEvent("start")
+
 	return d, nil
 }
 
+// startEventsConsumers starts the sink, the exporters, the webhooks, the
+// rules, the label hooks and the relay of the events of the daemon. It is
+// called once the daemon is set up, and the consumers started before an
+// error are stopped by Shutdown.
+func (daemon *Daemon) startEventsConsumers(config *Config) error {
+	if config.EventsSink != "" {
+		sink, err := events.NewLogSink(config.EventsSink)
+		if err != nil {
+			return err
+		}
+		forwarder := events.NewForwarder(config.EventsSink, sink, nil, 0)
+		forwarder.Start(daemon.EventsService)
+		daemon.eventsForwarders = append(daemon.eventsForwarders, forwarder)
+	}
+	for _, c := range config.EventsExporters {
+		forwarder, err := events.StartExporter(daemon.EventsService, c)
+		if err != nil {
+			return err
+		}
+		daemon.eventsForwarders = append(daemon.eventsForwarders, forwarder)
+	}
+	for _, c := range config.EventsWebhooks {
+		webhook, err := events.NewWebhook(c)
+		if err != nil {
+			return err
+		}
+		webhook.Start(daemon.EventsService)
+		daemon.eventsWebhooks = append(daemon.eventsWebhooks, webhook)
+	}
+	if len(config.EventsRules) > 0 {
+		rules, err := events.NewRules(config.EventsRules, ruleActions{daemon: daemon})
+		if err != nil {
+			return err
+		}
+		rules.Start(daemon.EventsService)
+		daemon.eventsRules = rules
+	}
+	if config.EventsHooksDir != "" {
+		hooks, err := events.NewLabelHooks(config.EventsHooksDir, config.EventsHooksUser)
+		if err != nil {
+			return err
+		}
+		hooks.Start(daemon.EventsService)
+		daemon.eventsHooks = hooks
+	}
+	if config.EventsRelay != "" {
+		l, err := eventsRelayListener(config.EventsRelay, config.EventsRelayGroup)
+		if err != nil {
+			return fmt.Errorf("error creating events relay socket: %v", err)
+		}
+		daemon.eventsRelay = events.NewRelay(l)
+		daemon.eventsRelay.Start(daemon.EventsService)
+	}
+	return nil
+}
+
 func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 	// TODO(windows): Handle docker restart with paused containers
 	if c.IsPaused() {
@@ -867,6 +1140,12 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 // Shutdown stops the daemon.
 func (daemon *Daemon) Shutdown() error {
 	daemon.shutdown = true
+	if daemon.EventsService != nil {
+		daemon.LogDaemonEvent("shutdown")
+	}
+	if daemon.resourceWatcher != nil {
+		daemon.resourceWatcher.Close()
+	}
 	if daemon.containers != nil {
 		logrus.Debug("starting clean shutdown of all containers...")
 		daemon.containers.ApplyAll(func(c *container.Container) {
@@ -893,6 +1172,33 @@ func (daemon *Daemon) Shutdown() error {
 		}
 	}
 
+	if daemon.eventsRules != nil {
+		daemon.eventsRules.Stop()
+	}
+	if daemon.eventsHooks != nil {
+		daemon.eventsHooks.Stop()
+	}
+	for _, webhook := range daemon.eventsWebhooks {
+		webhook.Stop()
+	}
+	for _, forwarder := range daemon.eventsForwarders {
+		forwarder.Stop()
+	}
+	if daemon.eventsRelay != nil {
+		daemon.eventsRelay.Stop()
+	}
+	if daemon.eventsDeadLetters != nil {
+		if err := daemon.eventsDeadLetters.Close(); err != nil {
+			logrus.Errorf("Error closing events dead letters: %v", err)
+		}
+	}
+
+	if daemon.EventsService != nil {
+		if err := daemon.EventsService.Close(); err != nil {
+			logrus.Errorf("Error closing events journal: %v", err)
+		}
+	}
+
 	if err := daemon.cleanupMounts(); err != nil {
 		return err
 	}
@@ -1023,15 +1329,17 @@ func (daemon *Daemon) PullImage(ref reference.Named, metaHeaders map[string][]st
 	}()
 
 	imagePullConfig := &distribution.ImagePullConfig{
-		MetaHeaders:      metaHeaders,
-		AuthConfig:       authConfig,
-		ProgressOutput:   progress.ChanOutput(progressChan),
-		RegistryService:  daemon.RegistryService,
-		ImageEventLogger: daemon.LogImageEvent,
-		MetadataStore:    daemon.distributionMetadataStore,
-		ImageStore:       daemon.imageStore,
-		ReferenceStore:   daemon.referenceStore,
-		DownloadManager:  daemon.downloadManager,
+		MetaHeaders:         metaHeaders,
+		AuthConfig:          authConfig,
+		ProgressOutput:      progress.ChanOutput(progressChan),
+		RegistryService:     daemon.RegistryService,
+		ImageEventLogger:    daemon.LogImageEvent,
+		ProgressEventLogger: daemon.LogImageEventWithAttributes,
+		RegistryEventLogger: daemon.LogRegistryEvent,
+		MetadataStore:       daemon.distributionMetadataStore,
+		ImageStore:          daemon.imageStore,
+		ReferenceStore:      daemon.referenceStore,
+		DownloadManager:     daemon.downloadManager,
 	}
 
 	err := distribution.Pull(ctx, ref, imagePullConfig)
@@ -1095,17 +1403,18 @@ func (daemon *Daemon) PushImage(ref reference.Named, metaHeaders map[string][]st
 	}()
 
 	imagePushConfig := &distribution.ImagePushConfig{
-		MetaHeaders:      metaHeaders,
-		AuthConfig:       authConfig,
-		ProgressOutput:   progress.ChanOutput(progressChan),
-		RegistryService:  daemon.RegistryService,
-		ImageEventLogger: daemon.LogImageEvent,
-		MetadataStore:    daemon.distributionMetadataStore,
-		LayerStore:       daemon.layerStore,
-		ImageStore:       daemon.imageStore,
-		ReferenceStore:   daemon.referenceStore,
-		TrustKey:         daemon.trustKey,
-		UploadManager:    daemon.uploadManager,
+		MetaHeaders:         metaHeaders,
+		AuthConfig:          authConfig,
+		ProgressOutput:      progress.ChanOutput(progressChan),
+		RegistryService:     daemon.RegistryService,
+		ImageEventLogger:    daemon.LogImageEvent,
+		ProgressEventLogger: daemon.LogImageEventWithAttributes,
+		MetadataStore:       daemon.distributionMetadataStore,
+		LayerStore:          daemon.layerStore,
+		ImageStore:          daemon.imageStore,
+		ReferenceStore:      daemon.referenceStore,
+		TrustKey:            daemon.trustKey,
+		UploadManager:       daemon.uploadManager,
 	}
 
 	err := distribution.Push(ctx, ref, imagePushConfig)
@@ -1514,7 +1823,18 @@ func configureVolumes(config *Config, rootUID, rootGID int) (*store.VolumeStore,
 
 // AuthenticateToRegistry checks the validity of credentials in authConfig
 func (daemon *Daemon) AuthenticateToRegistry(authConfig *types.AuthConfig) (string, error) {
-	return daemon.RegistryService.Auth(authConfig, dockerversion.DockerUserAgent())
+	status, err := daemon.RegistryService.Auth(authConfig, dockerversion.DockerUserAgent())
+	hostname := authConfig.ServerAddress
+	if u, err := url.Parse(hostname); err == nil && u.Host != "" {
+		hostname = u.Host
+	} else if hostname == "" {
+		hostname = registry.IndexName
+	}
+	daemon.logLoginEvent(hostname, authConfig.Username, err)
+	if err != nil {
+		return "", err
+	}
+	return status, nil
 }
 
 // SearchRegistryForImages queries the registry for images matching
@@ -1602,7 +1922,9 @@ func (daemon *Daemon) Reload(config *Config) error {
 	daemon.configStore.reloadLock.Lock()
 	daemon.configStore.Labels = config.Labels
 	daemon.configStore.reloadLock.Unlock()
+	daemon.EventsService.SetNode(nodeIdentity(daemon.ID, config.Labels))
 
+	daemon.LogDaemonEvent("reload")
 	return nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/pkg/discovery"
 	_ "github.com/docker/docker/pkg/discovery/memory"
 	"github.com/docker/docker/pkg/registrar"
@@ -19,6 +20,7 @@ import (
 	"github.com/docker/docker/volume/store"
 	containertypes "github.com/docker/engine-api/types/container"
 	"github.com/docker/go-connections/nat"
+	"golang.org/x/net/context"
 )
 
 //
@@ -308,7 +310,10 @@ func TestMerge(t *testing.T) {
 }
 
 func TestDaemonReloadLabels(t *testing.T) {
-	daemon := &Daemon{}
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+	daemon := &Daemon{EventsService: e}
 	daemon.configStore = &Config{
 		CommonConfig: CommonConfig{
 			Labels: []string{"foo:bar"},
@@ -326,6 +331,14 @@ func TestDaemonReloadLabels(t *testing.T) {
 	if label != "foo:baz" {
 		t.Fatalf("Expected daemon label `foo:baz`, got %s", label)
 	}
+	select {
+	case ev := <-l:
+		if ev.Action != "reload" {
+			t.Fatalf("Expected a reload event, got %+v", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the reload event")
+	}
 }
 
 func TestDaemonDiscoveryReload(t *testing.T) {
@@ -28,6 +28,7 @@ import (
 	runconfigopts "github.com/docker/docker/runconfig/opts"
 	pblkiodev "github.com/docker/engine-api/types/blkiodev"
 	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/docker/go-connections/sockets"
 	"github.com/docker/libnetwork"
 	nwconfig "github.com/docker/libnetwork/config"
 	"github.com/docker/libnetwork/drivers/bridge"
@@ -503,6 +504,12 @@ func isBridgeNetworkDisabled(config *Config) bool {
 	return config.bridgeConfig.Iface == disableNetworkBridge
 }
 
+// eventsRelayListener creates the unix socket of the events relay, only
+// accessible to root and to the members of group, if any.
+func eventsRelayListener(path, group string) (net.Listener, error) {
+	return sockets.NewUnixSocket(path, group)
+}
+
 func (daemon *Daemon) networkOptions(dconfig *Config) ([]nwconfig.Option, error) {
 	options := []nwconfig.Option{}
 	if dconfig == nil {
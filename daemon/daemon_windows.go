@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -124,6 +125,10 @@ func isBridgeNetworkDisabled(config *Config) bool {
 	return false
 }
 
+func eventsRelayListener(path, group string) (net.Listener, error) {
+	return nil, errors.New("the events relay is not supported on Windows")
+}
+
 func (daemon *Daemon) initNetworkController(config *Config) (libnetwork.NetworkController, error) {
 	// Set the name of the virtual switch if not specified by -b on daemon start
 	if config.bridgeConfig.VirtualSwitchName == "" {
@@ -147,12 +147,11 @@ func (daemon *Daemon) VolumeRm(name string) error {
 		return err
 	}
 
-	if err := daemon.volumes.Remove(v); err != nil {
+	if err := daemon.removeVolume(v); err != nil {
 		if volumestore.IsInUse(err) {
 			return derr.ErrorCodeRmVolumeInUse.WithArgs(err)
 		}
 		return derr.ErrorCodeRmVolume.WithArgs(name, err)
 	}
-	daemon.LogVolumeEvent(v.Name(), "destroy", map[string]string{"driver": v.DriverName()})
 	return nil
 }
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"strconv"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/volume"
+)
+
+// reclaimedSize returns the space reclaimed by the removal of the layers.
+func reclaimedSize(removed []layer.Metadata) int64 {
+	var size int64
+	for _, l := range removed {
+		size += l.DiffSize
+	}
+	return size
+}
+
+// removeVolume removes the volume and logs its destroy event, with the
+// space reclaimed when the volume is local.
+func (daemon *Daemon) removeVolume(v volume.Volume) error {
+	size := int64(-1)
+	if v.DriverName() == volume.DefaultDriverName {
+		s, err := directory.Size(v.Path())
+		if err != nil {
+			logrus.Debugf("Failed to compute the size of volume %s: %v", v.Name(), err)
+		} else {
+			size = s
+		}
+	}
+	if err := daemon.volumes.Remove(v); err != nil {
+		return err
+	}
+	attributes := map[string]string{"driver": v.DriverName()}
+	if size >= 0 {
+		attributes["reclaimed"] = strconv.FormatInt(size, 10)
+	}
+	daemon.LogVolumeEvent(v.Name(), "destroy", attributes)
+	return nil
+}
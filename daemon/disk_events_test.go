@@ -0,0 +1,17 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/layer"
+)
+
+func TestReclaimedSize(t *testing.T) {
+	if size := reclaimedSize(nil); size != 0 {
+		t.Fatalf("Expected nothing reclaimed, got %d", size)
+	}
+	removed := []layer.Metadata{{DiffSize: 100, Size: 1000}, {DiffSize: 20, Size: 900}}
+	if size := reclaimedSize(removed); size != 120 {
+		t.Fatalf("Expected the diff sizes to be reclaimed, got %d", size)
+	}
+}
@@ -1,13 +1,135 @@
 package daemon
 
 import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/container"
-	"github.com/docker/engine-api/types/events"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/engine-api/types/filters"
 	"github.com/docker/libnetwork"
+	"golang.org/x/net/context"
 )
 
+// ContainerEventsConfig holds information for configuring the runtime
+// behavior of a daemon.ContainerEvents() call.
+type ContainerEventsConfig struct {
+	Since, SinceNano int64
+	Until, UntilNano int64
+	// Filter selects the events of the container to write, among the
+	// events of the container whatever its container filter selects.
+	Filter    *daemonevents.Filter
+	OutStream io.Writer
+	// Schema is the form of the events known by the client.
+	Schema daemonevents.SchemaVersion
+	Stop      <-chan bool
+	// Owner is the tenant requesting the events when the event stream is
+	// scoped per tenant.
+	Owner string
+	// ACL restricts the events written, if any.
+	ACL *daemonevents.ACL
+}
+
+// ContainerEvents writes the events of a container to the stream given in
+// the config object, until the container is destroyed.
+func (daemon *Daemon) ContainerEvents(prefixOrName string, config *ContainerEventsConfig) error {
+	container, err := daemon.GetContainer(prefixOrName)
+	if err != nil {
+		return err
+	}
+
+	// The events are scoped to the container apart from the filters of
+	// the client, whose values would be ORed with the container.
+	scope := filters.NewArgs()
+	scope.Add("type", events.ContainerEventType)
+	scope.Add("container", container.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffered, l := daemon.EventsService.SubscribeWithOptions(ctx, daemonevents.SubscribeOptions{
+		Since:     config.Since,
+		SinceNano: config.SinceNano,
+		Until:     config.Until,
+		UntilNano: config.UntilNano,
+		Filter:    config.Filter,
+		Scope:     daemonevents.NewFilter(scope),
+		Owner:     config.Owner,
+		ACL:       config.ACL,
+	})
+
+	var until <-chan time.Time
+	if config.Until > 0 || config.UntilNano > 0 {
+		timer := time.NewTimer(time.Unix(config.Until, config.UntilNano).Sub(time.Now()))
+		defer timer.Stop()
+		until = timer.C
+	}
+
+	// Write an empty chunk of data.
+	// This is to ensure that the HTTP status code is sent immediately,
+	// even if the container does not generate any event.
+	config.OutStream.Write(nil)
+
+	enc := json.NewEncoder(config.OutStream)
+	for _, ev := range buffered {
+		if err := enc.Encode(daemonevents.Translate(ev, config.Schema)); err != nil {
+			return err
+		}
+		if ev.Action == "destroy" {
+			return nil
+		}
+	}
+	for {
+		select {
+		case ev, open := <-l:
+			if !open {
+				return nil
+			}
+			if err := enc.Encode(daemonevents.Translate(ev, config.Schema)); err != nil {
+				return err
+			}
+			if ev.Action == "destroy" {
+				return nil
+			}
+		case <-until:
+			return nil
+		case <-config.Stop:
+			return nil
+		}
+	}
+}
+
+// ContainerTimeline returns the states the container went through, folded
+// from its events.
+func (daemon *Daemon) ContainerTimeline(prefixOrName, owner string, acl *daemonevents.ACL) (events.Timeline, error) {
+	container, err := daemon.GetContainer(prefixOrName)
+	if err != nil {
+		return events.Timeline{}, err
+	}
+	return daemon.EventsService.ContainerTimeline(container.ID, owner, acl)
+}
+
+// ContainerUptime returns the availability of the container between since
+// and until, computed from its events.
+func (daemon *Daemon) ContainerUptime(prefixOrName string, since, until time.Time, owner string, acl *daemonevents.ACL) (events.Uptime, error) {
+	container, err := daemon.GetContainer(prefixOrName)
+	if err != nil {
+		return events.Uptime{}, err
+	}
+	return daemon.EventsService.ContainerUptime(container.ID, since, until, owner, acl)
+}
+
+// AttributeContainerEvents makes the events of the container target, an ID,
+// ID prefix or name, carry origin until done is called. When target is
+// empty, it applies to the container created next.
+func (daemon *Daemon) AttributeContainerEvents(target string, origin daemonevents.Origin) (done func()) {
+	return daemon.EventsService.Attribute(events.ContainerEventType, target, origin)
+}
+
 // LogContainerEvent generates an event related to a container with only the default attributes.
 func (daemon *Daemon) LogContainerEvent(container *container.Container, action string) {
 	daemon.LogContainerEventWithAttributes(container, action, map[string]string{})
@@ -21,11 +143,20 @@ func (daemon *Daemon) LogContainerEventWithAttributes(container *container.Conta
 	}
 	attributes["name"] = strings.TrimLeft(container.Name, "/")
 
-	actor := events.Actor{
-		ID:         container.ID,
-		Attributes: attributes,
+	daemon.EventsService.Publish(daemonevents.ContainerTopic, action, container.ID, attributes)
+
+	if action == "oom" {
+		daemon.LogDaemonEventWithAttributes("oom", map[string]string{"container": container.ID})
 	}
-	daemon.EventsService.Log(action, events.ContainerEventType, actor)
+}
+
+// logRestoreEvent generates the restore event of a container loaded when the
+// daemon starts, whose outcome tells what happened to it while the daemon
+// was down or as it started.
+func (daemon *Daemon) logRestoreEvent(container *container.Container, outcome string, wasRunning bool, attributes map[string]string) {
+	attributes["outcome"] = outcome
+	attributes["wasRunning"] = strconv.FormatBool(wasRunning)
+	daemon.LogContainerEventWithAttributes(container, "restore", attributes)
 }
 
 // LogImageEvent generates an event related to a container with only the default attributes.
@@ -44,21 +175,17 @@ func (daemon *Daemon) LogImageEventWithAttributes(imageID, refName, action strin
 	if refName != "" {
 		attributes["name"] = refName
 	}
-	actor := events.Actor{
-		ID:         imageID,
-		Attributes: attributes,
-	}
-
-	daemon.EventsService.Log(action, events.ImageEventType, actor)
+	daemon.EventsService.Publish(daemonevents.ImageTopic, action, imageID, attributes)
 }
 
 // LogVolumeEvent generates an event related to a volume.
 func (daemon *Daemon) LogVolumeEvent(volumeID, action string, attributes map[string]string) {
-	actor := events.Actor{
-		ID:         volumeID,
-		Attributes: attributes,
-	}
-	daemon.EventsService.Log(action, events.VolumeEventType, actor)
+	daemon.EventsService.Publish(daemonevents.VolumeTopic, action, volumeID, attributes)
+}
+
+// LogBuildEvent generates an event related to a build.
+func (daemon *Daemon) LogBuildEvent(buildID, action string, attributes map[string]string) {
+	daemon.EventsService.Publish(daemonevents.BuildTopic, action, buildID, attributes)
 }
 
 // LogNetworkEvent generates an event related to a network with only the default attributes.
@@ -70,11 +197,64 @@ func (daemon *Daemon) LogNetworkEvent(nw libnetwork.Network, action string) {
 func (daemon *Daemon) LogNetworkEventWithAttributes(nw libnetwork.Network, action string, attributes map[string]string) {
 	attributes["name"] = nw.Name()
 	attributes["type"] = nw.Type()
-	actor := events.Actor{
-		ID:         nw.ID(),
-		Attributes: attributes,
+	daemon.EventsService.Publish(daemonevents.NetworkTopic, action, nw.ID(), attributes)
+}
+
+// logEndpointEvent generates an event of the endpoint ep of a container
+// joining or leaving the network n, when the verbose network events are
+// enabled.
+func (daemon *Daemon) logEndpointEvent(n libnetwork.Network, ep libnetwork.Endpoint, containerID, action string) {
+	if !daemon.configStore.EventsNetworkVerbose {
+		return
+	}
+	attributes := map[string]string{
+		"container": containerID,
+		"endpoint":  ep.ID(),
 	}
-	daemon.EventsService.Log(action, events.NetworkEventType, actor)
+	if info := ep.Info(); info != nil && info.Iface() != nil {
+		if addr := info.Iface().Address(); addr != nil {
+			attributes["address"] = addr.String()
+		}
+		if mac := info.Iface().MacAddress(); mac != nil {
+			attributes["macAddress"] = mac.String()
+		}
+	}
+	daemon.LogNetworkEventWithAttributes(n, action, attributes)
+}
+
+// LogRegistryEvent generates an event related to the registry hostname.
+func (daemon *Daemon) LogRegistryEvent(hostname, action string, attributes map[string]string) {
+	daemon.EventsService.Publish(daemonevents.RegistryTopic, action, hostname, attributes)
+}
+
+// logLoginEvent generates the login or login_failure event of a user
+// logging in to the registry hostname. The subscribers of the events are not
+// all allowed to know who logs in, so the username is replaced by its keyed
+// hash, which relates the logins of a user until the daemon restarts.
+func (daemon *Daemon) logLoginEvent(hostname, username string, err error) {
+	attributes := map[string]string{}
+	if username != "" {
+		attributes["username"] = daemon.EventsService.Anonymizer().Hash(username)
+	}
+	if err != nil {
+		attributes["error"] = err.Error()
+		daemon.LogRegistryEvent(hostname, "login_failure", attributes)
+		return
+	}
+	daemon.LogRegistryEvent(hostname, "login", attributes)
+}
+
+// LogDaemonEvent generates an event related to the daemon itself with only the default attributes.
+func (daemon *Daemon) LogDaemonEvent(action string) {
+	daemon.LogDaemonEventWithAttributes(action, map[string]string{})
+}
+
+// LogDaemonEventWithAttributes generates an event related to the daemon itself with specific given attributes.
+func (daemon *Daemon) LogDaemonEventWithAttributes(action string, attributes map[string]string) {
+	if hostname, err := os.Hostname(); err == nil {
+		attributes["name"] = hostname
+	}
+	daemon.EventsService.Publish(daemonevents.DaemonTopic, action, daemon.ID, attributes)
 }
 
 // copyAttributes guarantees that labels are not mutated by event triggers.
@@ -86,3 +266,24 @@ func copyAttributes(attributes, labels map[string]string) {
 		attributes[k] = v
 	}
 }
+
+// nodeIdentity returns the identity of the daemon added to its events,
+// from its ID, hostname and labels.
+func nodeIdentity(id string, labels []string) daemonevents.Node {
+	node := daemonevents.Node{
+		ID:     id,
+		Labels: make(map[string]string, len(labels)),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		node.Name = hostname
+	}
+	for _, l := range labels {
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) == 2 {
+			node.Labels[kv[0]] = kv[1]
+		} else {
+			node.Labels[kv[0]] = ""
+		}
+	}
+	return node
+}
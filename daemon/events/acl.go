@@ -0,0 +1,140 @@
+package events
+
+import (
+	"fmt"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+// anyIdentity is the identity of the access control list applied to the
+// clients that no other list names.
+const anyIdentity = "*"
+
+// ACLConfig is the configuration of an access control list of the event
+// stream, restricting the events sent to some API clients.
+type ACLConfig struct {
+	// Identities are the users authenticated by the authorization
+	// plugins, or the common names of the TLS certificates of the
+	// clients, the list applies to. "*" applies the list to the clients
+	// that no other list names.
+	Identities []string `json:"identities"`
+	// Filters selects the events the clients can see, using the same
+	// filters as the events API. All events are allowed when it is not
+	// set.
+	Filters map[string][]string `json:"filters,omitempty"`
+	// Deny selects the events the clients cannot see among the allowed
+	// ones, using the same filters as the events API.
+	Deny map[string][]string `json:"deny,omitempty"`
+	// Admin allows the clients to manage the events service: to list,
+	// pause, resume and evict its subscribers.
+	Admin bool `json:"admin,omitempty"`
+}
+
+// ACL is an access control list of the event stream.
+type ACL struct {
+	allow *Filter
+	deny  *Filter
+	admin bool
+}
+
+// Include returns true if the event can be sent to the clients of the list.
+func (a *ACL) Include(ev eventtypes.Message) bool {
+	if a.allow != nil && !a.allow.Include(ev) {
+		return false
+	}
+	return a.deny == nil || !a.deny.Include(ev)
+}
+
+// ACLs are the access control lists of the event stream, by identity of
+// the clients.
+type ACLs struct {
+	lists map[string]*ACL
+}
+
+// NewACLs returns the access control lists of configs, after checking that
+// their filters are valid and that each identity is named by a single list.
+func NewACLs(configs []ACLConfig) (*ACLs, error) {
+	acls := &ACLs{lists: make(map[string]*ACL)}
+	for _, c := range configs {
+		if len(c.Identities) == 0 {
+			return nil, fmt.Errorf("invalid events ACL: no identities")
+		}
+		acl := &ACL{admin: c.Admin}
+		var err error
+		if len(c.Filters) > 0 {
+			if acl.allow, err = parseFilterMap(c.Filters); err != nil {
+				return nil, fmt.Errorf("invalid events ACL filters: %v", err)
+			}
+		}
+		if len(c.Deny) > 0 {
+			if acl.deny, err = parseFilterMap(c.Deny); err != nil {
+				return nil, fmt.Errorf("invalid events ACL deny filters: %v", err)
+			}
+		}
+		for _, identity := range c.Identities {
+			if _, exists := acls.lists[identity]; exists {
+				return nil, fmt.Errorf("invalid events ACL: %q is named by several lists", identity)
+			}
+			acls.lists[identity] = acl
+		}
+	}
+	return acls, nil
+}
+
+// parseFilterMap parses the filters names and values of the configuration
+// file of the daemon, like ParseFilter.
+func parseFilterMap(m map[string][]string) (*Filter, error) {
+	args := filters.NewArgs()
+	for name, values := range m {
+		for _, value := range values {
+			args.Add(name, value)
+		}
+	}
+	return ParseFilter(args)
+}
+
+// Lookup returns the access control list of a client identity, or nil when
+// the client can see all events.
+func (a *ACLs) Lookup(identity string) *ACL {
+	if acl, ok := a.lists[identity]; ok && identity != "" {
+		return acl
+	}
+	return a.lists[anyIdentity]
+}
+
+// SetACLs restricts the events the API clients can see, or removes the
+// restrictions when acls is nil.
+func (e *Events) SetACLs(acls *ACLs) {
+	e.logMu.Lock()
+	e.acls = acls
+	e.logMu.Unlock()
+}
+
+// Admin returns true if the client identity can manage the events
+// service. Every client can when neither access control lists are set nor
+// the event stream is scoped per tenant, otherwise only the clients of the
+// lists that allow it can.
+func (e *Events) Admin(identity string) bool {
+	e.logMu.Lock()
+	acls := e.acls
+	e.logMu.Unlock()
+	scoped := e.TenantScoping()
+	if acls == nil {
+		return !scoped
+	}
+	acl := acls.Lookup(identity)
+	return acl != nil && acl.admin
+}
+
+// ACL returns the access control list of a client identity, or nil when
+// the client can see all events.
+func (e *Events) ACL(identity string) *ACL {
+	e.logMu.Lock()
+	acls := e.acls
+	e.logMu.Unlock()
+	if acls == nil {
+		return nil
+	}
+	return acls.Lookup(identity)
+}
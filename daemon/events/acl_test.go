@@ -0,0 +1,91 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestACLs(t *testing.T) {
+	acls, err := NewACLs([]ACLConfig{
+		{
+			Identities: []string{"monitoring"},
+			Filters:    map[string][]string{"type": {"container", "image"}},
+			Deny:       map[string][]string{"event": {"exec_create", "exec_start", "exec_die"}},
+		},
+		{
+			Identities: []string{"*"},
+			Deny:       map[string][]string{"type": {"audit"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start"}}
+	exec := events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "exec_start: sh -c true"}}
+	network := events.Message{Message: eventtypes.Message{Type: events.NetworkEventType, Action: "connect"}}
+	audit := events.Message{Message: eventtypes.Message{Type: events.AuditEventType, Action: "exec"}}
+
+	cases := []struct {
+		identity string
+		ev       events.Message
+		included bool
+	}{
+		{"monitoring", start, true},
+		{"monitoring", exec, false},
+		{"monitoring", network, false},
+		{"monitoring", audit, false},
+		{"admin", exec, true},
+		{"admin", network, true},
+		{"admin", audit, false},
+		{"", audit, false},
+	}
+	for _, c := range cases {
+		if included := acls.Lookup(c.identity).Include(c.ev); included != c.included {
+			t.Errorf("Expected %s %s to be included for %q: %v, got %v", c.ev.Type, c.ev.Action, c.identity, c.included, included)
+		}
+	}
+
+	unrestricted, err := NewACLs([]ACLConfig{{Identities: []string{"monitoring"}, Filters: map[string][]string{"type": {"container"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acl := unrestricted.Lookup("admin"); acl != nil {
+		t.Fatal("Expected the clients no list names to be unrestricted")
+	}
+}
+
+func TestInvalidACLs(t *testing.T) {
+	for _, configs := range [][]ACLConfig{
+		{{Filters: map[string][]string{"type": {"container"}}}},
+		{{Identities: []string{"monitoring"}, Filters: map[string][]string{"unknown": {"x"}}}},
+		{{Identities: []string{"monitoring"}, Deny: map[string][]string{"name": {"~("}}}},
+		{{Identities: []string{"monitoring"}}, {Identities: []string{"monitoring"}}},
+	} {
+		if _, err := NewACLs(configs); err == nil {
+			t.Fatalf("Expected an error for %v", configs)
+		}
+	}
+}
+
+func TestACLsAdmin(t *testing.T) {
+	e := New(0)
+	defer e.Close()
+	if !e.Admin("monitoring") || !e.Admin("") {
+		t.Fatal("Expected every client to be an admin without access control lists")
+	}
+	acls, err := NewACLs([]ACLConfig{
+		{Identities: []string{"operator"}, Admin: true},
+		{Identities: []string{"monitoring"}, Filters: map[string][]string{"type": {"container"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetACLs(acls)
+	for identity, admin := range map[string]bool{"operator": true, "monitoring": false, "other": false, "": false} {
+		if e.Admin(identity) != admin {
+			t.Errorf("Expected %q to be an admin: %v", identity, admin)
+		}
+	}
+}
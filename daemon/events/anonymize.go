@@ -0,0 +1,167 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// anonymizedLength is the number of hexadecimal digits of the hash that
+// replaces a value, except the IDs, which are replaced by hashes of the
+// same length.
+const anonymizedLength = 16
+
+// fullID matches the IDs of the containers and the images.
+var fullID = regexp.MustCompile(`^(sha256:)?[0-9a-f]{64}$`)
+
+// plainAttributes are the attributes the daemon sets whose values do not
+// identify anything, they are kept by the anonymization.
+var plainAttributes = map[string]bool{
+	"attempt":           true,
+	"compacted.actions": true,
+	"compacted.count":   true,
+	"compacted.since":   true,
+	"count":             true,
+	"delay":             true,
+	"driver":            true,
+	"event.action":      true,
+	"event.sequence":    true,
+	"event.type":        true,
+	"exitCode":          true,
+	"failures":          true,
+	"height":            true,
+	"limit":             true,
+	"maxRetries":        true,
+	"outcome":           true,
+	"policy":            true,
+	"propagation":       true,
+	"reclaimed":         true,
+	"rw":                true,
+	"signal":            true,
+	"threshold":         true,
+	"type":              true,
+	"usage":             true,
+	"utilization":       true,
+	"wasRunning":        true,
+	"width":             true,
+}
+
+// daemonAttributes are the other attributes the daemon sets, their names
+// are kept and their values hashed. The names of the attributes that are
+// neither, such as the labels of the containers, are hashed too.
+var daemonAttributes = map[string]bool{
+	"address":              true,
+	"client":               true,
+	"command":              true,
+	"container":            true,
+	"destination":          true,
+	"endpoint":             true,
+	"error":                true,
+	"execID":               true,
+	"image":                true,
+	"macAddress":           true,
+	"name":                 true,
+	"node.id":              true,
+	"node.name":            true,
+	"oldName":              true,
+	"origin.client":        true,
+	"origin.correlationID": true,
+	"origin.requestID":     true,
+	"origin.user":          true,
+	"path":                 true,
+	"source":               true,
+	"team":                 true,
+	"user":                 true,
+	"username":             true,
+}
+
+// Anonymizer replaces the names, IDs and labels of the events by hashes,
+// so that the events can be shared without leaking them. The hashes are
+// keyed by a secret of the anonymizer, so that they cannot be reversed by
+// hashing known names, and a value has the same hash in all the events
+// it anonymizes, so that they can still be related.
+type Anonymizer struct {
+	key []byte
+}
+
+// NewAnonymizer returns an anonymizer with a random secret.
+func NewAnonymizer() *Anonymizer {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &Anonymizer{key: key}
+}
+
+// Anonymize returns a copy of the event m where the ID of the actor, the
+// details of the action, and the attributes that may identify something
+// are hashed. The signature of the event, that no longer matches, is
+// removed.
+func (a *Anonymizer) Anonymize(m eventtypes.Message) eventtypes.Message {
+	m.Actor.ID = a.hash(m.Actor.ID)
+	m.ID = a.hash(m.ID)
+	m.From = a.hash(m.From)
+	m.Action = a.action(m.Action)
+	m.Status = a.action(m.Status)
+	m.Signature = ""
+	if m.Actor.Attributes != nil {
+		attrs := make(map[string]string, len(m.Actor.Attributes))
+		for k, v := range m.Actor.Attributes {
+			switch {
+			case plainAttributes[k]:
+				attrs[k] = v
+			case daemonAttributes[k]:
+				attrs[k] = a.hash(v)
+			default:
+				attrs[a.hash(k)] = a.hash(v)
+			}
+		}
+		m.Actor.Attributes = attrs
+	}
+	return m
+}
+
+// action hashes the details of action, such as the command of an exec,
+// except the status of the health checks.
+func (a *Anonymizer) action(action string) string {
+	i := strings.Index(action, ": ")
+	if i < 0 || strings.HasPrefix(action, "health_status") {
+		return action
+	}
+	return action[:i+2] + a.hash(action[i+2:])
+}
+
+// hash returns the keyed hash of value, which is as long as value when it
+// is an ID.
+func (a *Anonymizer) hash(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	if fullID.MatchString(value) {
+		if strings.HasPrefix(value, "sha256:") {
+			return "sha256:" + sum
+		}
+		return sum
+	}
+	return sum[:anonymizedLength]
+}
+
+// Hash returns the keyed hash of value, as it appears in the events the
+// anonymizer anonymizes.
+func (a *Anonymizer) Hash(value string) string {
+	return a.hash(value)
+}
+
+// Anonymizer returns the anonymizer of the events service, whose hashes
+// stay the same until the daemon restarts.
+func (e *Events) Anonymizer() *Anonymizer {
+	return e.anonymizer
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestAnonymize(t *testing.T) {
+	id := strings.Repeat("5745704abe9caa5", 5)[:64]
+	m := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "exec_start: cat /run/secrets/db",
+			Actor: eventtypes.Actor{
+				ID: id,
+				Attributes: map[string]string{
+					"image":            "acme/billing:1.2",
+					"name":             "billing-db",
+					"exitCode":         "137",
+					"com.acme.project": "falcon",
+				},
+			},
+			TimeNano: 42,
+		},
+		Sequence:  7,
+		Signature: "ed25519:c2lnbmF0dXJl",
+	}
+	a := NewAnonymizer()
+	anon := a.Anonymize(m)
+
+	if anon.Type != m.Type || anon.TimeNano != m.TimeNano || anon.Sequence != m.Sequence {
+		t.Fatalf("Expected the type, time and sequence kept, got %+v", anon)
+	}
+	if anon.Signature != "" {
+		t.Fatalf("Expected the signature removed, got %q", anon.Signature)
+	}
+	if len(anon.Actor.ID) != 64 || anon.Actor.ID == id {
+		t.Fatalf("Expected the ID replaced by a hash of the same length, got %q", anon.Actor.ID)
+	}
+	if !strings.HasPrefix(anon.Action, "exec_start: ") || strings.Contains(anon.Action, "secrets") {
+		t.Fatalf("Expected the command of the exec hashed, got %q", anon.Action)
+	}
+	attrs := anon.Actor.Attributes
+	if len(attrs) != 4 || attrs["exitCode"] != "137" {
+		t.Fatalf("Expected the exit code kept, got %v", attrs)
+	}
+	for _, name := range []string{"image", "name"} {
+		if v := attrs[name]; v == "" || v == m.Actor.Attributes[name] {
+			t.Fatalf("Expected the %s hashed, got %v", name, attrs)
+		}
+	}
+	for k, v := range attrs {
+		if strings.Contains(k, "acme") || strings.Contains(v, "falcon") {
+			t.Fatalf("Expected the label hashed, got %v", attrs)
+		}
+	}
+	if m.Actor.Attributes["name"] != "billing-db" {
+		t.Fatal("Expected the event not modified")
+	}
+
+	// The same values have the same hashes in all the events.
+	if again := a.Anonymize(m); again.Actor.ID != anon.Actor.ID || again.Actor.Attributes["name"] != attrs["name"] {
+		t.Fatalf("Expected the same hashes, got %+v and %+v", anon, again)
+	}
+	// But not with another secret.
+	if other := NewAnonymizer().Anonymize(m); other.Actor.ID == anon.Actor.ID {
+		t.Fatal("Expected different hashes with another secret")
+	}
+
+	health := events.Message{Message: eventtypes.Message{Action: "health_status: unhealthy"}}
+	if anon := a.Anonymize(health); anon.Action != health.Action {
+		t.Fatalf("Expected the health status kept, got %q", anon.Action)
+	}
+}
@@ -0,0 +1,268 @@
+// Package awslogs provides the events exporter sending the daemon events
+// to Amazon CloudWatch Logs.
+package awslogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/dockerversion"
+)
+
+const (
+	name = "awslogs"
+
+	regionKey          = "awslogs-region"
+	groupKey           = "awslogs-group"
+	streamKey          = "awslogs-stream"
+	createGroupKey     = "awslogs-create-group"
+	credentialsFileKey = "awslogs-credentials-file"
+	profileKey         = "awslogs-profile"
+
+	// See: http://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+	perEventBytes          = 26
+	maximumBytesPerPut     = 1048576
+	maximumLogEventsPerPut = 10000
+
+	resourceAlreadyExistsCode = "ResourceAlreadyExistsException"
+	dataAlreadyAcceptedCode   = "DataAlreadyAcceptedException"
+	invalidSequenceTokenCode  = "InvalidSequenceTokenException"
+
+	userAgentHeader = "User-Agent"
+)
+
+func init() {
+	if err := events.RegisterExporter(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+type api interface {
+	CreateLogGroup(*cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(*cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(*cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+type regionFinder interface {
+	Region() (string, error)
+}
+
+type exporter struct {
+	client        api
+	group         string
+	stream        string
+	createGroup   bool
+	created       bool
+	sequenceToken *string
+}
+
+// New creates an awslogs exporter with the given options. Supported
+// options are awslogs-region, awslogs-group, awslogs-stream,
+// awslogs-create-group, awslogs-credentials-file and awslogs-profile. The
+// credentials are read from the given shared credentials file and
+// profile when set, and otherwise from the environment variables
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, the shared credentials file
+// (~/.aws/credentials), and the EC2 Instance Metadata Service.
+func New(options map[string]string) (events.Exporter, error) {
+	for key := range options {
+		switch key {
+		case regionKey:
+		case groupKey:
+		case streamKey:
+		case createGroupKey:
+		case credentialsFileKey:
+		case profileKey:
+		default:
+			return nil, fmt.Errorf("unknown option '%s' for %s events exporter", key, name)
+		}
+	}
+	if options[groupKey] == "" {
+		return nil, fmt.Errorf("%s is required by the %s events exporter", groupKey, name)
+	}
+	client, err := newClient(options)
+	if err != nil {
+		return nil, err
+	}
+	return newExporter(client, options)
+}
+
+// newExporter creates an awslogs exporter using client.
+func newExporter(client api, options map[string]string) (*exporter, error) {
+	stream := options[streamKey]
+	if stream == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		stream = "docker-events-" + hostname
+	}
+	return &exporter{
+		client:      client,
+		group:       options[groupKey],
+		stream:      stream,
+		createGroup: options[createGroupKey] == "true",
+	}, nil
+}
+
+// newRegionFinder is a variable such that the implementation
+// can be swapped out for unit tests.
+var newRegionFinder = func() regionFinder {
+	return ec2metadata.New(nil)
+}
+
+// newClient creates the service client for Amazon CloudWatch Logs, with a
+// Docker-specific User-Agent string. The region is read from the
+// EC2 Instance Metadata Service when it is not otherwise specified.
+func newClient(options map[string]string) (api, error) {
+	config := defaults.DefaultConfig
+	if options[regionKey] != "" {
+		config = config.Merge(&aws.Config{
+			Region: aws.String(options[regionKey]),
+		})
+	}
+	if options[credentialsFileKey] != "" || options[profileKey] != "" {
+		config = config.Merge(&aws.Config{
+			Credentials: credentials.NewSharedCredentials(options[credentialsFileKey], options[profileKey]),
+		})
+	}
+	if config.Region == nil || *config.Region == "" {
+		region, err := newRegionFinder().Region()
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine region for %s events exporter: %v", name, err)
+		}
+		config.Region = &region
+	}
+	client := cloudwatchlogs.New(config)
+	client.Handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "DockerUserAgentHandler",
+		Fn: func(r *request.Request) {
+			currentAgent := r.HTTPRequest.Header.Get(userAgentHeader)
+			r.HTTPRequest.Header.Set(userAgentHeader,
+				fmt.Sprintf("Docker %s (%s) %s",
+					dockerversion.Version, runtime.GOOS, currentAgent))
+		},
+	})
+	return client, nil
+}
+
+// Export sends the event m to the log stream.
+func (x *exporter) Export(m eventtypes.Message) error {
+	return x.ExportBatch([]eventtypes.Message{m})
+}
+
+// ExportBatch sends the events ms to the log stream, in as few
+// PutLogEvents requests as the limits of CloudWatch Logs allow.
+func (x *exporter) ExportBatch(ms []eventtypes.Message) error {
+	if !x.created {
+		if err := x.create(); err != nil {
+			return err
+		}
+		x.created = true
+	}
+
+	var (
+		batch []*cloudwatchlogs.InputLogEvent
+		bytes int
+	)
+	for _, m := range ms {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if len(batch) >= maximumLogEventsPerPut || bytes+len(b)+perEventBytes > maximumBytesPerPut {
+			if err := x.publish(batch); err != nil {
+				return err
+			}
+			batch, bytes = nil, 0
+		}
+		batch = append(batch, &cloudwatchlogs.InputLogEvent{
+			Message:   aws.String(string(b)),
+			Timestamp: aws.Int64(m.TimeNano / 1e6),
+		})
+		bytes += len(b) + perEventBytes
+	}
+	return x.publish(batch)
+}
+
+// create creates the log stream, and the log group first if requested.
+func (x *exporter) create() error {
+	if x.createGroup {
+		_, err := x.client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+			LogGroupName: aws.String(x.group),
+		})
+		if err != nil && !isCode(err, resourceAlreadyExistsCode) {
+			return err
+		}
+	}
+	_, err := x.client.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(x.group),
+		LogStreamName: aws.String(x.stream),
+	})
+	if err != nil && !isCode(err, resourceAlreadyExistsCode) {
+		return err
+	}
+	return nil
+}
+
+// publish calls PutLogEvents for the events, accounting for the sequence
+// token returned by the previous request.
+func (x *exporter) publish(batch []*cloudwatchlogs.InputLogEvent) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	token, err := x.putLogEvents(batch, x.sequenceToken)
+	if awsErr, ok := err.(awserr.Error); ok {
+		// The error message ends with the expected sequence token.
+		parts := strings.Split(awsErr.Message(), " ")
+		expected := parts[len(parts)-1]
+		switch awsErr.Code() {
+		case dataAlreadyAcceptedCode:
+			token, err = &expected, nil
+		case invalidSequenceTokenCode:
+			token, err = x.putLogEvents(batch, &expected)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	x.sequenceToken = token
+	return nil
+}
+
+// putLogEvents wraps the PutLogEvents API.
+func (x *exporter) putLogEvents(batch []*cloudwatchlogs.InputLogEvent, sequenceToken *string) (*string, error) {
+	resp, err := x.client.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+		LogEvents:     batch,
+		SequenceToken: sequenceToken,
+		LogGroupName:  aws.String(x.group),
+		LogStreamName: aws.String(x.stream),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.NextSequenceToken, nil
+}
+
+// Close does nothing, the events are sent synchronously.
+func (x *exporter) Close() error {
+	return nil
+}
+
+// isCode returns true if err is an AWS error with the given code.
+func isCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,119 @@
+package awslogs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+)
+
+type mockClient struct {
+	groups  []string
+	streams []string
+	puts    []*cloudwatchlogs.PutLogEventsInput
+	errs    []error
+}
+
+func (c *mockClient) CreateLogGroup(input *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	c.groups = append(c.groups, *input.LogGroupName)
+	return nil, awserr.New(resourceAlreadyExistsCode, "exists", nil)
+}
+
+func (c *mockClient) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.streams = append(c.streams, *input.LogStreamName)
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (c *mockClient) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.puts = append(c.puts, input)
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &cloudwatchlogs.PutLogEventsOutput{
+		NextSequenceToken: aws.String("token" + strings.Repeat("+", len(c.puts))),
+	}, nil
+}
+
+func TestNewOptions(t *testing.T) {
+	if _, err := New(map[string]string{"awslogs-region": "us-east-1"}); err == nil {
+		t.Fatal("Expected an error without awslogs-group")
+	}
+	if _, err := New(map[string]string{"awslogs-group": "g", "awslogs-unknown": "x"}); err == nil {
+		t.Fatal("Expected an error for an unknown option")
+	}
+}
+
+func TestExportBatch(t *testing.T) {
+	client := &mockClient{}
+	x, err := newExporter(client, map[string]string{
+		groupKey:       "group",
+		streamKey:      "stream",
+		createGroupKey: "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ms []eventtypes.Message
+	for i := 0; i < 3; i++ {
+		ms = append(ms, eventtypes.Message{Message: engineevents.Message{Type: "container", Action: "start", TimeNano: int64(i) * 1e6}})
+	}
+	if err := x.ExportBatch(ms); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.groups) != 1 || client.groups[0] != "group" {
+		t.Fatalf("Expected the log group to be created, got %v", client.groups)
+	}
+	if len(client.streams) != 1 || client.streams[0] != "stream" {
+		t.Fatalf("Expected the log stream to be created, got %v", client.streams)
+	}
+	if len(client.puts) != 1 || len(client.puts[0].LogEvents) != 3 {
+		t.Fatalf("Expected the events to be put at once, got %v", client.puts)
+	}
+	ev := client.puts[0].LogEvents[2]
+	var m eventtypes.Message
+	if err := json.Unmarshal([]byte(*ev.Message), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Action != "start" || *ev.Timestamp != 2 {
+		t.Fatalf("Unexpected log event %v", ev)
+	}
+
+	if err := x.Export(ms[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.streams) != 1 {
+		t.Fatalf("Expected the log stream to be created once, got %v", client.streams)
+	}
+	if len(client.puts) != 2 || *client.puts[1].SequenceToken != "token+" {
+		t.Fatalf("Expected the sequence token of the previous request, got %v", client.puts)
+	}
+}
+
+func TestExportInvalidSequenceToken(t *testing.T) {
+	client := &mockClient{
+		errs: []error{awserr.New(invalidSequenceTokenCode, "The given sequenceToken is invalid. The next expected sequenceToken is: expected", nil)},
+	}
+	x, err := newExporter(client, map[string]string{groupKey: "group", streamKey: "stream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Export(eventtypes.Message{Message: engineevents.Message{Action: "start"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.groups) != 0 {
+		t.Fatalf("Expected the log group not to be created, got %v", client.groups)
+	}
+	if len(client.puts) != 2 || *client.puts[1].SequenceToken != "expected" {
+		t.Fatalf("Expected a retry with the expected sequence token, got %v", client.puts)
+	}
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// backfillBackoff is the delay before the first attempt to export the
+// missed events to an exporter that failed, it doubles after every
+// attempt up to backfillMaxBackoff.
+var backfillBackoff = time.Second
+
+const backfillMaxBackoff = 30 * time.Second
+
+// recover waits for the exporter to recover from a failure, and exports
+// the events it missed since the last one it exported, read from memory
+// and from the journal. It returns false if the forwarder was stopped
+// first.
+func (f *Forwarder) recover() bool {
+	backoff := backfillBackoff
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-f.stop:
+			f.mu.Lock()
+			acked := f.acked
+			f.mu.Unlock()
+			logrus.Errorf("Giving up the backfill of events exporter %s, the events after sequence %d were not exported", f.name, acked)
+			return false
+		}
+		if backoff *= 2; backoff > backfillMaxBackoff {
+			backoff = backfillMaxBackoff
+		}
+
+		f.mu.Lock()
+		acked := f.acked
+		f.mu.Unlock()
+		backlog, err := f.events.eventsAfter(acked, f.filter)
+		if err != nil {
+			logrus.Errorf("Error reading the events missed by events exporter %s: %v", f.name, err)
+			continue
+		}
+		if err := f.exportAll(backlog); err != nil {
+			logrus.Debugf("Retrying the backfill of events exporter %s in %s: %v", f.name, backoff, err)
+			continue
+		}
+
+		// The events backfilled that are still queued are not exported
+		// twice.
+		f.mu.Lock()
+		i := 0
+		for i < len(f.queue) && f.queue[i].Sequence <= f.acked {
+			i++
+		}
+		f.queue = f.queue[i:]
+		f.mu.Unlock()
+		logrus.Infof("Events exporter %s recovered, %d events backfilled", f.name, len(backlog))
+		return true
+	}
+}
+
+// exportAll exports the events evs in order, in batches of the size of
+// the queue when the exporter supports them.
+func (f *Forwarder) exportAll(evs []eventtypes.Message) error {
+	bx, batched := f.x.(BatchExporter)
+	for len(evs) > 0 {
+		n := 1
+		if batched {
+			if n = len(evs); n > f.queueSize {
+				n = f.queueSize
+			}
+		}
+		var err error
+		if batched {
+			err = bx.ExportBatch(evs[:n])
+		} else {
+			err = f.x.Export(evs[0])
+		}
+		f.record(evs[:n], err)
+		if err != nil {
+			return err
+		}
+		evs = evs[n:]
+	}
+	return nil
+}
+
+// eventsAfter returns the events kept in memory and in the journal whose
+// sequence number is greater than seq and that are included by ef, if it
+// is not nil.
+func (e *Events) eventsAfter(seq uint64, ef *Filter) ([]eventtypes.Message, error) {
+	e.mu.Lock()
+	recent, journal := e.recent.events(), e.journal
+	var limit int64
+	if journal != nil {
+		limit = journal.Size()
+	}
+	e.mu.Unlock()
+
+	include := func(ev eventtypes.Message) bool {
+		return ev.Sequence > seq && (ef == nil || ef.Include(ev))
+	}
+	var evs []eventtypes.Message
+	if journal != nil && (len(recent) == 0 || recent[0].Sequence > seq+1) {
+		var oldest uint64
+		if len(recent) > 0 {
+			oldest = recent[0].Sequence
+		}
+		var err error
+		evs, err = loadJournal(journal, limit, oldest, include, include)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ev := range recent {
+		if include(ev) {
+			evs = append(evs, ev)
+		}
+	}
+	return evs, nil
+}
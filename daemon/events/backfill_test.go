@@ -0,0 +1,119 @@
+package events
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+// flakyExporter fails until it is up.
+type flakyExporter struct {
+	mu       sync.Mutex
+	up       bool
+	exported []events.Message
+}
+
+func (x *flakyExporter) Export(m events.Message) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.up {
+		return errors.New("broker unavailable")
+	}
+	x.exported = append(x.exported, m)
+	return nil
+}
+
+func (x *flakyExporter) Close() error {
+	return nil
+}
+
+func (x *flakyExporter) setUp(up bool) {
+	x.mu.Lock()
+	x.up = up
+	x.mu.Unlock()
+}
+
+func (x *flakyExporter) count() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.exported)
+}
+
+func TestForwarderBackfill(t *testing.T) {
+	defer func(d time.Duration) { backfillBackoff = d }(backfillBackoff)
+	backfillBackoff = 10 * time.Millisecond
+
+	tmp, err := ioutil.TempDir("", "events-backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	// The events logged before the forwarder starts are not backfilled,
+	// and only the last 4 events are kept in memory.
+	e := New(4)
+	e.SetJournal(j)
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "old"})
+
+	x := &flakyExporter{up: true}
+	f := NewForwarder("flaky", x, NewFilterFromMap(map[string][]string{"type": {"container"}}), 2)
+	f.backfill = true
+	f.Start(e)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "0"})
+	waitExported(t, x, 1)
+
+	// While the exporter is down, its queue of 2 events overflows.
+	x.setUp(false)
+	for i := 1; i <= 10; i++ {
+		e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: strconv.Itoa(i)})
+		e.Log("connect", events.NetworkEventType, eventtypes.Actor{ID: "net"})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for f.Status().Failed == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for the exporter to fail")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	x.setUp(true)
+	waitExported(t, x, 11)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "11"})
+	waitExported(t, x, 12)
+	f.Stop()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.exported) != 12 {
+		t.Fatalf("Expected 12 events exported, got %d", len(x.exported))
+	}
+	for i, m := range x.exported {
+		if m.Type != events.ContainerEventType || m.Actor.ID != strconv.Itoa(i) {
+			t.Fatalf("Unexpected event %d: %v", i, m)
+		}
+	}
+	if s := f.Status(); !s.Connected || s.Sequence != x.exported[11].Sequence {
+		t.Fatalf("Unexpected status %+v", s)
+	}
+}
+
+func waitExported(t *testing.T, x *flakyExporter, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for x.count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %d events exported, got %d", n, x.count())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
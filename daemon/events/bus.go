@@ -0,0 +1,54 @@
+package events
+
+import (
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+)
+
+// Topic is a topic of the event bus of the daemon: the type of the events
+// published to it, and the attributes all of them carry.
+type Topic struct {
+	// Type is the type of the events of the topic.
+	Type string
+	// Attributes are the attributes every event of the topic carries,
+	// they are empty when the publisher does not know them.
+	Attributes []string
+}
+
+// The topics of the subsystems of the daemon.
+var (
+	ContainerTopic = Topic{Type: eventtypes.ContainerEventType, Attributes: []string{"name"}}
+	ImageTopic     = Topic{Type: eventtypes.ImageEventType}
+	VolumeTopic    = Topic{Type: eventtypes.VolumeEventType, Attributes: []string{"driver"}}
+	NetworkTopic   = Topic{Type: eventtypes.NetworkEventType, Attributes: []string{"name", "type"}}
+	BuildTopic     = Topic{Type: eventtypes.BuildEventType}
+	DaemonTopic    = Topic{Type: eventtypes.DaemonEventType, Attributes: []string{"name"}}
+	AuditTopic     = Topic{Type: eventtypes.AuditEventType}
+	RegistryTopic  = Topic{Type: eventtypes.RegistryEventType}
+)
+
+// Bus is the interface of the event bus the subsystems of the daemon
+// publish their events to.
+type Bus interface {
+	// Publish publishes the event action about the object id on the
+	// topic.
+	Publish(topic Topic, action, id string, attributes map[string]string)
+}
+
+var _ Bus = &Events{}
+
+// Publish publishes the event action about the object id on the topic,
+// making sure it carries the attributes of the topic. Attributes is not
+// modified.
+func (e *Events) Publish(topic Topic, action, id string, attributes map[string]string) {
+	attrs := make(map[string]string, len(attributes)+len(topic.Attributes))
+	for k, v := range attributes {
+		attrs[k] = v
+	}
+	for _, k := range topic.Attributes {
+		if _, ok := attrs[k]; !ok {
+			attrs[k] = ""
+		}
+	}
+	e.Log(action, topic.Type, engineevents.Actor{ID: id, Attributes: attrs})
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestPublish(t *testing.T) {
+	e := New(0)
+	attributes := map[string]string{"container": "cont"}
+	e.Publish(NetworkTopic, "connect", "net", attributes)
+
+	recent := e.recent.events()
+	if len(recent) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(recent))
+	}
+	ev := recent[0]
+	if ev.Type != events.NetworkEventType || ev.Action != "connect" || ev.Actor.ID != "net" {
+		t.Fatalf("Unexpected event %v", ev)
+	}
+	for _, k := range []string{"name", "type", "container"} {
+		if _, ok := ev.Actor.Attributes[k]; !ok {
+			t.Fatalf("Expected the %s attribute, got %v", k, ev.Actor.Attributes)
+		}
+	}
+	if len(attributes) != 1 {
+		t.Fatalf("Expected the attributes not to be modified, got %v", attributes)
+	}
+}
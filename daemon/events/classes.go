@@ -0,0 +1,108 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// eventClass selects the events of a type, and of some actions of it.
+type eventClass struct {
+	eventType string
+	// action is a pattern, as in path.Match, of the actions of the
+	// class, all actions are selected when it is empty.
+	action string
+}
+
+// match returns true if the event is of the class. The action of the exec
+// and health status events is matched without its details.
+func (c eventClass) match(eventType, action string) bool {
+	if c.eventType != eventType {
+		return false
+	}
+	if c.action == "" {
+		return true
+	}
+	if ok, _ := path.Match(c.action, action); ok {
+		return true
+	}
+	if i := strings.Index(action, ":"); i > 0 {
+		ok, _ := path.Match(c.action, action[:i])
+		return ok
+	}
+	return false
+}
+
+// EventClasses selects the events the daemon emits, the others are dropped
+// before they reach the pipeline of the events service.
+type EventClasses struct {
+	types    map[string]bool
+	disabled []eventClass
+}
+
+// NewEventClasses returns the classes of events emitted: the events of
+// types, or of all types when it is empty, except the disabled ones. A
+// disabled class is either a type, or a type and an action pattern
+// separated by a colon, such as container:exec_*.
+func NewEventClasses(types, disabled []string) (*EventClasses, error) {
+	c := &EventClasses{}
+	for _, t := range types {
+		if !knownTypes[t] {
+			return nil, fmt.Errorf("invalid events type %q", t)
+		}
+		if c.types == nil {
+			c.types = make(map[string]bool)
+		}
+		c.types[t] = true
+	}
+	for _, d := range disabled {
+		parts := strings.SplitN(d, ":", 2)
+		if !knownTypes[parts[0]] {
+			return nil, fmt.Errorf("invalid disabled events %q: unknown type %q", d, parts[0])
+		}
+		class := eventClass{eventType: parts[0]}
+		if len(parts) == 2 {
+			if _, err := path.Match(parts[1], ""); err != nil || parts[1] == "" {
+				return nil, fmt.Errorf("invalid disabled events %q: bad action pattern", d)
+			}
+			class.action = parts[1]
+		}
+		c.disabled = append(c.disabled, class)
+	}
+	return c, nil
+}
+
+// knownTypes are the types of the events the daemon emits.
+var knownTypes = map[string]bool{
+	eventtypes.ContainerEventType: true,
+	eventtypes.ImageEventType:     true,
+	eventtypes.VolumeEventType:    true,
+	eventtypes.NetworkEventType:   true,
+	eventtypes.BuildEventType:     true,
+	eventtypes.DaemonEventType:    true,
+	eventtypes.AuditEventType:     true,
+	eventtypes.RegistryEventType:  true,
+}
+
+// emits returns true if the daemon emits the event.
+func (c *EventClasses) emits(eventType, action string) bool {
+	if c.types != nil && !c.types[eventType] {
+		return false
+	}
+	for _, d := range c.disabled {
+		if d.match(eventType, action) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetEventClasses makes the events service drop the events that are not
+// of classes, or emit all events when classes is nil.
+func (e *Events) SetEventClasses(classes *EventClasses) {
+	e.pipelineMu.Lock()
+	e.classes = classes
+	e.pipelineMu.Unlock()
+}
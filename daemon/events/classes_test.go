@@ -0,0 +1,39 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestEventClasses(t *testing.T) {
+	classes, err := NewEventClasses([]string{"container", "image"}, []string{"container:exec_*", "image:untag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetEventClasses(classes)
+
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Log("exec_start: sh -c true", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Log("exec_die", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Log("pull", events.ImageEventType, events.Actor{ID: "img"})
+	e.Log("untag", events.ImageEventType, events.Actor{ID: "img"})
+	e.Log("create", events.VolumeEventType, events.Actor{ID: "vol"})
+
+	recent := e.recent.events()
+	if len(recent) != 2 || recent[0].Action != "start" || recent[1].Action != "pull" {
+		t.Fatalf("Expected only the start and pull events, got %v", recent)
+	}
+
+	for _, c := range [][2][]string{
+		{{"unknown"}, nil},
+		{nil, {"unknown:start"}},
+		{nil, {"container:"}},
+		{nil, {"container:["}},
+	} {
+		if _, err := NewEventClasses(c[0], c[1]); err == nil {
+			t.Fatalf("Expected an error for %v", c)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+const (
+	// CloudEventsFormat is the name of the CloudEvents encoding of the
+	// events, for the API and the exporters.
+	CloudEventsFormat = "cloudevents"
+	// CloudEventsType is the media type of an event encoded as a
+	// CloudEvent in the structured mode.
+	CloudEventsType = "application/cloudevents+json"
+	// cloudEventsSpecVersion is the version of the CloudEvents
+	// specification the events follow.
+	cloudEventsSpecVersion = "1.0"
+	// cloudEventsTypePrefix prefixes the type and the action of the
+	// events in the type of the CloudEvents.
+	cloudEventsTypePrefix = "com.docker."
+)
+
+// CloudEvent is an event of the daemon as a CNCF CloudEvent, in the
+// structured JSON encoding. Its data is the event, as sent by the events
+// API.
+type CloudEvent struct {
+	SpecVersion     string             `json:"specversion"`
+	ID              string             `json:"id"`
+	Source          string             `json:"source"`
+	Type            string             `json:"type"`
+	Subject         string             `json:"subject,omitempty"`
+	Time            string             `json:"time"`
+	DataContentType string             `json:"datacontenttype"`
+	Data            eventtypes.Message `json:"data"`
+}
+
+// ToCloudEvent returns the event m as a CloudEvent. Its source is the
+// daemon that generated it, its type the type and the action of m, such as
+// com.docker.container.start, its subject the object it is about, and its
+// ID the sequence number of m, which is unique for the source.
+func ToCloudEvent(m eventtypes.Message) CloudEvent {
+	action := m.Action
+	if i := strings.Index(action, ":"); i > 0 {
+		// exec_start: sh -c ... and health_status: healthy
+		action = action[:i]
+	}
+	source := "/docker"
+	if id := m.Actor.Attributes["node.id"]; id != "" {
+		source += "/" + id
+	}
+	return CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              strconv.FormatUint(m.Sequence, 10),
+		Source:          source,
+		Type:            cloudEventsTypePrefix + m.Type + "." + action,
+		Subject:         m.Actor.ID,
+		Time:            time.Unix(0, m.TimeNano).UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            m,
+	}
+}
+
+// Marshaler returns the function encoding the events in format, which is
+// either json, the default, or cloudevents.
+func Marshaler(format string) (func(eventtypes.Message) ([]byte, error), error) {
+	switch format {
+	case "", "json":
+		return func(m eventtypes.Message) ([]byte, error) {
+			return json.Marshal(m)
+		}, nil
+	case CloudEventsFormat:
+		return func(m eventtypes.Message) ([]byte, error) {
+			return json.Marshal(ToCloudEvent(m))
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid events format %q, must be json or %s", format, CloudEventsFormat)
+}
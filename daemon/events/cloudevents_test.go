@@ -0,0 +1,55 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestToCloudEvent(t *testing.T) {
+	m := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "exec_start: sh -c true",
+			Actor: eventtypes.Actor{
+				ID:         "cont",
+				Attributes: map[string]string{"node.id": "ABCD:EFGH"},
+			},
+			TimeNano: 1442421716853979870,
+		},
+		Sequence: 42,
+	}
+	ce := ToCloudEvent(m)
+	if ce.SpecVersion != "1.0" || ce.ID != "42" || ce.Source != "/docker/ABCD:EFGH" || ce.Subject != "cont" {
+		t.Fatalf("Unexpected CloudEvent %+v", ce)
+	}
+	if ce.Type != "com.docker.container.exec_start" {
+		t.Fatalf("Unexpected CloudEvent type %s", ce.Type)
+	}
+	if ce.Time != "2015-09-16T16:41:56.85397987Z" {
+		t.Fatalf("Unexpected CloudEvent time %s", ce.Time)
+	}
+
+	marshal, err := Marshaler(CloudEventsFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"specversion", "id", "source", "type", "subject", "time", "datacontenttype", "data"} {
+		if _, ok := fields[k]; !ok {
+			t.Fatalf("Expected the %s attribute in %s", k, b)
+		}
+	}
+	if _, err := Marshaler("xml"); err == nil {
+		t.Fatal("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,364 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+	"github.com/docker/go-units"
+)
+
+// compactedAction is the action of the events that summarize the events
+// of a container removed by a compaction of the journal.
+const compactedAction = "compacted"
+
+// The attributes of the summaries, in addition to the ones of the last
+// event of the container summarized.
+const (
+	compactedCount   = "compacted.count"
+	compactedActions = "compacted.actions"
+	compactedSince   = "compacted.since"
+)
+
+// errJournalClosed is returned when the journal is closed while it is
+// compacted.
+var errJournalClosed = errors.New("events journal is closed")
+
+// containerSummary accumulates the events of a container compacted.
+type containerSummary struct {
+	// entries is the number of entries of the journal summarized, and
+	// last the last one, whose place the summary takes.
+	entries int
+	last    eventtypes.Message
+	// count is the number of events summarized, by action in actions,
+	// since the time of the first one.
+	count   int
+	actions map[string]int
+	since   int64
+}
+
+func (s *containerSummary) add(ev eventtypes.Message) {
+	if s.entries == 0 {
+		s.since = ev.TimeNano
+		s.actions = make(map[string]int)
+	}
+	s.entries++
+	s.last = ev
+	if ev.Action != compactedAction {
+		s.count++
+		s.actions[actionName(ev.Action)]++
+		return
+	}
+	// A summary of a previous compaction.
+	attrs := ev.Actor.Attributes
+	if since, err := strconv.ParseInt(attrs[compactedSince], 10, 64); err == nil && since < s.since {
+		s.since = since
+	}
+	if count, err := strconv.Atoi(attrs[compactedCount]); err == nil {
+		s.count += count
+	}
+	for _, a := range strings.Split(attrs[compactedActions], ",") {
+		i := strings.LastIndex(a, "=")
+		if i < 0 {
+			continue
+		}
+		if n, err := strconv.Atoi(a[i+1:]); err == nil {
+			s.actions[a[:i]] += n
+		}
+	}
+}
+
+// message returns the summary event.
+func (s *containerSummary) message() eventtypes.Message {
+	m := eventtypes.Message{
+		Message: engineevents.Message{
+			Type:     eventtypes.ContainerEventType,
+			Action:   compactedAction,
+			Time:     s.last.Time,
+			TimeNano: s.last.TimeNano,
+			Actor:    engineevents.Actor{ID: s.last.Actor.ID},
+		},
+		Sequence: s.last.Sequence,
+	}
+	attrs := make(map[string]string, len(s.last.Actor.Attributes)+3)
+	for k, v := range s.last.Actor.Attributes {
+		attrs[k] = v
+	}
+	actions := make([]string, 0, len(s.actions))
+	for a, n := range s.actions {
+		actions = append(actions, a+"="+strconv.Itoa(n))
+	}
+	sort.Strings(actions)
+	attrs[compactedCount] = strconv.Itoa(s.count)
+	attrs[compactedActions] = strings.Join(actions, ",")
+	attrs[compactedSince] = strconv.FormatInt(s.since, 10)
+	m.Actor.Attributes = attrs
+	return m
+}
+
+// actionName returns the action without its details, such as the command
+// of an exec.
+func actionName(action string) string {
+	if i := strings.Index(action, ":"); i > 0 {
+		return action[:i]
+	}
+	return action
+}
+
+// compactable returns true if the event ev of the journal can be part of
+// the summary of its container. The events that create and remove the
+// containers are kept, so that the journal still tells when they existed.
+func compactable(ev eventtypes.Message) bool {
+	if ev.Type != eventtypes.ContainerEventType || ev.Actor.ID == "" {
+		return false
+	}
+	_, lifecycle := lifecycleActions[ev.Type][ev.Action]
+	return !lifecycle
+}
+
+// JournalCompaction reports what a compaction of the journal did.
+type JournalCompaction struct {
+	// Compacted is the number of entries of the journal replaced by
+	// Summaries summaries.
+	Compacted, Summaries int
+	// Reclaimed is the number of bytes the journal shrank by.
+	Reclaimed int64
+}
+
+// Compact replaces the events of each container logged before the
+// timestamp before, except the ones that create and remove it, by a
+// single event summarizing them, whose action is compacted. The summary
+// takes the place of the last event it replaces, it has the attributes
+// of that event and counts the events summarized by action. The events
+// of the other objects, and the ones logged since before, are kept.
+//
+// The journal is rewritten in a new file that replaces it, the events
+// written meanwhile are appended to it. The cursors of the history
+// returned before a compaction are no longer valid.
+func (j *Journal) Compact(before int64) (JournalCompaction, error) {
+	j.compactMu.Lock()
+	defer j.compactMu.Unlock()
+
+	var result JournalCompaction
+	size := j.Size()
+
+	// The first pass finds the containers with events to summarize.
+	summaries := make(map[string]*containerSummary)
+	err := j.Walk(size, func(ev eventtypes.Message) bool {
+		if ev.TimeNano >= before {
+			return false
+		}
+		if compactable(ev) {
+			s, ok := summaries[ev.Actor.ID]
+			if !ok {
+				s = &containerSummary{}
+				summaries[ev.Actor.ID] = s
+			}
+			s.add(ev)
+		}
+		return true
+	})
+	if err != nil {
+		return result, err
+	}
+	for id, s := range summaries {
+		if s.entries < 2 {
+			delete(summaries, id)
+			continue
+		}
+		result.Compacted += s.entries
+		result.Summaries++
+	}
+	if result.Summaries == 0 {
+		return JournalCompaction{}, nil
+	}
+
+	// The second pass writes the events kept and the summaries.
+	tmp, err := os.OpenFile(j.path+".compact", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return JournalCompaction{}, err
+	}
+	defer func() {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	f, err := os.Open(j.path)
+	if err != nil {
+		return JournalCompaction{}, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(tmp)
+	if err := compactEntries(w, bufio.NewReader(io.LimitReader(f, size)), before, summaries); err != nil {
+		return JournalCompaction{}, err
+	}
+	if err := w.Flush(); err != nil {
+		return JournalCompaction{}, err
+	}
+
+	compacted := tmp
+	tmp = nil
+	result.Reclaimed, err = j.replace(compacted, f, size)
+	if err != nil {
+		return JournalCompaction{}, err
+	}
+	return result, nil
+}
+
+// replace makes tmp, a rewrite of the first size bytes of the journal,
+// replace the journal. The entries written to the journal since, read
+// from f, are appended to tmp first. It returns the number of bytes the
+// journal shrank by. tmp is closed, and removed if it did not replace the
+// journal.
+func (j *Journal) replace(tmp, f *os.File, size int64) (int64, error) {
+	replaced := false
+	defer func() {
+		tmp.Close()
+		if !replaced {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// The journal is replaced without readers.
+	j.readers.Lock()
+	defer j.readers.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return 0, errJournalClosed
+	}
+	if _, err := f.Seek(size, 0); err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(tmp, f, j.size-size); err != nil {
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	newSize, err := tmp.Seek(0, 2)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return 0, err
+	}
+	replaced = true
+
+	nf, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, err
+	}
+	j.f.Close()
+	j.f = nf
+	reclaimed := j.size - newSize
+	j.size = newSize
+	// The offsets changed, the index is built again by the next search.
+	j.idx = nil
+	return reclaimed, nil
+}
+
+// compactEntries copies the entries of the journal read from r to w,
+// replacing the events of the containers summarized by their summaries.
+// As in the first pass, the events are summarized up to the first one
+// logged since before, the following entries are copied as they are. The
+// entries that cannot be read, which the first pass skipped, are kept.
+func compactEntries(w io.Writer, r *bufio.Reader, before int64, summaries map[string]*containerSummary) error {
+	copying := false
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) == 0 {
+			return nil
+		}
+		if !copying {
+			var ev eventtypes.Message
+			switch err := json.Unmarshal(line, &ev); {
+			case err != nil:
+			case ev.TimeNano >= before:
+				copying = true
+			default:
+				s, ok := summaries[ev.Actor.ID]
+				if !ok || !compactable(ev) {
+					break
+				}
+				if ev.Sequence != s.last.Sequence {
+					continue
+				}
+				b, err := json.Marshal(s.message())
+				if err != nil {
+					return err
+				}
+				line = append(b, '\n')
+			}
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+}
+
+// SetJournalCompaction makes the events service compact the events of the
+// journal older than age in the background, see Journal.Compact. The
+// compaction is disabled when age is not positive.
+func (e *Events) SetJournalCompaction(age time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.compactor != nil {
+		close(e.compactor)
+		e.compactor = nil
+	}
+	if age <= 0 || e.journal == nil {
+		return
+	}
+	stop := make(chan struct{})
+	e.compactor = stop
+	go compactJournal(e.journal, age, compactInterval(age), stop)
+}
+
+// compactInterval returns how often the journal is compacted, a tenth of
+// age, between a minute and a day.
+func compactInterval(age time.Duration) time.Duration {
+	interval := age / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	if interval > 24*time.Hour {
+		interval = 24 * time.Hour
+	}
+	return interval
+}
+
+// compactJournal compacts the events of the journal older than age every
+// interval, until stop is closed.
+func compactJournal(j *Journal, age, interval time.Duration, stop chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			c, err := j.Compact(now.Add(-age).UnixNano())
+			if err != nil {
+				logrus.Errorf("Error compacting events journal: %v", err)
+				continue
+			}
+			if c.Summaries > 0 {
+				logrus.Infof("Compacted events journal: %d events into %d summaries, %s reclaimed", c.Compacted, c.Summaries, units.HumanSize(float64(c.Reclaimed)))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
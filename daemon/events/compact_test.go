@@ -0,0 +1,114 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestJournalCompact(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for i, ev := range []events.Message{
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "create", Actor: eventtypes.Actor{ID: "c1"}}},
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "c1"}}},
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "exec_start: ls /", Actor: eventtypes.Actor{ID: "c1"}}},
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "die", Actor: eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web", "exitCode": "0"}}}},
+		{Message: eventtypes.Message{Type: events.NetworkEventType, Action: "connect", Actor: eventtypes.Actor{ID: "net"}}},
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "c2"}}},
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "c1"}, TimeNano: 100}},
+	} {
+		ev.Sequence = uint64(i + 1)
+		if ev.TimeNano == 0 {
+			ev.TimeNano = int64(i + 1)
+		}
+		if err := j.Write(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The search builds the index, which the compaction resets.
+	if _, err := j.index(); err != nil {
+		t.Fatal(err)
+	}
+	size := j.Size()
+
+	c, err := j.Compact(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Compacted != 3 || c.Summaries != 1 || c.Reclaimed <= 0 || c.Reclaimed >= size {
+		t.Fatalf("Unexpected compaction %+v", c)
+	}
+	evs := walkJournal(t, j)
+	if len(evs) != 5 {
+		t.Fatalf("Expected 5 events, got %+v", evs)
+	}
+	for i, seq := range []uint64{1, 4, 5, 6, 7} {
+		if evs[i].Sequence != seq {
+			t.Fatalf("Expected the event %d at %d, got %+v", seq, i, evs)
+		}
+	}
+	idx, err := j.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offsets := idx.lookup(0, 0, 0, events.ContainerEventType, "c1"); len(offsets) != 3 {
+		t.Fatalf("Expected the index built again, got %v", offsets)
+	}
+	s := evs[1]
+	if s.Action != compactedAction || s.Actor.ID != "c1" || s.TimeNano != 4 {
+		t.Fatalf("Unexpected summary %+v", s)
+	}
+	attrs := s.Actor.Attributes
+	if attrs["name"] != "web" || attrs[compactedCount] != "3" || attrs[compactedActions] != "die=1,exec_start=1,start=1" || attrs[compactedSince] != "2" {
+		t.Fatalf("Unexpected attributes of the summary %v", attrs)
+	}
+
+	// The events written after the compaction are appended to the
+	// compacted journal, and a later compaction merges the summaries.
+	if err := j.Write(events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "stop", Actor: eventtypes.Actor{ID: "c1"}, TimeNano: 200}, Sequence: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = j.Compact(150); err != nil || c.Compacted != 2 || c.Summaries != 1 {
+		t.Fatalf("Unexpected compaction %+v, %v", c, err)
+	}
+	evs = walkJournal(t, j)
+	if len(evs) != 5 || evs[3].Sequence != 7 || evs[4].Action != "stop" {
+		t.Fatalf("Unexpected events %+v", evs)
+	}
+	attrs = evs[3].Actor.Attributes
+	if evs[3].Action != compactedAction || attrs[compactedCount] != "4" || attrs[compactedActions] != "die=1,exec_start=1,start=2" || attrs[compactedSince] != "2" {
+		t.Fatalf("Unexpected summary %+v", evs[3])
+	}
+	if last, err := j.LastSequence(); err != nil || last != 8 {
+		t.Fatalf("Expected the last sequence 8, got %d, %v", last, err)
+	}
+
+	// Nothing is left to compact.
+	if c, err = j.Compact(150); err != nil || c.Summaries != 0 {
+		t.Fatalf("Expected nothing compacted, got %+v, %v", c, err)
+	}
+}
+
+func walkJournal(t *testing.T, j *Journal) []events.Message {
+	var evs []events.Message
+	if err := j.Walk(j.Size(), func(ev events.Message) bool {
+		evs = append(evs, ev)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return evs
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// DeadLetterAction is the action of the daemon events generated when an
+// event could not be delivered.
+const DeadLetterAction = "dead_letter"
+
+// DeadLetter is an event that could not be delivered, as stored in the
+// dead letters file.
+type DeadLetter struct {
+	// Time is when the delivery failed.
+	Time time.Time `json:"time"`
+	// Destination is the exporter or the webhook the event was sent to.
+	Destination string `json:"destination"`
+	// Error is why the last attempt to deliver the event failed.
+	Error string `json:"error"`
+	// Event is the event not delivered.
+	Event eventtypes.Message `json:"event"`
+}
+
+// DeadLetters stores the events the exporters and the webhooks fail to
+// deliver after their retries, one JSON encoded DeadLetter per line, so
+// that they can be delivered again by other means.
+type DeadLetters struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// OpenDeadLetters opens the dead letters file at path, creating it if
+// needed. The dead letters are appended to the file.
+func OpenDeadLetters(path string) (*DeadLetters, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetters{path: path, f: f}, nil
+}
+
+// Add stores a dead letter.
+func (d *DeadLetters) Add(dl DeadLetter) error {
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = d.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the dead letters file.
+func (d *DeadLetters) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.f.Close()
+}
+
+// SetDeadLetters makes the exporters and the webhooks store in d the
+// events they fail to deliver, and the daemon generate a dead_letter
+// event for each of them.
+func (e *Events) SetDeadLetters(d *DeadLetters) {
+	e.mu.Lock()
+	e.deadLetters = d
+	e.mu.Unlock()
+}
+
+// deadLetter records that ev could not be delivered to destination. The
+// dead_letter events that cannot be delivered are stored too, but do not
+// generate another event, not to loop when a destination is down.
+func (e *Events) deadLetter(destination string, ev eventtypes.Message, err error) {
+	e.mu.Lock()
+	d := e.deadLetters
+	e.mu.Unlock()
+	if d == nil {
+		return
+	}
+	dl := DeadLetter{
+		Time:        time.Now().UTC(),
+		Destination: destination,
+		Error:       err.Error(),
+		Event:       ev,
+	}
+	if err := d.Add(dl); err != nil {
+		logrus.Errorf("Error storing event not delivered to %s in %s: %v", destination, d.path, err)
+	}
+	if ev.Type == eventtypes.DaemonEventType && ev.Action == DeadLetterAction {
+		return
+	}
+
+	var id, name string
+	e.pipelineMu.RLock()
+	if e.node != nil {
+		id, name = e.node.ID, e.node.Name
+	}
+	e.pipelineMu.RUnlock()
+	e.Publish(DaemonTopic, DeadLetterAction, id, map[string]string{
+		"name":           name,
+		"destination":    destination,
+		"error":          dl.Error,
+		"event.type":     ev.Type,
+		"event.action":   ev.Action,
+		"event.sequence": strconv.FormatUint(ev.Sequence, 10),
+	})
+}
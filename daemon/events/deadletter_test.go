@@ -0,0 +1,95 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+type failingExporter struct{}
+
+func (failingExporter) Export(m events.Message) error {
+	return errors.New("endpoint unavailable")
+}
+
+func (failingExporter) Close() error {
+	return nil
+}
+
+func TestDeadLetters(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-dead-letters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "events", "dead-letters.jsonl")
+	d, err := OpenDeadLetters(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	e := New(0)
+	e.SetNode(Node{ID: "node1", Name: "host1"})
+	e.SetDeadLetters(d)
+	_, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Filter: NewFilterFromMap(map[string][]string{"type": {events.DaemonEventType}}),
+	})
+	defer e.Evict(l)
+	f := NewForwarder("fluentd", failingExporter{}, nil, 0)
+	f.Start(e)
+
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	select {
+	case m := <-l:
+		if m.Action != DeadLetterAction || m.Actor.ID != "node1" || m.Actor.Attributes["destination"] != "fluentd" ||
+			m.Actor.Attributes["error"] != "endpoint unavailable" || m.Actor.Attributes["event.sequence"] != "1" {
+			t.Fatalf("Unexpected event %v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the dead_letter event")
+	}
+	f.Stop()
+
+	// The dead_letter event is not exported either, it is stored without
+	// generating another event.
+	select {
+	case m := <-l:
+		t.Fatalf("Unexpected event %v", m)
+	default:
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var letters []DeadLetter
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		var dl DeadLetter
+		if err := json.Unmarshal(s.Bytes(), &dl); err != nil {
+			t.Fatal(err)
+		}
+		letters = append(letters, dl)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %v", letters)
+	}
+	if letters[0].Destination != "fluentd" || letters[0].Error != "endpoint unavailable" || letters[0].Event.Actor.ID != "cont" {
+		t.Fatalf("Unexpected dead letter %v", letters[0])
+	}
+	if letters[1].Event.Action != DeadLetterAction {
+		t.Fatalf("Unexpected dead letter %v", letters[1])
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+// redundantActions lists, for each action of a container, the actions
+// that report the same change of the container. A container that is
+// stopped dies, then stops.
+var redundantActions = map[string][]string{
+	"stop": {"die"},
+}
+
+// deduplicator drops the events that repeat the previous event of the same
+// actor, if it was logged during the last window: its duplicates, with the
+// same action and attributes, and the events whose action is redundant
+// with it.
+type deduplicator struct {
+	window    time.Duration
+	last      map[string]lastEvent
+	lastSweep time.Time
+}
+
+// lastEvent is the previous event of an actor.
+type lastEvent struct {
+	time       time.Time
+	action     string
+	attributes string
+}
+
+// SetDedupWindow makes the events service drop the events that repeat the
+// previous event of the same object, if it was logged less than window
+// ago, either because they have the same action and attributes, or because
+// their action is redundant with the previous one, as the stop event of a
+// container after its die event. Deduplication is disabled when window is not positive.
+func (e *Events) SetDedupWindow(window time.Duration) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	if window <= 0 {
+		e.dedup = nil
+		return
+	}
+	e.dedup = &deduplicator{
+		window: window,
+		last:   make(map[string]lastEvent),
+	}
+}
+
+// duplicate returns true if the event repeats the previous event of its
+// actor, and records it otherwise. It must be called with the logMu lock
+// of the events service held.
+func (d *deduplicator) duplicate(now time.Time, action, eventType string, actor eventtypes.Actor) bool {
+	if now.Sub(d.lastSweep) >= d.window {
+		d.sweep(now)
+	}
+
+	key := eventType + "/" + actor.ID
+	attributes := attributesKey(actor.Attributes)
+	if prev, ok := d.last[key]; ok && now.Sub(prev.time) < d.window {
+		if prev.action == action && prev.attributes == attributes {
+			return true
+		}
+		if eventType == eventtypes.ContainerEventType {
+			for _, a := range redundantActions[action] {
+				if prev.action == a {
+					return true
+				}
+			}
+		}
+	}
+	d.last[key] = lastEvent{time: now, action: action, attributes: attributes}
+	return false
+}
+
+// sweep forgets the events logged before the window.
+func (d *deduplicator) sweep(now time.Time) {
+	for key, prev := range d.last {
+		if now.Sub(prev.time) >= d.window {
+			delete(d.last, key)
+		}
+	}
+	d.lastSweep = now
+}
+
+// attributesKey returns a string that is the same for equal attributes.
+func attributesKey(attributes map[string]string) string {
+	kv := make([]string, 0, len(attributes))
+	for k, v := range attributes {
+		kv = append(kv, k+"="+v)
+	}
+	sort.Strings(kv)
+	return strings.Join(kv, "\x00")
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestDedup(t *testing.T) {
+	e := New(0)
+	e.SetDedupWindow(time.Minute)
+
+	cont := events.Actor{ID: "cont", Attributes: map[string]string{"name": "web"}}
+	net := events.Actor{ID: "net"}
+	e.Log("kill", events.ContainerEventType, cont)
+	e.Log("kill", events.ContainerEventType, cont)
+	e.Log("die", events.ContainerEventType, cont)
+	e.Log("stop", events.ContainerEventType, cont)
+	e.Log("start", events.ContainerEventType, cont)
+	e.Log("die", events.ContainerEventType, cont)
+	e.Log("connect", events.NetworkEventType, events.Actor{ID: "net", Attributes: map[string]string{"container": "a"}})
+	e.Log("connect", events.NetworkEventType, events.Actor{ID: "net", Attributes: map[string]string{"container": "b"}})
+	e.Log("destroy", events.NetworkEventType, net)
+
+	expected := []string{"kill", "die", "start", "die", "connect", "connect", "destroy"}
+	if len(e.recent.events()) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), e.recent.events())
+	}
+	for i, action := range expected {
+		if e.recent.events()[i].Action != action {
+			t.Fatalf("Expected event %d to be %s, got %v", i, action, e.recent.events()[i])
+		}
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	e := New(0)
+	e.SetDedupWindow(10 * time.Millisecond)
+
+	actor := events.Actor{ID: "img"}
+	e.Log("pull", events.ImageEventType, actor)
+	time.Sleep(20 * time.Millisecond)
+	e.Log("pull", events.ImageEventType, actor)
+	if len(e.recent.events()) != 2 {
+		t.Fatalf("Expected 2 events, got %v", e.recent.events())
+	}
+
+	e.SetDedupWindow(0)
+	e.Log("pull", events.ImageEventType, actor)
+	if len(e.recent.events()) != 3 {
+		t.Fatalf("Expected 3 events, got %v", e.recent.events())
+	}
+}
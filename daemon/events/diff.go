@@ -0,0 +1,130 @@
+package events
+
+import (
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	derr "github.com/docker/docker/errors"
+)
+
+// DiffOptions holds the parameters of a request for the changes between
+// two time points.
+type DiffOptions struct {
+	// Since and SinceNano are the timestamp of the first time point. Since
+	// is -1 to start at the oldest event in the journal.
+	Since, SinceNano int64
+	// Until and UntilNano are the timestamp of the second time point.
+	// Until is -1 to end at the newest event.
+	Until, UntilNano int64
+	// Owner is the tenant requesting the changes when the event stream is
+	// scoped per tenant, only the changes of the objects it owns are
+	// returned.
+	Owner string
+	// ACL restricts the events the changes are derived from, if any.
+	ACL *ACL
+}
+
+// lifecycleActions are, by type of object, the actions of the events that
+// create an object, true, and remove it, false.
+var lifecycleActions = map[string]map[string]bool{
+	eventtypes.ContainerEventType: {"create": true, "destroy": false},
+	eventtypes.ImageEventType:     {"pull": true, "import": true, "load": true, "tag": true, "delete": false},
+	eventtypes.VolumeEventType:    {"create": true, "destroy": false},
+	eventtypes.NetworkEventType:   {"create": true, "destroy": false},
+}
+
+// objectChange is how an object changed between the two time points.
+type objectChange struct {
+	name string
+	// created is true if the first event of the object created it, and
+	// removed if the last one removed it.
+	created, removed bool
+}
+
+// changeSet are the changes of the objects of a type, in the order of
+// their first event.
+type changeSet struct {
+	ids     []string
+	changes map[string]*objectChange
+}
+
+func (cs *changeSet) add(ev eventtypes.Message, create bool) {
+	id := ev.Actor.ID
+	c, ok := cs.changes[id]
+	if !ok {
+		c = &objectChange{created: create}
+		cs.changes[id] = c
+		cs.ids = append(cs.ids, id)
+	}
+	c.removed = !create
+	if name := ev.Actor.Attributes["name"]; name != "" {
+		c.name = name
+	}
+}
+
+// net returns the objects created and not removed, and the ones removed
+// that were not created, between the two time points.
+func (cs *changeSet) net() eventtypes.Changes {
+	changes := eventtypes.Changes{
+		Created: []eventtypes.ChangedObject{},
+		Removed: []eventtypes.ChangedObject{},
+	}
+	for _, id := range cs.ids {
+		c := cs.changes[id]
+		o := eventtypes.ChangedObject{ID: id, Name: c.name}
+		switch {
+		case c.created && !c.removed:
+			changes.Created = append(changes.Created, o)
+		case !c.created && c.removed:
+			changes.Removed = append(changes.Removed, o)
+		}
+	}
+	return changes
+}
+
+// Diff returns the containers, images, volumes and networks created and
+// removed between two time points, derived from the events stored in the
+// journal.
+func (e *Events) Diff(opts DiffOptions) (eventtypes.Diff, error) {
+	e.mu.Lock()
+	journal := e.journal
+	e.mu.Unlock()
+	if journal == nil {
+		return eventtypes.Diff{}, derr.ErrorCodeNoEventsJournal
+	}
+
+	sets := make(map[string]*changeSet, len(lifecycleActions))
+	for t := range lifecycleActions {
+		sets[t] = &changeSet{changes: make(map[string]*objectChange)}
+	}
+	sinceTime := time.Unix(opts.Since, opts.SinceNano).UnixNano()
+	err := journal.Walk(journal.Size(), func(ev eventtypes.Message) bool {
+		if opts.Until != -1 && after(ev, opts.Until, opts.UntilNano) {
+			return false
+		}
+		if opts.Since != -1 && ev.TimeNano < sinceTime {
+			return true
+		}
+		create, ok := lifecycleActions[ev.Type][ev.Action]
+		if !ok {
+			return true
+		}
+		if opts.Owner != "" && !ownedBy(ev, opts.Owner) {
+			return true
+		}
+		if opts.ACL != nil && !opts.ACL.Include(ev) {
+			return true
+		}
+		sets[ev.Type].add(ev, create)
+		return true
+	})
+	if err != nil {
+		return eventtypes.Diff{}, err
+	}
+	return eventtypes.Diff{
+		Containers: sets[eventtypes.ContainerEventType].net(),
+		Images:     sets[eventtypes.ImageEventType].net(),
+		Volumes:    sets[eventtypes.VolumeEventType].net(),
+		Networks:   sets[eventtypes.NetworkEventType].net(),
+	}, nil
+}
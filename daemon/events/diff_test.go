@@ -0,0 +1,102 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	derr "github.com/docker/docker/errors"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestDiff(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetJournal(j)
+	defer e.Close()
+
+	named := func(id, name string) eventtypes.Actor {
+		return eventtypes.Actor{ID: id, Attributes: map[string]string{"name": name}}
+	}
+	// Before the first time point.
+	e.Log("create", events.ContainerEventType, named("old", "db"))
+	e.Log("create", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	time.Sleep(time.Millisecond)
+	since := time.Now()
+	time.Sleep(time.Millisecond)
+
+	e.Log("create", events.ContainerEventType, named("web", "frontend"))
+	e.Log("start", events.ContainerEventType, named("web", "frontend"))
+	e.Log("create", events.ContainerEventType, named("tmp", "scratch"))
+	e.Log("destroy", events.ContainerEventType, named("tmp", "scratch"))
+	e.Log("destroy", events.ContainerEventType, named("old", "db"))
+	e.Log("pull", events.ImageEventType, named("nginx:latest", "nginx"))
+	e.Log("destroy", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	e.Log("create", events.NetworkEventType, named("3f2a", "backend"))
+	time.Sleep(time.Millisecond)
+	until := time.Now()
+	time.Sleep(time.Millisecond)
+
+	// After the second time point.
+	e.Log("destroy", events.ContainerEventType, named("web", "frontend"))
+
+	diff, err := e.Diff(DiffOptions{
+		Since:     since.Unix(),
+		SinceNano: int64(since.Nanosecond()),
+		Until:     until.Unix(),
+		UntilNano: int64(until.Nanosecond()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	none := []events.ChangedObject{}
+	expected := events.Diff{
+		Containers: events.Changes{
+			Created: []events.ChangedObject{{ID: "web", Name: "frontend"}},
+			Removed: []events.ChangedObject{{ID: "old", Name: "db"}},
+		},
+		Images: events.Changes{
+			Created: []events.ChangedObject{{ID: "nginx:latest", Name: "nginx"}},
+			Removed: none,
+		},
+		Volumes: events.Changes{
+			Created: none,
+			Removed: []events.ChangedObject{{ID: "data"}},
+		},
+		Networks: events.Changes{
+			Created: []events.ChangedObject{{ID: "3f2a", Name: "backend"}},
+			Removed: none,
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, diff)
+	}
+
+	// Without time points, all the events of the journal are compared.
+	diff, err = e.Diff(DiffOptions{Since: -1, Until: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Containers.Created) != 0 || len(diff.Containers.Removed) != 0 {
+		t.Fatalf("Expected no net container changes, got %+v", diff.Containers)
+	}
+}
+
+func TestDiffWithoutJournal(t *testing.T) {
+	e := New(0)
+	if _, err := e.Diff(DiffOptions{Since: -1, Until: -1}); err != derr.ErrorCodeNoEventsJournal {
+		t.Fatalf("Expected ErrorCodeNoEventsJournal, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// dispatchQueueSize is the number of events logged that can wait to be
+// published to the subscribers before logging an event blocks.
+const dispatchQueueSize = 1024
+
+// verboseQueueLimit is the number of events waiting to be published
+// beyond which the verbose events are dropped instead of queued.
+const verboseQueueLimit = dispatchQueueSize * 3 / 4
+
+// dispatch is an event waiting to be published, or, when done is set, a
+// marker closed once the events queued before it are published.
+type dispatch struct {
+	ev   eventtypes.Message
+	done chan struct{}
+}
+
+// dispatcher publishes the events logged from its own goroutine, so that
+// logging an event does not wait for the subscribers to receive it. The
+// events are published in the order they are queued.
+type dispatcher struct {
+	// dropped is the number of verbose events not published because
+	// too many events were waiting, it is accessed atomically.
+	dropped uint64
+	pub     *publisher
+	queue   chan dispatch
+	wg      sync.WaitGroup
+	// shedMu protects shedding, the verbose events dropped that are not
+	// yet counted as dropped for the subscribers that match them.
+	shedMu   sync.Mutex
+	shedding []eventtypes.Message
+}
+
+func newDispatcher(pub *publisher) *dispatcher {
+	d := &dispatcher{
+		pub:   pub,
+		queue: make(chan dispatch, dispatchQueueSize),
+	}
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *dispatcher) run() {
+	defer d.wg.Done()
+	for item := range d.queue {
+		d.countShed()
+		if item.done != nil {
+			close(item.done)
+			continue
+		}
+		d.pub.publish(item.ev)
+	}
+	d.countShed()
+}
+
+// countShed counts the verbose events shed so far as dropped for the
+// subscribers they match, from the goroutine of the dispatcher, so that
+// logging an event does not wait for the subscribers.
+func (d *dispatcher) countShed() {
+	d.shedMu.Lock()
+	shed := d.shedding
+	d.shedding = nil
+	d.shedMu.Unlock()
+	for _, ev := range shed {
+		d.pub.shed(ev)
+	}
+}
+
+// shed returns true if ev is dropped instead of logged: the verbose events
+// are dropped once the queue is mostly full, to leave room for the others.
+// They are dropped before they are assigned a sequence number, so that the
+// subscribers see no gap in the sequence numbers, and are counted as dropped
+// for the subscribers they match, up to dispatchQueueSize of them between
+// two events published.
+func (d *dispatcher) shed(ev eventtypes.Message) bool {
+	if len(d.queue) < verboseQueueLimit || priorityOf(ev) != PriorityVerbose {
+		return false
+	}
+	atomic.AddUint64(&d.dropped, 1)
+	d.shedMu.Lock()
+	if len(d.shedding) < dispatchQueueSize {
+		d.shedding = append(d.shedding, ev)
+	}
+	d.shedMu.Unlock()
+	return true
+}
+
+// publish queues ev to be published, it blocks when the queue is full.
+func (d *dispatcher) publish(ev eventtypes.Message) {
+	d.queue <- dispatch{ev: ev}
+}
+
+// flush returns once the events queued so far are published.
+func (d *dispatcher) flush() {
+	done := make(chan struct{})
+	d.queue <- dispatch{done: done}
+	<-done
+}
+
+// queued returns the number of events waiting to be published.
+func (d *dispatcher) queued() int {
+	return len(d.queue)
+}
+
+// stop publishes the events queued and stops the dispatcher, no event
+// must be queued afterwards.
+func (d *dispatcher) stop() {
+	close(d.queue)
+	d.wg.Wait()
+}
+
+// publish publishes ev through the dispatcher, or directly once the
+// events service is closed. The caller must hold logMu.
+func (e *Events) publish(ev eventtypes.Message) {
+	if e.dispatcher == nil {
+		e.pub.publish(ev)
+		return
+	}
+	e.dispatcher.publish(ev)
+}
+
+// Flush returns once the events logged so far are published to the
+// subscribers.
+func (e *Events) Flush() {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	if e.dispatcher != nil {
+		e.dispatcher.flush()
+	}
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestDispatch(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, slow := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Timeout: time.Second})
+
+	// The subscriber not reading the events does not hold the caller.
+	start := time.Now()
+	for i := 0; i < bufferSize+5; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Expected the events logged without waiting for the subscriber, took %s", d)
+	}
+	if m := e.Metrics(); m.Dispatching == 0 {
+		t.Fatalf("Expected events waiting to be published, got %+v", m)
+	}
+
+	// The events are published in order.
+	var last uint64
+	for i := 0; i < bufferSize+5; i++ {
+		select {
+		case ev := <-slow:
+			if ev.Sequence != last+1 {
+				t.Fatalf("Expected the event %d, got %d", last+1, ev.Sequence)
+			}
+			last = ev.Sequence
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for the event %d", last+1)
+		}
+	}
+	e.Flush()
+	if m := e.Metrics(); m.Dispatching != 0 || m.Published != bufferSize+5 || m.Dropped != 0 {
+		t.Fatalf("Expected all the events published, got %+v", m)
+	}
+
+	// Once closed, the events are published directly.
+	e.Close()
+	e.Log("stop", events.ContainerEventType, events.Actor{ID: "cont"})
+	if ev := <-slow; ev.Action != "stop" {
+		t.Fatalf("Expected the stop event, got %+v", ev)
+	}
+}
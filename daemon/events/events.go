@@ -4,123 +4,483 @@ import (
 	"sync"
 	"time"
 
-	"github.com/docker/docker/pkg/pubsub"
-	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
 )
 
 const (
-	eventsLimit = 64
-	bufferSize  = 1024
+	eventsLimit    = 64
+	bufferSize     = 1024
+	publishTimeout = 100 * time.Millisecond
+	// minPublishTimeout is the shortest time a subscriber with an
+	// adaptive timeout is waited for.
+	minPublishTimeout = time.Millisecond
 )
 
+// SubscribeOptions holds the parameters of a subscription to the events.
+type SubscribeOptions struct {
+	// Since and SinceNano are the timestamp of the oldest past event
+	// returned to the subscriber. Since is -1 to not return past events.
+	Since, SinceNano int64
+	// Until and UntilNano are the timestamp after which events are not
+	// returned anymore. Until is -1 to not set an upper bound.
+	Until, UntilNano int64
+	// Filter selects the events returned to the subscriber, all events
+	// are returned when it is nil.
+	Filter *Filter
+	// Scope restricts the events returned to the subscriber to the ones
+	// it includes, whatever Filter selects, if it is set.
+	Scope *Filter
+	// Policy defines what happens when the subscriber is not ready to
+	// receive an event.
+	Policy Policy
+	// Timeout is how long to wait for the subscriber before applying the
+	// policy. When it is not set, the timeout adapts to the subscriber:
+	// it starts at 100 milliseconds, and is shortened while the
+	// subscriber is slow to receive the events.
+	Timeout time.Duration
+	// ResumeAfter is the sequence number of the last event received by
+	// the subscriber. When it is set, the events that follow it are
+	// returned as past events, instead of the ones selected by Since.
+	ResumeAfter uint64
+	// Tail is the largest number of past events returned, the most
+	// recent ones. When neither Since nor ResumeAfter select past events,
+	// the last Tail events kept in memory are returned.
+	Tail int
+	// Sample keeps only one in Sample events of each type and action,
+	// except the events reporting a failure, which are all kept. All
+	// events are kept when it is not greater than 1.
+	Sample int
+	// Priority is the minimum priority of the events returned, all
+	// events are returned when it is PriorityVerbose.
+	Priority Priority
+	// Client identifies the API client subscribing, if any.
+	Client string
+	// Owner is the tenant subscribing when the event stream is scoped
+	// per tenant, only the events of the objects it owns are returned.
+	Owner string
+	// ACL restricts the events returned to the subscriber, if any.
+	ACL *ACL
+}
+
 // Events is pubsub channel for events generated by the engine.
 type Events struct {
-	mu     sync.Mutex
-	events []eventtypes.Message
-	pub    *pubsub.Publisher
+	// logMu serializes Log, so that events are published in the order
+	// of their sequence numbers.
+	logMu sync.Mutex
+	// pipelineMu protects the middlewares, the event classes and the
+	// state read by the enrich stage. The enrich stage runs with it read
+	// locked but without logMu, so that a slow annotator does not hold up
+	// the events logged by the other goroutines.
+	pipelineMu sync.RWMutex
+	mu         sync.Mutex
+	recent     *ring
+	pub        *publisher
+	// dispatcher publishes the events logged, it is protected by logMu
+	// and nil once the events service is closed.
+	dispatcher *dispatcher
+	journal    *Journal
+	sequence   uint64
+	limiter    *rateLimiter
+	dedup      *deduplicator
+	origins    originScopes
+	node       *Node
+	signer     Signer
+	owners     *owners
+	acls       *ACLs
+	redactor   *Redactor
+	classes    *EventClasses
+	stats      eventStats
+	// deadLetters stores the events the exporters and the webhooks fail
+	// to deliver, it is protected by mu.
+	deadLetters *DeadLetters
+	// middlewares are the pipeline of the events logged, sorted by
+	// stage.
+	middlewares []middleware
+	// annotators are called by the enrich stage for each event, in order.
+	annotators []*annotator
+	// pruner is closed to stop discarding the events older than the
+	// retention age, it is protected by mu.
+	pruner chan struct{}
+	// compactor is closed to stop compacting the journal, it is
+	// protected by mu.
+	compactor chan struct{}
+	// anonymizer hashes the events the clients ask to anonymize.
+	anonymizer *Anonymizer
+	// payloads shares the encodings of the events between the
+	// subscribers.
+	payloads *PayloadCache
+	// importMu serializes the imports of snapshots.
+	importMu sync.Mutex
 }
 
-// New returns new *Events instance
-func New() *Events {
-	return &Events{
-		events: make([]eventtypes.Message, 0, eventsLimit),
-		pub:    pubsub.NewPublisher(100*time.Millisecond, bufferSize),
+// New returns new *Events instance that keeps the last size events
+// in memory. If size is not positive, the default limit of 64 events is used.
+func New(size int) *Events {
+	if size <= 0 {
+		size = eventsLimit
+	}
+	e := &Events{
+		recent:     newRing(size),
+		pub:        newPublisher(publishTimeout, bufferSize),
+		anonymizer: NewAnonymizer(),
+		payloads:   NewPayloadCache(payloadCacheSize),
 	}
+	e.dispatcher = newDispatcher(e.pub)
+	e.middlewares = e.builtinMiddlewares()
+	return e
 }
 
-// Subscribe adds new listener to events, returns slice of 64 stored
-// last events, a channel in which you can expect new events (in form
-// of interface{}, so you need type assertion), and a function to call
-// to stop the stream of events.
-func (e *Events) Subscribe() ([]eventtypes.Message, chan interface{}, func()) {
+// SetJournal makes the events service write every event to j, and read
+// from it the events requested by subscribers that are older than the
+// ones kept in memory. The sequence numbers continue from the last event
+// of the journal.
+func (e *Events) SetJournal(j *Journal) {
+	last, err := j.LastSequence()
+	if err != nil {
+		logrus.Errorf("Error reading events journal: %v", err)
+	}
 	e.mu.Lock()
-	current := make([]eventtypes.Message, len(e.events))
-	copy(current, e.events)
-	l := e.pub.Subscribe()
+	e.journal = j
+	if last > e.sequence {
+		e.sequence = last
+	}
 	e.mu.Unlock()
+}
 
-	cancel := func() {
-		e.Evict(l)
+// Subscribe adds new listener to events, returns slice of the stored
+// last events and a channel in which you can expect new events. The
+// listener is evicted, and the channel closed, when ctx is done.
+func (e *Events) Subscribe(ctx context.Context) ([]eventtypes.Message, <-chan eventtypes.Message) {
+	var (
+		recent []eventtypes.Message
+		last   uint64
+	)
+	s := &subscriber{
+		topic: func(ev eventtypes.Message) bool {
+			return ev.Sequence > last
+		},
+		created: time.Now().UTC(),
 	}
-	return current, l, cancel
+	l := e.pub.subscribe(ctx, s, func() {
+		e.mu.Lock()
+		recent, last = e.recent.events(), e.sequence
+		e.mu.Unlock()
+	})
+	current := make([]eventtypes.Message, len(recent))
+	copy(current, recent)
+	return current, l
 }
 
-// SubscribeTopic adds new listener to events, returns slice of 64 stored
-// last events, a channel in which you can expect new events (in form
-// of interface{}, so you need type assertion).
-func (e *Events) SubscribeTopic(since, sinceNano int64, ef *Filter) ([]eventtypes.Message, chan interface{}) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+// SubscribeTopic adds new listener to events, returns slice of the stored
+// last events and a channel in which you can expect new events, until
+// ctx is done.
+// Events that happened after until are never returned, use -1 to
+// not set an upper bound.
+func (e *Events) SubscribeTopic(ctx context.Context, since, sinceNano, until, untilNano int64, ef *Filter) ([]eventtypes.Message, <-chan eventtypes.Message) {
+	return e.SubscribeWithOptions(ctx, SubscribeOptions{
+		Since:     since,
+		SinceNano: sinceNano,
+		Until:     until,
+		UntilNano: untilNano,
+		Filter:    ef,
+	})
+}
 
-	var buffered []eventtypes.Message
-	topic := func(m interface{}) bool {
-		return ef.Include(m.(eventtypes.Message))
+// SubscribeWithOptions adds new listener to events according to opts,
+// returns slice of the stored past events and a channel in which you
+// can expect new events. The listener is evicted, and the channel closed,
+// when ctx is done.
+func (e *Events) SubscribeWithOptions(ctx context.Context, opts SubscribeOptions) ([]eventtypes.Message, <-chan eventtypes.Message) {
+	since, sinceNano := opts.Since, opts.SinceNano
+	until, untilNano := opts.Until, opts.UntilNano
+	ef := opts.Filter
+	if ef == nil {
+		ef = NewFilter(filters.NewArgs())
+	}
+	timeout, adaptive := opts.Timeout, false
+	if timeout == 0 {
+		timeout, adaptive = publishTimeout, true
 	}
 
-	if since != -1 {
-		for i := len(e.events) - 1; i >= 0; i-- {
-			ev := e.events[i]
-			if ev.Time < since || ((ev.Time == since) && (ev.TimeNano < sinceNano)) {
-				break
+	sample := newSampler(opts.Sample)
+	match := func(ev eventtypes.Message) bool {
+		if until != -1 && after(ev, until, untilNano) {
+			return false
+		}
+		if opts.Priority > PriorityVerbose && priorityOf(ev) < opts.Priority {
+			return false
+		}
+		if opts.Owner != "" && !ownedBy(ev, opts.Owner) {
+			return false
+		}
+		if opts.ACL != nil && !opts.ACL.Include(ev) {
+			return false
+		}
+		if opts.Scope != nil && !opts.Scope.Include(ev) {
+			return false
+		}
+		return ef.filter.Len() == 0 || ef.Include(ev)
+	}
+	topic := func(ev eventtypes.Message) bool {
+		// Sampling comes last, to count only the events selected.
+		return match(ev) && (sample == nil || sample.keep(ev))
+	}
+
+	// past returns true for the events that were requested by the
+	// subscriber among the events logged before it subscribed.
+	var past func(eventtypes.Message) bool
+	switch {
+	case opts.ResumeAfter > 0:
+		past = func(ev eventtypes.Message) bool {
+			return ev.Sequence > opts.ResumeAfter
+		}
+	case since != -1:
+		sinceTime := time.Unix(since, sinceNano).UnixNano()
+		past = func(ev eventtypes.Message) bool {
+			return ev.TimeNano >= sinceTime
+		}
+	}
+	// Only the events kept in memory are returned as the tail of the
+	// stream, unless the subscriber asked for older ones.
+	memoryOnly := past == nil && opts.Tail > 0
+	if memoryOnly {
+		past = func(eventtypes.Message) bool {
+			return true
+		}
+	}
+
+	s := &subscriber{
+		wait:     int64(timeout),
+		policy:   opts.Policy,
+		timeout:  timeout,
+		adaptive: adaptive,
+		priority: opts.Priority,
+		client:   opts.Client,
+		owner:    opts.Owner,
+		filters:  filterValues(ef.filter),
+		created:  time.Now().UTC(),
+		match:    match,
+	}
+	var (
+		recent  []eventtypes.Message
+		last    uint64
+		journal *Journal
+		limit   int64
+	)
+	// Events logged before the subscription are returned in buffered,
+	// or not at all, they must not be delivered if they are published
+	// afterwards.
+	s.topic = func(ev eventtypes.Message) bool {
+		return ev.Sequence > last && topic(ev)
+	}
+	// Only the events logged so far are read from memory and from the
+	// journal, everything logged after the subscription is delivered
+	// through the channel.
+	ch := e.pub.subscribe(ctx, s, func() {
+		e.mu.Lock()
+		recent, last = e.recent.events(), e.sequence
+		if e.journal != nil {
+			journal, limit = e.journal, e.journal.Size()
+		}
+		e.mu.Unlock()
+	})
+
+	var buffered []eventtypes.Message
+	if past != nil {
+		i := len(recent)
+		for i > 0 && past(recent[i-1]) {
+			i--
+		}
+		// Events older than the ones in memory have to be read from the
+		// journal, they come first.
+		if journal != nil && i == 0 && !memoryOnly {
+			var oldest uint64
+			if len(recent) > 0 {
+				oldest = recent[0].Sequence
 			}
-			if ef.filter.Len() == 0 || topic(ev) {
-				buffered = append([]eventtypes.Message{ev}, buffered...)
+			var err error
+			buffered, err = loadJournal(journal, limit, oldest, past, topic)
+			if err != nil {
+				logrus.Errorf("Error reading events journal: %v", err)
 			}
 		}
+		for _, ev := range recent[i:] {
+			if topic(ev) {
+				buffered = append(buffered, ev)
+			}
+		}
+		if opts.Tail > 0 && len(buffered) > opts.Tail {
+			buffered = buffered[len(buffered)-opts.Tail:]
+		}
 	}
 
-	var ch chan interface{}
-	if ef.filter.Len() > 0 {
-		ch = e.pub.SubscribeTopic(topic)
-	} else {
-		// Subscribe to all events if there are no filters
-		ch = e.pub.Subscribe()
+	return buffered, ch
+}
+
+// loadJournal returns the events in the journal that were requested by
+// the subscriber and match topic, and whose sequence number is lower than
+// oldest, the sequence number of the oldest event kept in memory. Oldest
+// is 0 when there are no events in memory.
+func loadJournal(journal *Journal, limit int64, oldest uint64, past func(eventtypes.Message) bool, topic func(eventtypes.Message) bool) ([]eventtypes.Message, error) {
+	var older []eventtypes.Message
+	err := journal.Walk(limit, func(ev eventtypes.Message) bool {
+		if oldest != 0 && ev.Sequence >= oldest {
+			return false
+		}
+		if past(ev) && topic(ev) {
+			older = append(older, ev)
+		}
+		return true
+	})
+	return older, err
+}
+
+// after returns true if the event ev happened after the given timestamp.
+func after(ev eventtypes.Message, sec, nsec int64) bool {
+	return ev.TimeNano > time.Unix(sec, nsec).UnixNano()
+}
+
+// Evict evicts listener from pubsub. The events already queued for the
+// listener are still received before its channel is closed.
+func (e *Events) Evict(l <-chan eventtypes.Message) {
+	e.pub.evict(l)
+}
+
+// Log broadcasts event to listeners. The event is recorded before Log
+// returns, and published to the listeners from another goroutine, so
+// that the caller does not wait for them. The event carries the origin
+// of the API request it is attributed to, if any.
+func (e *Events) Log(action, eventType string, actor engineevents.Actor) {
+	e.LogWithOrigin(action, eventType, actor, e.origins.lookup(action, eventType, actor))
+}
+
+// LogWithOrigin broadcasts an event caused by the API request origin, which
+// is added to the attributes of the actor. It is the same as Log when
+// origin is nil.
+func (e *Events) LogWithOrigin(action, eventType string, actor engineevents.Actor, origin *Origin) {
+	e.pipelineMu.RLock()
+	defer e.pipelineMu.RUnlock()
+
+	if e.classes != nil && !e.classes.emits(eventType, action) {
+		return
+	}
+	ev := &Event{
+		Time:   time.Now().UTC(),
+		Action: action,
+		Type:   eventType,
+		Actor:  actor,
+		Origin: origin,
+	}
+	if !e.enrich(ev) {
+		return
 	}
 
-	return buffered, ch
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	if !e.process(ev) {
+		return
+	}
+	e.log(ev.Time, ev.Action, ev.Type, ev.Actor)
 }
 
-// Evict evicts listener from pubsub
-func (e *Events) Evict(l chan interface{}) {
-	e.pub.Evict(l)
+// logCoalesced logs the event replacing the events coalesced by r, unless
+// the rate limit was changed since.
+func (e *Events) logCoalesced(r *rateLimiter, eventType, action string, actor engineevents.Actor) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+
+	if e.limiter != r {
+		return
+	}
+	e.log(time.Now().UTC(), action, eventType, actor)
 }
 
-// Log broadcasts event to listeners. Each listener has 100 millisecond for
-// receiving event or it will be skipped.
-func (e *Events) Log(action, eventType string, actor eventtypes.Actor) {
-	now := time.Now().UTC()
+// log records and publishes an event, the caller must hold logMu.
+func (e *Events) log(now time.Time, action, eventType string, actor engineevents.Actor) {
 	jm := eventtypes.Message{
-		Action:   action,
-		Type:     eventType,
-		Actor:    actor,
-		Time:     now.Unix(),
-		TimeNano: now.UnixNano(),
+		Message: engineevents.Message{
+			Action:   action,
+			Type:     eventType,
+			Actor:    actor,
+			Time:     now.Unix(),
+			TimeNano: now.UnixNano(),
+		},
 	}
-
-	// fill deprecated fields for container and images
+	// fill deprecated fields, so that clients that only know about them
+	// see every kind of event
 	switch eventType {
 	case eventtypes.ContainerEventType:
 		jm.ID = actor.ID
 		jm.Status = action
 		jm.From = actor.Attributes["image"]
-	case eventtypes.ImageEventType:
+	case eventtypes.ImageEventType, eventtypes.VolumeEventType, eventtypes.NetworkEventType:
 		jm.ID = actor.ID
 		jm.Status = action
 	}
+	if e.dispatcher != nil && e.dispatcher.shed(jm) {
+		e.stats.addShed(now)
+		return
+	}
 
 	e.mu.Lock()
-	if len(e.events) == cap(e.events) {
-		// discard oldest event
-		copy(e.events, e.events[1:])
-		e.events[len(e.events)-1] = jm
-	} else {
-		e.events = append(e.events, jm)
+	e.sequence++
+	jm.Sequence = e.sequence
+	if e.signer != nil {
+		e.sign(&jm)
+	}
+	if e.journal != nil {
+		if err := e.journal.Write(jm); err != nil {
+			logrus.Errorf("Error writing event to the journal: %v", err)
+		}
 	}
+	e.recent.add(jm)
 	e.mu.Unlock()
-	e.pub.Publish(jm)
+	e.stats.add(now, eventType, action)
+	e.publish(jm)
+}
+
+// Close stops the background tasks of the events service and closes
+// the events journal, if any.
+func (e *Events) Close() error {
+	e.logMu.Lock()
+	if e.limiter != nil {
+		e.limiter.stop()
+		e.limiter = nil
+	}
+	if e.dispatcher != nil {
+		e.dispatcher.stop()
+		e.dispatcher = nil
+	}
+	e.logMu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pruner != nil {
+		close(e.pruner)
+		e.pruner = nil
+	}
+	if e.compactor != nil {
+		close(e.compactor)
+		e.compactor = nil
+	}
+	if e.journal == nil {
+		return nil
+	}
+	return e.journal.Close()
+}
+
+// Dropped returns the number of events that were not delivered to
+// the listener l.
+func (e *Events) Dropped(l <-chan eventtypes.Message) uint64 {
+	stats, _ := e.pub.stat(l)
+	return stats.Dropped
 }
 
 // SubscribersCount returns number of event listeners
 func (e *Events) SubscribersCount() int {
-	return e.pub.Len()
+	return e.pub.len()
 }
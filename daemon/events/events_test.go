@@ -2,35 +2,35 @@ package events
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/docker/engine-api/types/events"
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
 )
 
 func TestEventsLog(t *testing.T) {
-	e := New()
-	_, l1, _ := e.Subscribe()
-	_, l2, _ := e.Subscribe()
+	e := New(0)
+	_, l1 := e.Subscribe(context.Background())
+	_, l2 := e.Subscribe(context.Background())
 	defer e.Evict(l1)
 	defer e.Evict(l2)
 	count := e.SubscribersCount()
 	if count != 2 {
 		t.Fatalf("Must be 2 subscribers, got %d", count)
 	}
-	actor := events.Actor{
+	actor := eventtypes.Actor{
 		ID:         "cont",
 		Attributes: map[string]string{"image": "image"},
 	}
 	e.Log("test", events.ContainerEventType, actor)
 	select {
-	case msg := <-l1:
-		jmsg, ok := msg.(events.Message)
-		if !ok {
-			t.Fatalf("Unexpected type %T", msg)
-		}
-		if len(e.events) != 1 {
-			t.Fatalf("Must be only one event, got %d", len(e.events))
+	case jmsg := <-l1:
+		if len(e.recent.events()) != 1 {
+			t.Fatalf("Must be only one event, got %d", len(e.recent.events()))
 		}
 		if jmsg.Status != "test" {
 			t.Fatalf("Status should be test, got %s", jmsg.Status)
@@ -45,13 +45,9 @@ func TestEventsLog(t *testing.T) {
 		t.Fatal("Timeout waiting for broadcasted message")
 	}
 	select {
-	case msg := <-l2:
-		jmsg, ok := msg.(events.Message)
-		if !ok {
-			t.Fatalf("Unexpected type %T", msg)
-		}
-		if len(e.events) != 1 {
-			t.Fatalf("Must be only one event, got %d", len(e.events))
+	case jmsg := <-l2:
+		if len(e.recent.events()) != 1 {
+			t.Fatalf("Must be only one event, got %d", len(e.recent.events()))
 		}
 		if jmsg.Status != "test" {
 			t.Fatalf("Status should be test, got %s", jmsg.Status)
@@ -68,13 +64,13 @@ func TestEventsLog(t *testing.T) {
 }
 
 func TestEventsLogTimeout(t *testing.T) {
-	e := New()
-	_, l, _ := e.Subscribe()
+	e := New(0)
+	_, l := e.Subscribe(context.Background())
 	defer e.Evict(l)
 
 	c := make(chan struct{})
 	go func() {
-		actor := events.Actor{
+		actor := eventtypes.Actor{
 			ID: "image",
 		}
 		e.Log("test", events.ImageEventType, actor)
@@ -89,45 +85,40 @@ func TestEventsLogTimeout(t *testing.T) {
 }
 
 func TestLogEvents(t *testing.T) {
-	e := New()
+	e := New(0)
 
 	for i := 0; i < eventsLimit+16; i++ {
 		action := fmt.Sprintf("action_%d", i)
 		id := fmt.Sprintf("cont_%d", i)
 		from := fmt.Sprintf("image_%d", i)
 
-		actor := events.Actor{
+		actor := eventtypes.Actor{
 			ID:         id,
 			Attributes: map[string]string{"image": from},
 		}
 		e.Log(action, events.ContainerEventType, actor)
 	}
 	time.Sleep(50 * time.Millisecond)
-	current, l, _ := e.Subscribe()
+	current, l := e.Subscribe(context.Background())
 	for i := 0; i < 10; i++ {
 		num := i + eventsLimit + 16
 		action := fmt.Sprintf("action_%d", num)
 		id := fmt.Sprintf("cont_%d", num)
 		from := fmt.Sprintf("image_%d", num)
 
-		actor := events.Actor{
+		actor := eventtypes.Actor{
 			ID:         id,
 			Attributes: map[string]string{"image": from},
 		}
 		e.Log(action, events.ContainerEventType, actor)
 	}
-	if len(e.events) != eventsLimit {
-		t.Fatalf("Must be %d events, got %d", eventsLimit, len(e.events))
+	if len(e.recent.events()) != eventsLimit {
+		t.Fatalf("Must be %d events, got %d", eventsLimit, len(e.recent.events()))
 	}
 
 	var msgs []events.Message
 	for len(msgs) < 10 {
-		m := <-l
-		jm, ok := (m).(events.Message)
-		if !ok {
-			t.Fatalf("Unexpected type %T", m)
-		}
-		msgs = append(msgs, jm)
+		msgs = append(msgs, <-l)
 	}
 	if len(current) != eventsLimit {
 		t.Fatalf("Must be %d events, got %d", eventsLimit, len(current))
@@ -150,3 +141,307 @@ func TestLogEvents(t *testing.T) {
 		t.Fatalf("Last action is %s, must be action_89", lastC.Status)
 	}
 }
+
+func TestLogEventsBufferSize(t *testing.T) {
+	e := New(128)
+
+	for i := 0; i < 200; i++ {
+		actor := eventtypes.Actor{
+			ID: fmt.Sprintf("cont_%d", i),
+		}
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, actor)
+	}
+
+	current, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+	if len(current) != 128 {
+		t.Fatalf("Must be 128 events, got %d", len(current))
+	}
+	if current[0].Status != "action_72" {
+		t.Fatalf("First action is %s, must be action_72", current[0].Status)
+	}
+}
+
+func TestSubscribeTopicUntil(t *testing.T) {
+	e := New(0)
+
+	for i := 0; i < 5; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	until := e.recent.events()[2]
+
+	buffered, l := e.SubscribeTopic(context.Background(), 0, 0, until.Time, until.TimeNano%int64(time.Second), NewFilter(filters.NewArgs()))
+	defer e.Evict(l)
+	if len(buffered) != 3 {
+		t.Fatalf("Must be 3 events, got %d", len(buffered))
+	}
+	if last := buffered[len(buffered)-1]; last.Action != "action_2" {
+		t.Fatalf("Last action is %s, must be action_2", last.Action)
+	}
+
+	e.Log("action_5", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	select {
+	case ev := <-l:
+		t.Fatalf("Unexpected event after until: %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeTail(t *testing.T) {
+	e := New(0)
+
+	for i := 0; i < 5; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+
+	buffered, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since: -1,
+		Until: -1,
+		Tail:  2,
+	})
+	defer e.Evict(l)
+	if len(buffered) != 2 {
+		t.Fatalf("Must be 2 events, got %d", len(buffered))
+	}
+	if buffered[0].Action != "action_3" || buffered[1].Action != "action_4" {
+		t.Fatalf("Expected the last 2 events, got %v", buffered)
+	}
+
+	// The tail only counts the events selected by since.
+	since := e.recent.events()[4]
+	buffered, l = e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:     since.Time,
+		SinceNano: since.TimeNano % int64(time.Second),
+		Until:     -1,
+		Tail:      3,
+	})
+	defer e.Evict(l)
+	if len(buffered) != 1 || buffered[0].Action != "action_4" {
+		t.Fatalf("Expected only the last event, got %v", buffered)
+	}
+
+	e.Log("action_5", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	select {
+	case ev := <-l:
+		if ev.Action != "action_5" {
+			t.Fatalf("Expected action_5, got %s", ev.Action)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the new event to be streamed")
+	}
+}
+
+func TestSubscribeWithOptionsPolicy(t *testing.T) {
+	policy, err := ParsePolicy("drop-newest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePolicy("unknown"); err == nil {
+		t.Fatal("Expected an error parsing an unknown policy")
+	}
+
+	e := New(0)
+	_, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Policy: policy,
+	})
+	defer e.Evict(l)
+
+	for i := 0; i < bufferSize+10; i++ {
+		e.Log("test", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	e.Flush()
+	if d := e.Dropped(l); d != 10 {
+		t.Fatalf("Must be 10 dropped events, got %d", d)
+	}
+	if m := DroppedMessage(10); m.Actor.Attributes["count"] != "10" {
+		t.Fatalf("Unexpected dropped message %v", m)
+	}
+}
+
+func TestEventsMetrics(t *testing.T) {
+	e := New(2)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	for i := 0; i < 3; i++ {
+		e.Log("test", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	e.Flush()
+
+	m := e.Metrics()
+	if m.Published != 3 {
+		t.Fatalf("Must be 3 published events, got %d", m.Published)
+	}
+	if m.Buffered != 2 || m.BufferSize != 2 {
+		t.Fatalf("Must be 2 of 2 buffered events, got %d of %d", m.Buffered, m.BufferSize)
+	}
+	if len(m.Subscribers) != 1 {
+		t.Fatalf("Must be 1 subscriber, got %d", len(m.Subscribers))
+	}
+	if m.Subscribers[0].Queued != 3 {
+		t.Fatalf("Must be 3 queued events, got %d", m.Subscribers[0].Queued)
+	}
+}
+
+func TestLogVolumeAndNetworkEvents(t *testing.T) {
+	e := New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	e.Log("create", events.VolumeEventType, eventtypes.Actor{
+		ID:         "vol",
+		Attributes: map[string]string{"driver": "local"},
+	})
+	e.Log("connect", events.NetworkEventType, eventtypes.Actor{
+		ID:         "net",
+		Attributes: map[string]string{"container": "cont"},
+	})
+
+	for _, expected := range []string{"vol", "net"} {
+		jmsg := <-l
+		if jmsg.ID != expected {
+			t.Fatalf("ID should be %s, got %s", expected, jmsg.ID)
+		}
+		if jmsg.Status != jmsg.Action {
+			t.Fatalf("Status should be %s, got %s", jmsg.Action, jmsg.Status)
+		}
+		if jmsg.From != "" {
+			t.Fatalf("From should be empty, got %s", jmsg.From)
+		}
+	}
+}
+
+func TestLogSequence(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, l := e.Subscribe(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+		}()
+	}
+	wg.Wait()
+
+	for i := uint64(1); i <= 10; i++ {
+		ev := <-l
+		if ev.Sequence != i {
+			t.Fatalf("Event %d has sequence number %d", i, ev.Sequence)
+		}
+	}
+}
+
+func TestLogNode(t *testing.T) {
+	e := New(0)
+	e.Log("pull", events.ImageEventType, eventtypes.Actor{ID: "busybox"})
+	e.SetNode(Node{ID: "node1", Name: "host1", Labels: map[string]string{"zone": "east"}})
+	actor := eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "web"}}
+	e.Log("start", events.ContainerEventType, actor)
+
+	if a := e.recent.events()[0].Actor.Attributes; a["node.id"] != "" {
+		t.Fatalf("Expected event without node, got %v", a)
+	}
+	a := e.recent.events()[1].Actor.Attributes
+	if a["node.id"] != "node1" || a["node.name"] != "host1" || a["node.label.zone"] != "east" || a["name"] != "web" {
+		t.Fatalf("Expected event from node1, got %v", a)
+	}
+	if _, ok := actor.Attributes["node.id"]; ok {
+		t.Fatal("Expected attributes of the actor not to be modified")
+	}
+}
+
+func TestSubscribeContext(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, l := e.Subscribe(ctx)
+
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	cancel()
+	for range l {
+	}
+	if n := e.SubscribersCount(); n != 0 {
+		t.Fatalf("Expected the subscriber to be evicted, got %d subscribers", n)
+	}
+	if n := len(e.Subscribers("")); n != 0 {
+		t.Fatalf("Expected no subscribers, got %d", n)
+	}
+}
+
+// benchmarkLog logs b.N events while subscribers subscribers, each
+// subscribed with a filter created by filter if not nil, receive them.
+func benchmarkLog(b *testing.B, subscribers int, filter func() *Filter) {
+	e := New(0)
+	var wg sync.WaitGroup
+	for i := 0; i < subscribers; i++ {
+		var ef *Filter
+		if filter != nil {
+			ef = filter()
+		}
+		_, l := e.SubscribeTopic(context.Background(), -1, 0, -1, 0, ef)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range l {
+			}
+		}()
+	}
+	actor := eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "web", "image": "busybox:latest"}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Log("start", events.ContainerEventType, actor)
+	}
+	b.StopTimer()
+
+	for _, s := range e.Subscribers("") {
+		e.EvictSubscriber(s.ID, "")
+	}
+	wg.Wait()
+}
+
+func BenchmarkLog(b *testing.B) {
+	benchmarkLog(b, 0, nil)
+}
+
+func BenchmarkLogSubscribers(b *testing.B) {
+	benchmarkLog(b, 50, nil)
+}
+
+func BenchmarkLogFiltered(b *testing.B) {
+	benchmarkLog(b, 50, func() *Filter {
+		ef := filters.NewArgs()
+		ef.Add("type", "container")
+		ef.Add("image", "busybox")
+		ef.Add("event", "start")
+		return NewFilter(ef)
+	})
+}
+
+func BenchmarkSubscribe(b *testing.B) {
+	e := New(1024)
+	actor := eventtypes.Actor{ID: "cont"}
+	for i := 0; i < 1024; i++ {
+		e.Log("start", events.ContainerEventType, actor)
+	}
+	ef := filters.NewArgs()
+	ef.Add("event", "start")
+	filter := NewFilter(ef)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		past, _ := e.SubscribeTopic(ctx, 0, 0, -1, 0, filter)
+		if len(past) != 1024 {
+			b.Fatalf("Expected 1024 past events, got %d", len(past))
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,138 @@
+package eventspb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/golang/protobuf/proto"
+)
+
+// MediaType is the media type of a stream of events encoded as protocol
+// buffers.
+const MediaType = "application/vnd.docker.events.protobuf"
+
+// FromMessage converts an event message to its protocol buffer.
+func FromMessage(m events.Message) *Event {
+	ev := &Event{
+		Type:   proto.String(m.Type),
+		Action: proto.String(m.Action),
+		Actor: &Actor{
+			Id:         proto.String(m.Actor.ID),
+			Attributes: m.Actor.Attributes,
+		},
+	}
+	if m.Time != 0 {
+		ev.Time = proto.Int64(m.Time)
+	}
+	if m.TimeNano != 0 {
+		ev.TimeNano = proto.Int64(m.TimeNano)
+	}
+	if m.Sequence != 0 {
+		ev.Sequence = proto.Uint64(m.Sequence)
+	}
+	if m.Status != "" {
+		ev.Status = proto.String(m.Status)
+	}
+	if m.ID != "" {
+		ev.Id = proto.String(m.ID)
+	}
+	if m.From != "" {
+		ev.From = proto.String(m.From)
+	}
+	if m.Signature != "" {
+		ev.Signature = proto.String(m.Signature)
+	}
+	return ev
+}
+
+// ToMessage converts the protocol buffer of an event to its message.
+func ToMessage(ev *Event) events.Message {
+	return events.Message{
+		Message: eventtypes.Message{
+			Type:   ev.GetType(),
+			Action: ev.GetAction(),
+			Actor: eventtypes.Actor{
+				ID:         ev.GetActor().GetId(),
+				Attributes: ev.GetActor().GetAttributes(),
+			},
+			Time:     ev.GetTime(),
+			TimeNano: ev.GetTimeNano(),
+			Status:   ev.GetStatus(),
+			ID:       ev.GetId(),
+			From:     ev.GetFrom(),
+		},
+		Sequence:  ev.GetSequence(),
+		Signature: ev.GetSignature(),
+	}
+}
+
+// Encoder writes events to a stream, each preceded by its length.
+type Encoder struct {
+	w   io.Writer
+	buf *proto.Buffer
+}
+
+// NewEncoder returns an encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, buf: proto.NewBuffer(nil)}
+}
+
+// Encode writes an event message, or a batch of them.
+func (e *Encoder) Encode(v interface{}) error {
+	var msgs []events.Message
+	switch m := v.(type) {
+	case events.Message:
+		msgs = []events.Message{m}
+	case []events.Message:
+		msgs = m
+	default:
+		return fmt.Errorf("cannot encode %T as an event", v)
+	}
+	e.buf.Reset()
+	for _, m := range msgs {
+		data, err := proto.Marshal(FromMessage(m))
+		if err != nil {
+			return err
+		}
+		if err := e.buf.EncodeRawBytes(data); err != nil {
+			return err
+		}
+	}
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// Decoder reads the events written by an Encoder.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next event message. It returns io.EOF at the end of
+// the stream.
+func (d *Decoder) Decode() (events.Message, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return events.Message{}, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return events.Message{}, err
+	}
+	var ev Event
+	if err := proto.Unmarshal(data, &ev); err != nil {
+		return events.Message{}, err
+	}
+	return ToMessage(&ev), nil
+}
@@ -0,0 +1,63 @@
+package eventspb
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	msgs := []events.Message{
+		{
+			Message: eventtypes.Message{
+				Status: "start",
+				ID:     "abc",
+				From:   "busybox",
+				Type:   events.ContainerEventType,
+				Action: "start",
+				Actor: eventtypes.Actor{
+					ID:         "abc",
+					Attributes: map[string]string{"name": "web", "image": "busybox"},
+				},
+				Time:     1,
+				TimeNano: 1000000001,
+			},
+			Sequence: 1,
+		},
+		{
+			Message: eventtypes.Message{
+				Type:   events.NetworkEventType,
+				Action: "connect",
+				Actor:  eventtypes.Actor{ID: "net"},
+			},
+			Sequence: 2,
+		},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(msgs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(msgs[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(&buf)
+	for _, expected := range msgs {
+		m, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(m, expected) {
+			t.Fatalf("Expected %v, got %v", expected, m)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("Expected EOF, got %v", err)
+	}
+}
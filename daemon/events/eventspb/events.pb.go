@@ -0,0 +1,135 @@
+// Code generated by protoc-gen-go.
+// source: events.proto
+// DO NOT EDIT!
+
+/*
+Package eventspb is a generated protocol buffer package.
+
+It is generated from these files:
+	events.proto
+
+It has these top-level messages:
+	Actor
+	Event
+*/
+package eventspb
+
+import proto "github.com/golang/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type Actor struct {
+	Id               *string           `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Attributes       map[string]string `protobuf:"bytes,2,rep,name=attributes" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *Actor) Reset()         { *m = Actor{} }
+func (m *Actor) String() string { return proto.CompactTextString(m) }
+func (*Actor) ProtoMessage()    {}
+
+func (m *Actor) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Actor) GetAttributes() map[string]string {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+type Event struct {
+	Type     *string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	Action   *string `protobuf:"bytes,2,opt,name=action" json:"action,omitempty"`
+	Actor    *Actor  `protobuf:"bytes,3,opt,name=actor" json:"actor,omitempty"`
+	Time     *int64  `protobuf:"varint,4,opt,name=time" json:"time,omitempty"`
+	TimeNano *int64  `protobuf:"varint,5,opt,name=time_nano" json:"time_nano,omitempty"`
+	Sequence *uint64 `protobuf:"varint,6,opt,name=sequence" json:"sequence,omitempty"`
+	// Deprecated information, only set in container events.
+	Status           *string `protobuf:"bytes,7,opt,name=status" json:"status,omitempty"`
+	Id               *string `protobuf:"bytes,8,opt,name=id" json:"id,omitempty"`
+	From             *string `protobuf:"bytes,9,opt,name=from" json:"from,omitempty"`
+	Signature        *string `protobuf:"bytes,10,opt,name=signature" json:"signature,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+func (m *Event) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+func (m *Event) GetAction() string {
+	if m != nil && m.Action != nil {
+		return *m.Action
+	}
+	return ""
+}
+
+func (m *Event) GetActor() *Actor {
+	if m != nil {
+		return m.Actor
+	}
+	return nil
+}
+
+func (m *Event) GetTime() int64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+func (m *Event) GetTimeNano() int64 {
+	if m != nil && m.TimeNano != nil {
+		return *m.TimeNano
+	}
+	return 0
+}
+
+func (m *Event) GetSequence() uint64 {
+	if m != nil && m.Sequence != nil {
+		return *m.Sequence
+	}
+	return 0
+}
+
+func (m *Event) GetStatus() string {
+	if m != nil && m.Status != nil {
+		return *m.Status
+	}
+	return ""
+}
+
+func (m *Event) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Event) GetFrom() string {
+	if m != nil && m.From != nil {
+		return *m.From
+	}
+	return ""
+}
+
+func (m *Event) GetSignature() string {
+	if m != nil && m.Signature != nil {
+		return *m.Signature
+	}
+	return ""
+}
@@ -0,0 +1,327 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"golang.org/x/net/context"
+)
+
+// Exporter sends events to an external system.
+type Exporter interface {
+	// Export sends the event m.
+	Export(m eventtypes.Message) error
+	// Close releases the resources held by the exporter.
+	Close() error
+}
+
+// BatchExporter is implemented by the exporters that send several events
+// at once. The forwarder of a batch exporter passes it all the events
+// queued while it was exporting the previous batch.
+type BatchExporter interface {
+	Exporter
+	// ExportBatch sends the events ms, oldest first.
+	ExportBatch(ms []eventtypes.Message) error
+}
+
+// ExporterCreator builds an exporter with the given options.
+type ExporterCreator func(options map[string]string) (Exporter, error)
+
+// ExporterConfig is the configuration of an exporter the daemon
+// sends its events to.
+type ExporterConfig struct {
+	// Type is the name the exporter is registered with.
+	Type string `json:"type"`
+	// Options are specific to the type of exporter.
+	Options map[string]string `json:"options,omitempty"`
+	// Filters selects the events sent to the exporter, using the same
+	// filters as the events API.
+	Filters map[string][]string `json:"filters,omitempty"`
+	// QueueSize is the number of events waiting to be exported after
+	// which the oldest ones are dropped, it defaults to 1024.
+	QueueSize int `json:"queue-size,omitempty"`
+	// Backfill makes the exporter retry until it recovers when it fails
+	// to export an event, and then export the events it missed from the
+	// journal before the new ones.
+	Backfill bool `json:"backfill,omitempty"`
+}
+
+type exporterFactory struct {
+	registry map[string]ExporterCreator
+	m        sync.Mutex
+}
+
+var exporters = &exporterFactory{registry: make(map[string]ExporterCreator)}
+
+// RegisterExporter registers the given exporter builder with the given
+// exporter type name.
+func RegisterExporter(name string, c ExporterCreator) error {
+	exporters.m.Lock()
+	defer exporters.m.Unlock()
+
+	if _, ok := exporters.registry[name]; ok {
+		return fmt.Errorf("events: exporter named '%s' is already registered", name)
+	}
+	exporters.registry[name] = c
+	return nil
+}
+
+// unregisterExporter removes the exporter builder registered with name.
+func unregisterExporter(name string) {
+	exporters.m.Lock()
+	delete(exporters.registry, name)
+	exporters.m.Unlock()
+}
+
+// NewExporter builds the exporter described by config.
+func NewExporter(config ExporterConfig) (Exporter, error) {
+	exporters.m.Lock()
+	c, ok := exporters.registry[config.Type]
+	exporters.m.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("events: no exporter named '%s' is registered", config.Type)
+	}
+	x, err := c(config.Options)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s events exporter: %v", config.Type, err)
+	}
+	return x, nil
+}
+
+// defaultQueueSize is the number of events a forwarder queues for its
+// exporter when the exporter configuration does not set it.
+const defaultQueueSize = 1024
+
+// Forwarder feeds an exporter with the events of the daemon. Events are
+// queued, and exported asynchronously, so that a slow exporter does
+// not delay the other subscribers.
+type Forwarder struct {
+	dropped   uint64 // accessed atomically
+	name      string
+	x         Exporter
+	filter    *Filter
+	queueSize int
+	events    *Events
+	l         <-chan eventtypes.Message
+	done      chan struct{}
+	// stop is closed when the forwarder is stopped, to give up the
+	// backfill of an exporter that does not recover.
+	stop     chan struct{}
+	backfill bool
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []eventtypes.Message
+	closed bool
+	// exporting is the number of events being exported.
+	exporting int
+	exported  uint64
+	failed    uint64
+	// failing is true when the last export failed, with lastError.
+	failing    bool
+	lastError  string
+	lastFailed time.Time
+	lastExport time.Time
+	// acked is the sequence number of the last event exported.
+	acked uint64
+}
+
+// NewForwarder returns a forwarder for the exporter x, name is used
+// to identify the exporter in the daemon logs. Only the events included
+// by ef are exported, all of them when ef is nil. At most queueSize
+// events wait to be exported, the default is 1024 when it is not
+// positive.
+func NewForwarder(name string, x Exporter, ef *Filter, queueSize int) *Forwarder {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	f := &Forwarder{
+		name:      name,
+		x:         x,
+		filter:    ef,
+		queueSize: queueSize,
+		done:      make(chan struct{}),
+		stop:      make(chan struct{}),
+	}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// StartExporter builds the exporter described by config, and starts
+// forwarding it the events of e.
+func StartExporter(e *Events, config ExporterConfig) (*Forwarder, error) {
+	x, err := NewExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	f := NewForwarder(config.Type, x, NewFilterFromMap(config.Filters), config.QueueSize)
+	f.backfill = config.Backfill
+	f.Start(e)
+	return f, nil
+}
+
+// Start subscribes the forwarder to the events of e, and exports them
+// in the background. Events that arrive when the queue is full are
+// dropped, oldest first.
+func (f *Forwarder) Start(e *Events) {
+	f.events = e
+	// The backfill starts after the events logged before the forwarder.
+	e.mu.Lock()
+	f.acked = e.sequence
+	e.mu.Unlock()
+	_, f.l = e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Filter: f.filter,
+		Policy: DropOldest,
+	})
+	go f.receive()
+	go f.run()
+}
+
+// Stop unsubscribes the forwarder, exports the events already queued
+// and closes the exporter.
+func (f *Forwarder) Stop() {
+	f.events.Evict(f.l)
+	close(f.stop)
+	<-f.done
+	if err := f.x.Close(); err != nil {
+		logrus.Errorf("Error closing %s events exporter: %v", f.name, err)
+	}
+}
+
+// Dropped returns the number of events the forwarder dropped because
+// its queue was full.
+func (f *Forwarder) Dropped() uint64 {
+	return atomic.LoadUint64(&f.dropped)
+}
+
+// queued returns the number of events waiting to be exported.
+func (f *Forwarder) queued() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.queue)
+}
+
+// receive moves the events of the subscription to the queue.
+func (f *Forwarder) receive() {
+	for ev := range f.l {
+		f.mu.Lock()
+		if len(f.queue) >= f.queueSize {
+			f.queue = f.queue[1:]
+			atomic.AddUint64(&f.dropped, 1)
+		}
+		f.queue = append(f.queue, ev)
+		f.cond.Signal()
+		f.mu.Unlock()
+	}
+	f.mu.Lock()
+	f.closed = true
+	f.cond.Signal()
+	f.mu.Unlock()
+}
+
+// run exports the queued events until the subscription is closed and
+// the queue is empty.
+func (f *Forwarder) run() {
+	defer close(f.done)
+	for {
+		f.mu.Lock()
+		for len(f.queue) == 0 && !f.closed {
+			f.cond.Wait()
+		}
+		if len(f.queue) == 0 {
+			f.mu.Unlock()
+			return
+		}
+		if bx, ok := f.x.(BatchExporter); ok {
+			batch := f.queue
+			f.queue = nil
+			f.exporting = len(batch)
+			f.mu.Unlock()
+
+			err := bx.ExportBatch(batch)
+			f.record(batch, err)
+			if err != nil {
+				logrus.Errorf("Error exporting %d events to %s: %v", len(batch), f.name, err)
+				if f.backfill {
+					if !f.recover() {
+						return
+					}
+					continue
+				}
+				for _, ev := range batch {
+					f.events.deadLetter(f.name, ev, err)
+				}
+			}
+			continue
+		}
+		ev := f.queue[0]
+		f.queue = f.queue[1:]
+		f.exporting = 1
+		f.mu.Unlock()
+
+		err := f.x.Export(ev)
+		f.record([]eventtypes.Message{ev}, err)
+		if err != nil {
+			logrus.Errorf("Error exporting event to %s: %v", f.name, err)
+			if f.backfill {
+				if !f.recover() {
+					return
+				}
+				continue
+			}
+			f.events.deadLetter(f.name, ev, err)
+		}
+	}
+}
+
+// record records the result of the export of the events evs.
+func (f *Forwarder) record(evs []eventtypes.Message, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exporting = 0
+	f.failing = err != nil
+	if err != nil {
+		f.failed += uint64(len(evs))
+		f.lastError = err.Error()
+		f.lastFailed = time.Now().UTC()
+		return
+	}
+	f.exported += uint64(len(evs))
+	f.lastExport = time.Now().UTC()
+	if seq := evs[len(evs)-1].Sequence; seq > f.acked {
+		f.acked = seq
+	}
+}
+
+// Status returns the state of the exporter of the forwarder. The events
+// behind are the ones queued, being exported, and received by the
+// subscription of the forwarder but not queued yet.
+func (f *Forwarder) Status() eventtypes.ExporterStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := eventtypes.ExporterStatus{
+		Name:      f.name,
+		Connected: !f.failing,
+		LastError: f.lastError,
+		Exported:  f.exported,
+		Failed:    f.failed,
+		Queued:    len(f.queue),
+		Behind:    len(f.queue) + f.exporting + len(f.l),
+		Dropped:   atomic.LoadUint64(&f.dropped),
+		Sequence:  f.acked,
+	}
+	if !f.lastFailed.IsZero() {
+		s.LastErrorTime = f.lastFailed.Format(time.RFC3339Nano)
+	}
+	if !f.lastExport.IsZero() {
+		s.LastExportTime = f.lastExport.Format(time.RFC3339Nano)
+	}
+	return s
+}
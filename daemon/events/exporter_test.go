@@ -0,0 +1,186 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+type fakeExporter struct {
+	exported chan events.Message
+	closed   bool
+}
+
+func (x *fakeExporter) Export(m events.Message) error {
+	x.exported <- m
+	return nil
+}
+
+func (x *fakeExporter) Close() error {
+	x.closed = true
+	return nil
+}
+
+func TestForwarder(t *testing.T) {
+	x := &fakeExporter{exported: make(chan events.Message, 1)}
+	if err := RegisterExporter("fake", func(map[string]string) (Exporter, error) { return x, nil }); err != nil {
+		t.Fatal(err)
+	}
+	defer unregisterExporter("fake")
+	if err := RegisterExporter("fake", nil); err == nil {
+		t.Fatal("Expected an error registering the same exporter twice")
+	}
+	if _, err := NewExporter(ExporterConfig{Type: "unknown"}); err == nil {
+		t.Fatal("Expected an error for an unknown exporter")
+	}
+	exporter, err := NewExporter(ExporterConfig{Type: "fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(0)
+	f := NewForwarder("fake", exporter, nil, 0)
+	f.Start(e)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	select {
+	case m := <-x.exported:
+		if m.Action != "start" || m.Actor.ID != "cont" {
+			t.Fatalf("Unexpected event %v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the exported event")
+	}
+	f.Stop()
+	if !x.closed {
+		t.Fatal("Expected the exporter to be closed")
+	}
+	if n := e.SubscribersCount(); n != 0 {
+		t.Fatalf("Expected no subscribers, got %d", n)
+	}
+}
+
+// blockingExporter blocks every export until it is released.
+type blockingExporter struct {
+	exporting chan struct{}
+	release   chan struct{}
+	exported  []events.Message
+}
+
+func (x *blockingExporter) Export(m events.Message) error {
+	x.exporting <- struct{}{}
+	<-x.release
+	x.exported = append(x.exported, m)
+	return nil
+}
+
+func (x *blockingExporter) Close() error {
+	return nil
+}
+
+func TestForwarderFilterAndQueue(t *testing.T) {
+	x := &blockingExporter{exporting: make(chan struct{}, 4), release: make(chan struct{})}
+	e := New(0)
+	f := NewForwarder("blocking", x, NewFilterFromMap(map[string][]string{"type": {"network"}}), 2)
+	f.Start(e)
+
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Log("create", events.NetworkEventType, eventtypes.Actor{ID: "net"})
+	<-x.exporting
+	// the first network event is being exported, the next ones are queued
+	// until the queue is full
+	for _, action := range []string{"connect", "disconnect", "destroy"} {
+		e.Log(action, events.NetworkEventType, eventtypes.Actor{ID: "net"})
+	}
+	deadline := time.After(5 * time.Second)
+	for f.Dropped() != 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("Expected 1 dropped event, got %d", f.Dropped())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	close(x.release)
+	f.Stop()
+
+	var actions []string
+	for _, m := range x.exported {
+		actions = append(actions, m.Action)
+	}
+	if len(actions) != 3 || actions[0] != "create" || actions[1] != "disconnect" || actions[2] != "destroy" {
+		t.Fatalf("Unexpected exported events %v", actions)
+	}
+}
+
+type fakeBatchExporter struct {
+	fakeExporter
+	batches chan []events.Message
+	entered chan struct{}
+	block   chan struct{}
+}
+
+func (x *fakeBatchExporter) ExportBatch(ms []events.Message) error {
+	x.entered <- struct{}{}
+	<-x.block
+	x.batches <- ms
+	return nil
+}
+
+func TestForwarderBatch(t *testing.T) {
+	x := &fakeBatchExporter{
+		batches: make(chan []events.Message, 2),
+		entered: make(chan struct{}, 2),
+		block:   make(chan struct{}, 2),
+	}
+	e := New(0)
+	f := NewForwarder("fake", x, nil, 0)
+	f.Start(e)
+
+	// The first event is exported on its own, the following ones are
+	// queued meanwhile and exported together.
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	<-x.entered
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	for f.queued() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	x.block <- struct{}{}
+	x.block <- struct{}{}
+
+	for _, expected := range [][]string{{"create"}, {"start", "die"}} {
+		select {
+		case batch := <-x.batches:
+			if len(batch) != len(expected) {
+				t.Fatalf("Expected batch %v, got %v", expected, batch)
+			}
+			for i, action := range expected {
+				if batch[i].Action != action {
+					t.Fatalf("Expected batch %v, got %v", expected, batch)
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for the exported batch")
+		}
+	}
+	f.Stop()
+}
+
+func TestForwarderStatus(t *testing.T) {
+	e := New(0)
+	f := NewForwarder("fluentd", failingExporter{}, nil, 0)
+	f.Start(e)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Log("stop", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Flush()
+	f.Stop()
+
+	s := f.Status()
+	if s.Name != "fluentd" || s.Connected || s.Failed != 2 || s.Exported != 0 || s.Behind != 0 {
+		t.Fatalf("Unexpected status %+v", s)
+	}
+	if s.LastError != "endpoint unavailable" || s.LastErrorTime == "" || s.LastExportTime != "" {
+		t.Fatalf("Unexpected status %+v", s)
+	}
+}
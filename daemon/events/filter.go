@@ -1,37 +1,147 @@
 package events
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/reference"
-	"github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
 )
 
 // Filter can filter out docker events from a stream
 type Filter struct {
-	filter filters.Args
+	filter    filters.Args
+	labels    []labelSelector
+	notLabels []labelSelector
+	patterns  map[string][]*regexp.Regexp
+}
+
+// patternFields are the filters that accept glob patterns, or regular
+// expressions prefixed by "~", on names.
+var patternFields = []string{
+	events.ContainerEventType,
+	events.VolumeEventType,
+	events.NetworkEventType,
+	"image",
+	"name",
+}
+
+// acceptedFilters are the names of the filters of the events.
+var acceptedFilters = map[string]bool{
+	"container": true,
+	"event":     true,
+	"image":     true,
+	"label":     true,
+	"label!":    true,
+	"name":      true,
+	"network":   true,
+	"type":      true,
+	"volume":    true,
+}
+
+// ParseFilter creates a new Filter like NewFilter, after checking that
+// the filters are valid: their names are known, the regular expressions
+// compile and the label selectors are well formed. The error starts with
+// "bad parameter" otherwise.
+func ParseFilter(filter filters.Args) (*Filter, error) {
+	if err := filter.Validate(acceptedFilters); err != nil {
+		return nil, fmt.Errorf("bad parameter: %v", err)
+	}
+	for _, field := range patternFields {
+		for _, value := range filter.Get(field) {
+			if !strings.HasPrefix(value, "~") {
+				continue
+			}
+			if _, err := regexp.Compile(value[1:]); err != nil {
+				return nil, fmt.Errorf("bad parameter: invalid regular expression in '%s' filter %q: %v", field, value, err)
+			}
+		}
+	}
+	for _, field := range []string{"label", "label!"} {
+		for _, value := range filter.Get(field) {
+			if err := validateLabelSelector(value); err != nil {
+				return nil, fmt.Errorf("bad parameter: invalid '%s' filter %q: %v", field, value, err)
+			}
+		}
+	}
+	return NewFilter(filter), nil
 }
 
-// NewFilter creates a new Filter
+// NewFilter creates a new Filter. The filters that are not valid are
+// not checked: an unknown filter is ignored, an invalid regular
+// expression never matches.
 func NewFilter(filter filters.Args) *Filter {
-	return &Filter{filter: filter}
+	ef := &Filter{filter: filter}
+	for _, l := range filter.Get("label") {
+		ef.labels = append(ef.labels, parseLabelSelector(l))
+	}
+	for _, l := range filter.Get("label!") {
+		ef.notLabels = append(ef.notLabels, parseLabelSelector(l))
+	}
+	ef.patterns = make(map[string][]*regexp.Regexp)
+	for _, field := range patternFields {
+		for _, pattern := range filter.Get(field) {
+			if re := compilePattern(pattern); re != nil {
+				ef.patterns[field] = append(ef.patterns[field], re)
+			}
+		}
+	}
+	return ef
+}
+
+// NewFilterFromMap creates a new Filter from the filters names and
+// values, as found in the configuration file of the daemon.
+func NewFilterFromMap(m map[string][]string) *Filter {
+	args := filters.NewArgs()
+	for name, values := range m {
+		for _, value := range values {
+			args.Add(name, value)
+		}
+	}
+	return NewFilter(args)
 }
 
 // Include returns true when the event ev is included by the filters
 func (ef *Filter) Include(ev events.Message) bool {
-	return ef.filter.ExactMatch("event", ev.Action) &&
+	return ef.matchEvent(ev) &&
 		ef.filter.ExactMatch("type", ev.Type) &&
 		ef.matchContainer(ev) &&
 		ef.matchVolume(ev) &&
 		ef.matchNetwork(ev) &&
 		ef.matchImage(ev) &&
+		ef.matchName(ev) &&
 		ef.matchLabels(ev.Actor.Attributes)
 }
 
+// matchLabels returns true when the attributes match all the label
+// selectors, and none of the negated label selectors.
 func (ef *Filter) matchLabels(attributes map[string]string) bool {
-	if !ef.filter.Include("label") {
+	for _, l := range ef.labels {
+		if !l.match(attributes) {
+			return false
+		}
+	}
+	for _, l := range ef.notLabels {
+		if l.match(attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchEvent returns true when the action of the event is filtered. The
+// actions of the exec events are followed by their command, as in
+// "exec_start: sh", they are matched by the part before the colon too.
+func (ef *Filter) matchEvent(ev events.Message) bool {
+	if ef.filter.ExactMatch("event", ev.Action) {
 		return true
 	}
-	return ef.filter.MatchKVList("label", attributes)
+	if i := strings.Index(ev.Action, ":"); i > 0 {
+		return ef.filter.ExactMatch("event", ev.Action[:i])
+	}
+	return false
 }
 
 func (ef *Filter) matchContainer(ev events.Message) bool {
@@ -46,9 +156,28 @@ func (ef *Filter) matchNetwork(ev events.Message) bool {
 	return ef.fuzzyMatchName(ev, events.NetworkEventType)
 }
 
+// matchName matches the name of any kind of actor.
+func (ef *Filter) matchName(ev events.Message) bool {
+	name := ev.Actor.Attributes["name"]
+	return ef.filter.ExactMatch("name", name) ||
+		ef.patternMatch("name", name)
+}
+
 func (ef *Filter) fuzzyMatchName(ev events.Message, eventType string) bool {
 	return ef.filter.FuzzyMatch(eventType, ev.Actor.ID) ||
-		ef.filter.FuzzyMatch(eventType, ev.Actor.Attributes["name"])
+		ef.filter.FuzzyMatch(eventType, ev.Actor.Attributes["name"]) ||
+		ef.patternMatch(eventType, ev.Actor.Attributes["name"])
+}
+
+// patternMatch returns true if the source matches one of the filters
+// that are glob patterns or regular expressions.
+func (ef *Filter) patternMatch(field, source string) bool {
+	for _, re := range ef.patterns[field] {
+		if re.MatchString(source) {
+			return true
+		}
+	}
+	return false
 }
 
 // matchImage matches against both event.Actor.ID (for image events)
@@ -56,6 +185,10 @@ func (ef *Filter) fuzzyMatchName(ev events.Message, eventType string) bool {
 // from an image will be included in the image events. Also compare both
 // against the stripped repo name without any tags.
 func (ef *Filter) matchImage(ev events.Message) bool {
+	if !ef.filter.Include("image") {
+		// Parsing the references below is expensive.
+		return true
+	}
 	id := ev.Actor.ID
 	nameAttr := "image"
 	var imageName string
@@ -70,7 +203,8 @@ func (ef *Filter) matchImage(ev events.Message) bool {
 	return ef.filter.ExactMatch("image", id) ||
 		ef.filter.ExactMatch("image", imageName) ||
 		ef.filter.ExactMatch("image", stripTag(id)) ||
-		ef.filter.ExactMatch("image", stripTag(imageName))
+		ef.filter.ExactMatch("image", stripTag(imageName)) ||
+		ef.patternMatch("image", imageName)
 }
 
 func stripTag(image string) string {
@@ -0,0 +1,211 @@
+package events
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+func TestFilterLabelSelectors(t *testing.T) {
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "start",
+			Actor: eventtypes.Actor{
+				ID: "cont",
+				Attributes: map[string]string{
+					"env":  "prod",
+					"tier": "web",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		key, value string
+		include    bool
+	}{
+		{"label", "env", true},
+		{"label", "env=prod", true},
+		{"label", "env=dev", false},
+		{"label", "env in (staging, prod)", true},
+		{"label", "env in (dev,staging)", false},
+		{"label", "env notin (dev,staging)", true},
+		{"label", "env notin (prod)", false},
+		{"label", "owner notin (bob)", true},
+		{"label!", "owner", true},
+		{"label!", "env", false},
+		{"label!", "env=dev", true},
+		{"label!", "env=prod", false},
+	}
+
+	for _, c := range cases {
+		args := filters.NewArgs()
+		args.Add(c.key, c.value)
+		if include := NewFilter(args).Include(ev); include != c.include {
+			t.Fatalf("Expected %s=%s to include the event: %v, got %v", c.key, c.value, c.include, include)
+		}
+	}
+}
+
+func TestFilterGlobs(t *testing.T) {
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "start",
+			Actor: eventtypes.Actor{
+				ID: "4386fb97867d",
+				Attributes: map[string]string{
+					"name":  "web-1",
+					"image": "docker.io/library/nginx:1.9",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		key, value string
+		include    bool
+	}{
+		{"container", "web-*", true},
+		{"container", "web-?", true},
+		{"container", "db-*", false},
+		{"container", "*-1", true},
+		{"image", "*/nginx:*", true},
+		{"image", "*/redis:*", false},
+	}
+
+	for _, c := range cases {
+		args := filters.NewArgs()
+		args.Add(c.key, c.value)
+		if include := NewFilter(args).Include(ev); include != c.include {
+			t.Fatalf("Expected %s=%s to include the event: %v, got %v", c.key, c.value, c.include, include)
+		}
+	}
+}
+
+func TestFilterRegexp(t *testing.T) {
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "start",
+			Actor: eventtypes.Actor{
+				ID: "4386fb97867d",
+				Attributes: map[string]string{
+					"name":  "web-12",
+					"image": "nginx:1.9",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		key, value string
+		include    bool
+	}{
+		{"name", "web-12", true},
+		{"name", "web-1", false},
+		{"name", `~^web-\d+$`, true},
+		{"name", `~^db-\d+$`, false},
+		{"name", `~^web-(`, false},
+		{"container", `~^web-\d+$`, true},
+		{"container", `~^4386`, false},
+		{"image", `~^nginx:1\.`, true},
+		{"image", `~^redis`, false},
+	}
+
+	for _, c := range cases {
+		args := filters.NewArgs()
+		args.Add(c.key, c.value)
+		if include := NewFilter(args).Include(ev); include != c.include {
+			t.Fatalf("Expected %s=%s to include the event: %v, got %v", c.key, c.value, c.include, include)
+		}
+	}
+}
+
+func TestFilterExecEvents(t *testing.T) {
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "exec_start: sh -c true",
+			Actor:  eventtypes.Actor{ID: "cont"},
+		},
+	}
+	for value, include := range map[string]bool{
+		"exec_start":             true,
+		"exec_start: sh -c true": true,
+		"exec_create":            false,
+		"exec_start: sh":         false,
+	} {
+		args := filters.NewArgs()
+		args.Add("event", value)
+		if NewFilter(args).Include(ev) != include {
+			t.Fatalf("Expected event=%q to include %v, got %v", value, ev.Action, !include)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		key, value string
+		valid      bool
+	}{
+		{"type", "container", true},
+		{"event", "start", true},
+		{"name", "~^web-[0-9]+$", true},
+		{"image", "busybox*", true},
+		{"label", "env in (prod,staging)", true},
+		{"label!", "env=dev", true},
+		{"label", "tier", true},
+		{"unknown", "value", false},
+		{"name", "~web-(", false},
+		{"container", "~[", false},
+		{"label", "", false},
+		{"label", "=prod", false},
+		{"label", "env in (prod", false},
+		{"label!", "env in (prod,)", false},
+	}
+	for _, c := range cases {
+		args := filters.NewArgs()
+		args.Add(c.key, c.value)
+		ef, err := ParseFilter(args)
+		if c.valid && (err != nil || ef == nil) {
+			t.Fatalf("Expected %s=%q to be valid, got %v", c.key, c.value, err)
+		}
+		if !c.valid && (err == nil || !strings.HasPrefix(err.Error(), "bad parameter")) {
+			t.Fatalf("Expected a bad parameter error for %s=%q, got %v", c.key, c.value, err)
+		}
+	}
+}
+
+func TestParseFilterRandom(t *testing.T) {
+	const alphabet = "abz09~^$*?()[]{}|\\.+=!, \t"
+	fields := []string{"type", "event", "name", "image", "container", "label", "label!"}
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "start",
+			Actor:  eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "web", "image": "busybox"}},
+		},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		b := make([]byte, r.Intn(16))
+		for j := range b {
+			b[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		args := filters.NewArgs()
+		args.Add(fields[r.Intn(len(fields))], string(b))
+		// Neither parsing nor matching must panic, whether the filter is
+		// valid or not.
+		if ef, err := ParseFilter(args); err == nil {
+			ef.Include(ev)
+		}
+		NewFilter(args).Include(ev)
+	}
+}
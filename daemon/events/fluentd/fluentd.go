@@ -0,0 +1,138 @@
+// Package fluentd provides the events exporter forwarding the daemon
+// events to fluentd endpoints.
+package fluentd
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events"
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+const (
+	name = "fluentd"
+
+	addressKey     = "fluentd-address"
+	tagPrefixKey   = "fluentd-tag-prefix"
+	bufferLimitKey = "fluentd-buffer-limit"
+
+	defaultHostName    = "localhost"
+	defaultPort        = 24224
+	defaultTagPrefix   = "docker.events"
+	defaultBufferLimit = 1 * 1024 * 1024 // 1M buffer by default
+)
+
+func init() {
+	if err := events.RegisterExporter(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+type exporter struct {
+	prefix string
+	writer *fluent.Fluent
+}
+
+// New creates a fluentd exporter with the given options. Supported options
+// are fluentd-address, fluentd-tag-prefix and fluentd-buffer-limit. As with
+// the fluentd logging driver, the events are buffered while the endpoint
+// cannot be reached, and the exporter reconnects on its own.
+func New(options map[string]string) (events.Exporter, error) {
+	for key := range options {
+		switch key {
+		case addressKey:
+		case tagPrefixKey:
+		case bufferLimitKey:
+		default:
+			return nil, fmt.Errorf("unknown option '%s' for %s events exporter", key, name)
+		}
+	}
+
+	host, port, err := parseAddress(options[addressKey])
+	if err != nil {
+		return nil, err
+	}
+	bufferLimit, err := parseBufferLimit(options[bufferLimitKey])
+	if err != nil {
+		return nil, err
+	}
+	prefix := options[tagPrefixKey]
+	if prefix == "" {
+		prefix = defaultTagPrefix
+	}
+
+	logrus.Debugf("events exporter fluentd configured for host:%s, port:%d, tag prefix:%s.", host, port, prefix)
+	// see the fluentd logging driver for the retry settings
+	w, err := fluent.New(fluent.Config{FluentPort: port, FluentHost: host, RetryWait: 1000, MaxRetry: math.MaxInt32, BufferLimit: bufferLimit})
+	if err != nil {
+		// The events are buffered until the endpoint can be reached.
+		logrus.Warnf("fluentd events exporter cannot connect to %s:%d: %v", host, port, err)
+	}
+	return &exporter{prefix: prefix, writer: w}, nil
+}
+
+// Export forwards m with the tag named after its type, such as
+// docker.events.container, and the time of the event.
+func (x *exporter) Export(m eventtypes.Message) error {
+	record := map[string]interface{}{
+		"type":       m.Type,
+		"action":     m.Action,
+		"id":         m.Actor.ID,
+		"attributes": m.Actor.Attributes,
+		"time":       m.Time,
+		"timeNano":   m.TimeNano,
+	}
+	if m.Status != "" {
+		record["status"] = m.Status
+		record["from"] = m.From
+	}
+	if m.Actor.Attributes == nil {
+		record["attributes"] = map[string]string{}
+	}
+	// fluent-logger-golang buffers events from failures and disconnections,
+	// and these are transferred again automatically.
+	return x.writer.PostWithTime(x.prefix+"."+m.Type, time.Unix(0, m.TimeNano), record)
+}
+
+// Close closes the connection to the endpoint.
+func (x *exporter) Close() error {
+	return x.writer.Close()
+}
+
+func parseAddress(address string) (string, int, error) {
+	if address == "" {
+		return defaultHostName, defaultPort, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if !strings.Contains(err.Error(), "missing port in address") {
+			return "", 0, fmt.Errorf("invalid %s %s: %s", addressKey, address, err)
+		}
+		return address, defaultPort, nil
+	}
+
+	portnum, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid %s %s: %s", addressKey, address, err)
+	}
+	return host, portnum, nil
+}
+
+func parseBufferLimit(bufferLimit string) (int, error) {
+	if bufferLimit == "" {
+		return defaultBufferLimit, nil
+	}
+	limit, err := strconv.Atoi(bufferLimit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s: %s", bufferLimitKey, bufferLimit, err)
+	}
+	return limit, nil
+}
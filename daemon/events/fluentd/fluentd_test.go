@@ -0,0 +1,111 @@
+package fluentd
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		host    string
+		port    int
+	}{
+		{"", defaultHostName, defaultPort},
+		{"fluentd.example.com", "fluentd.example.com", defaultPort},
+		{"fluentd.example.com:24225", "fluentd.example.com", 24225},
+	}
+	for _, c := range cases {
+		host, port, err := parseAddress(c.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host != c.host || port != c.port {
+			t.Fatalf("Expected %s:%d for %q, got %s:%d", c.host, c.port, c.address, host, port)
+		}
+	}
+	if _, _, err := parseAddress("fluentd.example.com:port"); err == nil {
+		t.Fatal("Expected an error for an invalid port")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	if _, err := New(map[string]string{"fluentd-unknown": "x"}); err == nil {
+		t.Fatal("Expected an error for an unknown option")
+	}
+	if _, err := New(map[string]string{bufferLimitKey: "lots"}); err == nil {
+		t.Fatal("Expected an error for an invalid buffer limit")
+	}
+}
+
+func TestExport(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	messages := make(chan fluent.Message, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		// A single event fits in a read. The strings of the decoded message
+		// refer to buf, which is not reused.
+		buf := make([]byte, 64*1024)
+		n, err := c.Read(buf)
+		if err != nil {
+			return
+		}
+		var msg fluent.Message
+		if _, err := msg.UnmarshalMsg(buf[:n]); err != nil {
+			return
+		}
+		messages <- msg
+	}()
+
+	x, err := New(map[string]string{addressKey: l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	now := time.Now()
+	m := eventtypes.Message{
+		Message: engineevents.Message{
+			Type:     "container",
+			Action:   "start",
+			Actor:    engineevents.Actor{ID: "cont", Attributes: map[string]string{"image": "busybox"}},
+			Time:     now.Unix(),
+			TimeNano: now.UnixNano(),
+		},
+	}
+	if err := x.Export(m); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-messages:
+		if msg.Tag != "docker.events.container" {
+			t.Fatalf("Unexpected tag %q", msg.Tag)
+		}
+		if msg.Time != now.Unix() {
+			t.Fatalf("Expected the time of the event, got %v", msg.Time)
+		}
+		record := msg.Record.(map[string]interface{})
+		if fmt.Sprintf("%s", record["action"]) != "start" || fmt.Sprintf("%s", record["id"]) != "cont" {
+			t.Fatalf("Unexpected record %v", record)
+		}
+		if attrs := record["attributes"].(map[string]interface{}); fmt.Sprintf("%s", attrs["image"]) != "busybox" {
+			t.Fatalf("Unexpected attributes %v", attrs)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the event")
+	}
+}
@@ -0,0 +1,34 @@
+// +build gofuzz
+
+package events
+
+import (
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+// Fuzz is the entry point of go-fuzz for the events filters. It parses
+// data as the filters parameter of the events API, and matches an event
+// with the filters if they are valid.
+func Fuzz(data []byte) int {
+	args, err := filters.FromParam(string(data))
+	if err != nil {
+		return 0
+	}
+	ef, err := ParseFilter(args)
+	if err != nil {
+		return 0
+	}
+	ef.Include(events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "exec_start: sh",
+			Actor: eventtypes.Actor{
+				ID:         "cont",
+				Attributes: map[string]string{"name": "web", "image": "busybox:latest", "env": "prod"},
+			},
+		},
+	})
+	return 1
+}
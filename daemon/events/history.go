@@ -0,0 +1,115 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/engine-api/types/filters"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// HistoryOptions holds the parameters of a request for past events.
+type HistoryOptions struct {
+	// Since and SinceNano are the timestamp of the oldest event returned.
+	// Since is -1 to start at the oldest event in the journal.
+	Since, SinceNano int64
+	// Until and UntilNano are the timestamp of the newest event
+	// returned. Until is -1 to not set an upper bound.
+	Until, UntilNano int64
+	// Filter selects the returned events, all events are returned when
+	// it is nil.
+	Filter *Filter
+	// Limit is the maximum number of events returned, it defaults to
+	// 100 and cannot be more than 1000.
+	Limit int
+	// Cursor is the position returned with the previous page, it is
+	// empty to get the first page.
+	Cursor string
+	// Owner is the tenant requesting the events when the event stream is
+	// scoped per tenant, only the events of the objects it owns are
+	// returned.
+	Owner string
+	// ACL restricts the returned events, if any.
+	ACL *ACL
+	// Snapshot is the ID of the imported snapshot the events are read
+	// from, they are read from the journal when it is empty.
+	Snapshot string
+}
+
+// History returns a page of the events stored in the journal, from the
+// oldest to the newest, and the cursor of the next page if any.
+func (e *Events) History(opts HistoryOptions) (eventtypes.History, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		return eventtypes.History{}, fmt.Errorf("bad parameter: limit cannot be more than %d", maxHistoryLimit)
+	}
+	var offset int64
+	if opts.Cursor != "" {
+		var err error
+		offset, err = strconv.ParseInt(opts.Cursor, 10, 64)
+		if err != nil || offset < 0 {
+			return eventtypes.History{}, fmt.Errorf("bad parameter: invalid cursor %q", opts.Cursor)
+		}
+	}
+	ef := opts.Filter
+	if ef == nil {
+		ef = NewFilter(filters.NewArgs())
+	}
+
+	e.mu.Lock()
+	journal := e.journal
+	e.mu.Unlock()
+	if journal == nil {
+		return eventtypes.History{}, derr.ErrorCodeNoEventsJournal
+	}
+	if opts.Snapshot != "" {
+		var err error
+		if journal, err = snapshotJournal(journal, opts.Snapshot); err != nil {
+			return eventtypes.History{}, err
+		}
+		defer journal.Close()
+	}
+	size := journal.Size()
+	if offset > size {
+		return eventtypes.History{}, fmt.Errorf("bad parameter: invalid cursor %q", opts.Cursor)
+	}
+
+	sinceTime := time.Unix(opts.Since, opts.SinceNano).UnixNano()
+	history := eventtypes.History{Events: []eventtypes.Message{}}
+	err := journal.WalkFrom(offset, size, func(ev eventtypes.Message, next int64) bool {
+		if opts.Until != -1 && after(ev, opts.Until, opts.UntilNano) {
+			return false
+		}
+		if opts.Since != -1 && ev.TimeNano < sinceTime {
+			return true
+		}
+		if opts.Owner != "" && !ownedBy(ev, opts.Owner) {
+			return true
+		}
+		if opts.ACL != nil && !opts.ACL.Include(ev) {
+			return true
+		}
+		if ef.filter.Len() > 0 && !ef.Include(ev) {
+			return true
+		}
+		history.Events = append(history.Events, ev)
+		if len(history.Events) < limit {
+			return true
+		}
+		if next < size {
+			history.Cursor = strconv.FormatInt(next, 10)
+		}
+		return false
+	})
+	return history, err
+}
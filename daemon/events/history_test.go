@@ -0,0 +1,77 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+func TestHistoryPagination(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetJournal(j)
+	defer e.Close()
+
+	for i := 0; i < 5; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, events.Actor{ID: "cont"})
+		e.Log("create", events.VolumeEventType, events.Actor{ID: "vol"})
+	}
+
+	args := filters.NewArgs()
+	args.Add("type", events.ContainerEventType)
+	opts := HistoryOptions{Since: -1, Until: -1, Filter: NewFilter(args), Limit: 2}
+
+	var actions []string
+	for pages := 1; ; pages++ {
+		history, err := e.History(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range history.Events {
+			actions = append(actions, ev.Action)
+		}
+		if history.Cursor == "" {
+			if pages != 3 {
+				t.Fatalf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		opts.Cursor = history.Cursor
+	}
+	if len(actions) != 5 {
+		t.Fatalf("Expected 5 events, got %v", actions)
+	}
+	for i, action := range actions {
+		if expected := fmt.Sprintf("action_%d", i); action != expected {
+			t.Fatalf("Event %d is %s, must be %s", i, action, expected)
+		}
+	}
+
+	if _, err := e.History(HistoryOptions{Since: -1, Until: -1, Cursor: "foo"}); err == nil {
+		t.Fatal("Expected an error for an invalid cursor")
+	}
+	if _, err := e.History(HistoryOptions{Since: -1, Until: -1, Limit: maxHistoryLimit + 1}); err == nil {
+		t.Fatal("Expected an error for a limit too large")
+	}
+}
+
+func TestHistoryWithoutJournal(t *testing.T) {
+	e := New(0)
+	if _, err := e.History(HistoryOptions{Since: -1, Until: -1}); err != derr.ErrorCodeNoEventsJournal {
+		t.Fatalf("Expected ErrorCodeNoEventsJournal, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"golang.org/x/net/context"
+)
+
+const (
+	// HookLabelPrefix prefixes the labels of the containers naming the
+	// hook run for an action of the container, as in
+	// events.hook.die=notify.sh.
+	HookLabelPrefix = "events.hook."
+	// hooksClient identifies the subscriber of the hooks.
+	hooksClient = "hooks"
+	// maxRunningHooks is the number of hooks running at once, after which
+	// the hooks of the following events are dropped.
+	maxRunningHooks = 16
+	// hookPath is the PATH of the hooks, which do not inherit the
+	// environment of the daemon.
+	hookPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// LabelHooks runs the hooks named by the labels of the containers when
+// their events are generated. Only the executables of the directory of the
+// hooks are run, as an unprivileged user, in their own process group,
+// with a clean environment and a timeout.
+type LabelHooks struct {
+	dir      string
+	uid, gid int
+	events   *Events
+	l        <-chan eventtypes.Message
+	running  chan struct{}
+	wg       sync.WaitGroup
+	done     chan struct{}
+}
+
+// NewLabelHooks returns the hooks of the containers, found in dir and run
+// as user. The hooks are not run until they are started.
+func NewLabelHooks(dir, user string) (*LabelHooks, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("invalid events hooks directory %q: path must be absolute", dir)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events hooks directory: %v", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("invalid events hooks directory %q: not a directory", dir)
+	}
+	uid, gid, err := lookupHookUser(user)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events hooks user %q: %v", user, err)
+	}
+	return &LabelHooks{
+		dir:     filepath.Clean(dir),
+		uid:     uid,
+		gid:     gid,
+		running: make(chan struct{}, maxRunningHooks),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Start subscribes the hooks to e and runs them in the background.
+func (h *LabelHooks) Start(e *Events) {
+	h.events = e
+	_, h.l = e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Filter: NewFilterFromMap(map[string][]string{"type": {eventtypes.ContainerEventType}}),
+		Policy: DropOldest,
+		Client: hooksClient,
+	})
+	go h.run()
+}
+
+// Stop unsubscribes the hooks and waits for the hooks running.
+func (h *LabelHooks) Stop() {
+	h.events.Evict(h.l)
+	<-h.done
+	h.wg.Wait()
+}
+
+func (h *LabelHooks) run() {
+	defer close(h.done)
+	for ev := range h.l {
+		action := ev.Action
+		if i := strings.Index(action, ":"); i > 0 {
+			// exec_start: sh -c ... and health_status: healthy
+			action = action[:i]
+		}
+		hook := ev.Actor.Attributes[HookLabelPrefix+action]
+		if hook == "" {
+			continue
+		}
+		path, err := h.resolve(hook)
+		if err != nil {
+			logrus.Errorf("Error running hook of container %s for %s: %v", ev.Actor.ID, ev.Action, err)
+			continue
+		}
+		select {
+		case h.running <- struct{}{}:
+		default:
+			logrus.Warnf("Too many hooks running, dropping hook %s of container %s for %s", path, ev.Actor.ID, ev.Action)
+			continue
+		}
+		h.wg.Add(1)
+		go func(path string, ev eventtypes.Message) {
+			defer func() {
+				<-h.running
+				h.wg.Done()
+			}()
+			cmd := exec.Command(path)
+			cmd.Dir = h.dir
+			cmd.Env = []string{"PATH=" + hookPath}
+			sandboxHook(cmd, h.uid, h.gid)
+			if err := runHook(cmd, ev); err != nil {
+				logrus.Errorf("Error running hook of container %s for %s: %v", ev.Actor.ID, ev.Action, err)
+			}
+		}(path, ev)
+	}
+}
+
+// resolve returns the path of the hook named by a label, relative to the
+// directory of the hooks or absolute. It is an error for the hook not to
+// be in that directory.
+func (h *LabelHooks) resolve(hook string) (string, error) {
+	path := hook
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.dir, path)
+	}
+	path = filepath.Clean(path)
+	rel, err := filepath.Rel(h.dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("hook %q is not in the hooks directory %s", hook, h.dir)
+	}
+	return path, nil
+}
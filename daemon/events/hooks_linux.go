@@ -0,0 +1,36 @@
+package events
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/opencontainers/runc/libcontainer/user"
+)
+
+// lookupHookUser returns the IDs of the user running the hooks.
+func lookupHookUser(name string) (int, int, error) {
+	u, err := user.LookupUser(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	return u.Uid, u.Gid, nil
+}
+
+// sandboxHook runs cmd as the user uid, without supplementary groups, in
+// its own process group, and kills it if the daemon dies.
+func sandboxHook(cmd *exec.Cmd, uid, gid int) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}},
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+}
+
+// killHook kills the command of a hook, and the processes of its group.
+func killHook(cmd *exec.Cmd) {
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		return
+	}
+	cmd.Process.Kill()
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+	"github.com/opencontainers/runc/libcontainer/user"
+)
+
+func TestLabelHooks(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	out := filepath.Join(tmp, "out")
+	if err := os.Mkdir(out, 0777); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$DOCKER_EVENT_ACTION $DOCKER_EVENT_ACTOR_ID $HOME\" > " + filepath.Join(out, "die") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(tmp, "notify.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	u, err := user.LookupUid(os.Getuid())
+	if err != nil {
+		t.Skip(err)
+	}
+
+	h, err := NewLabelHooks(tmp, u.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	h.Start(e)
+	e.Log("die", events.ContainerEventType, events.Actor{
+		ID:         "web",
+		Attributes: map[string]string{HookLabelPrefix + "die": filepath.Join(tmp, "notify.sh")},
+	})
+	e.Log("start", events.ContainerEventType, events.Actor{
+		ID:         "db",
+		Attributes: map[string]string{HookLabelPrefix + "start": "../notify.sh"},
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	var data []byte
+	for {
+		if data, err = ioutil.ReadFile(filepath.Join(out, "die")); err == nil && len(data) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for the hook to run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Stop()
+	// The hooks do not inherit the environment of the daemon.
+	if s := strings.TrimSpace(string(data)); s != "die web" {
+		t.Fatalf("Unexpected output of the hook %q", s)
+	}
+}
+
+func TestLabelHooksResolve(t *testing.T) {
+	h := &LabelHooks{dir: "/etc/docker/hooks"}
+	for hook, expected := range map[string]string{
+		"notify.sh":                   "/etc/docker/hooks/notify.sh",
+		"/etc/docker/hooks/notify.sh": "/etc/docker/hooks/notify.sh",
+		"alerts/../notify.sh":         "/etc/docker/hooks/notify.sh",
+		"../notify.sh":                "",
+		"/usr/bin/notify.sh":          "",
+		"/etc/docker/hooks":           "",
+		"/etc/docker/hooks-other/x":   "",
+	} {
+		path, err := h.resolve(hook)
+		if path != expected || (expected == "") != (err != nil) {
+			t.Fatalf("Unexpected path %q for %q: %v", path, hook, err)
+		}
+	}
+}
+
+func TestNewLabelHooksInvalid(t *testing.T) {
+	if _, err := NewLabelHooks("hooks", "nobody"); err == nil {
+		t.Fatal("Expected an error for a relative directory")
+	}
+	if _, err := NewLabelHooks("/nonexistent/hooks", "nobody"); err == nil {
+		t.Fatal("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,21 @@
+// +build !linux
+
+package events
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errHooksNotSupported = errors.New("the hooks of the containers are only supported on linux")
+
+func lookupHookUser(name string) (int, int, error) {
+	return 0, 0, errHooksNotSupported
+}
+
+func sandboxHook(cmd *exec.Cmd, uid, gid int) {
+}
+
+func killHook(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
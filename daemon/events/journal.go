@@ -0,0 +1,281 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/go-units"
+)
+
+const journalFileName = "events.log"
+
+// bufferPool holds the buffers the journal entries are encoded in.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// Journal is an append-only log of events stored on disk, one JSON
+// encoded message per line. It allows the daemon to serve events
+// that are no longer kept in memory, including events generated
+// before the last restart.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+	// idx is the index of the events, it is built by the first search.
+	idx *journalIndex
+	// readers is held while the journal is read, so that a compaction
+	// does not replace the file under them.
+	readers sync.RWMutex
+	// compactMu serializes the compactions and the trims.
+	compactMu sync.Mutex
+	closed    bool
+	// maxSize is the size beyond which the oldest events are dropped, 0
+	// when the journal is not limited, and trimming is true while they
+	// are dropped in the background.
+	maxSize  int64
+	trimming bool
+}
+
+// NewJournal opens, or creates, the events journal inside root.
+func NewJournal(root string) (*Journal, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, journalFileName)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	size, err := terminateEntry(path, f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Journal{
+		path: path,
+		f:    f,
+		size: size,
+	}, nil
+}
+
+// terminateEntry ends the entry partially written at the end of the
+// journal of size bytes, when the daemon stopped while writing it, so that
+// the entries appended to the journal are not appended to it. It returns
+// the new size of the journal.
+func terminateEntry(path string, f *os.File, size int64) (int64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	r, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	last := make([]byte, 1)
+	if _, err := r.ReadAt(last, size-1); err != nil {
+		return 0, err
+	}
+	if last[0] == '\n' {
+		return size, nil
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		return 0, err
+	}
+	return size + 1, nil
+}
+
+// Write appends the event to the journal.
+func (j *Journal) Write(m eventtypes.Message) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buf)
+	buf.Reset()
+	// Encode terminates the entry with a newline.
+	if err := json.NewEncoder(buf).Encode(m); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	offset := j.size
+	n, err := j.f.Write(buf.Bytes())
+	j.size += int64(n)
+	if err == nil && j.idx != nil {
+		j.idx.add(m, offset, j.size)
+	}
+	max := j.maxSize
+	trim := max > 0 && j.size > max && !j.trimming
+	if trim {
+		j.trimming = true
+	}
+	j.mu.Unlock()
+
+	if trim {
+		go j.trim(max)
+	}
+	return err
+}
+
+// SetMaxSize limits the journal to max bytes: once it grows beyond max,
+// its oldest events are dropped in the background, see Trim. The journal
+// is not limited when max is not positive.
+func (j *Journal) SetMaxSize(max int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.maxSize = max
+}
+
+// Trim drops the oldest events of the journal when it is larger than max
+// bytes, so that the events kept take at most three quarters of max and
+// the journal is not trimmed again at the next write. It returns the
+// number of bytes the journal shrank by. As with Compact, the journal is
+// rewritten, and the cursors of the history returned before a trim are no
+// longer valid.
+func (j *Journal) Trim(max int64) (int64, error) {
+	j.compactMu.Lock()
+	defer j.compactMu.Unlock()
+
+	size := j.Size()
+	if max <= 0 || size <= max {
+		return 0, nil
+	}
+	// The events kept are the ones starting in the last three quarters
+	// of max bytes.
+	from := size - max/4*3
+	cut := size
+	err := j.WalkFrom(0, size, func(m eventtypes.Message, next int64) bool {
+		if next >= from {
+			cut = next
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.OpenFile(j.path+".trim", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(j.path)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(cut, 0); err == nil {
+		_, err = io.CopyN(tmp, f, size-cut)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return j.replace(tmp, f, size)
+}
+
+// trim trims the journal to max bytes, see Trim, and logs the result.
+func (j *Journal) trim(max int64) {
+	reclaimed, err := j.Trim(max)
+	j.mu.Lock()
+	j.trimming = false
+	j.mu.Unlock()
+	if err != nil {
+		logrus.Errorf("Error trimming events journal: %v", err)
+		return
+	}
+	if reclaimed > 0 {
+		logrus.Infof("Trimmed events journal to its maximum size of %s, %s of the oldest events dropped", units.HumanSize(float64(max)), units.HumanSize(float64(reclaimed)))
+	}
+}
+
+// Size returns the number of bytes written to the journal.
+func (j *Journal) Size() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.size
+}
+
+// Walk calls fn for every event stored in the first limit bytes of the
+// journal, from the oldest to the newest. It stops walking when fn
+// returns false. A partially written last entry is ignored.
+func (j *Journal) Walk(limit int64, fn func(eventtypes.Message) bool) error {
+	return j.WalkFrom(0, limit, func(m eventtypes.Message, next int64) bool {
+		return fn(m)
+	})
+}
+
+// WalkFrom is like Walk, but starts walking at the entry found at offset
+// bytes in the journal. It also passes to fn the offset of the entry
+// that follows the event. The entries that cannot be decoded are logged
+// and skipped, and a partially written last entry is ignored.
+func (j *Journal) WalkFrom(offset, limit int64, fn func(m eventtypes.Message, next int64) bool) error {
+	j.readers.RLock()
+	defer j.readers.RUnlock()
+	f, err := os.Open(j.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if offset >= limit {
+		return nil
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(io.LimitReader(f, limit-offset))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// the end of the journal, or a partially written entry
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start := offset
+		offset += int64(len(line))
+		var m eventtypes.Message
+		if err := json.Unmarshal(line, &m); err != nil {
+			logrus.Errorf("Skipping corrupted entry of the events journal %s at offset %d: %v", j.path, start, err)
+			continue
+		}
+		if !fn(m, offset) {
+			return nil
+		}
+	}
+}
+
+// LastSequence returns the sequence number of the last event of the
+// journal, or 0 if the journal is empty.
+func (j *Journal) LastSequence() (uint64, error) {
+	var last uint64
+	err := j.Walk(j.Size(), func(m eventtypes.Message) bool {
+		last = m.Sequence
+		return true
+	})
+	return last, err
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.closed = true
+	return j.f.Close()
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"sort"
+	"sync"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// indexEntry locates an event in the journal.
+type indexEntry struct {
+	offset   int64
+	timeNano int64
+}
+
+// journalIndex indexes the events of the journal by time, type and actor,
+// so that searches only read the events that can match.
+type journalIndex struct {
+	mu sync.RWMutex
+	// size is the number of bytes of the journal indexed.
+	size int64
+	// entries are all the events, in the order of the journal, which is
+	// the order of their time.
+	entries []indexEntry
+	// types and actors are the positions in entries of the events of
+	// each type and of each actor.
+	types  map[string][]int
+	actors map[string][]int
+}
+
+func newJournalIndex() *journalIndex {
+	return &journalIndex{
+		types:  make(map[string][]int),
+		actors: make(map[string][]int),
+	}
+}
+
+// add indexes the event m, found at offset in the journal, whose entry
+// ends at next.
+func (idx *journalIndex) add(m eventtypes.Message, offset, next int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	i := len(idx.entries)
+	idx.entries = append(idx.entries, indexEntry{offset: offset, timeNano: m.TimeNano})
+	idx.types[m.Type] = append(idx.types[m.Type], i)
+	if m.Actor.ID != "" {
+		idx.actors[m.Actor.ID] = append(idx.actors[m.Actor.ID], i)
+	}
+	idx.size = next
+}
+
+// lookup returns the offsets in the journal, from the oldest, of the
+// events at the offset from or after it, logged between sinceNano and
+// untilNano, which are 0 when not set. When eventType or actor are set,
+// only the offsets of the events of that type and of that actor are
+// returned.
+func (idx *journalIndex) lookup(from, sinceNano, untilNano int64, eventType, actor string) []int64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	lo := sort.Search(len(idx.entries), func(i int) bool {
+		e := idx.entries[i]
+		return e.offset >= from && e.timeNano >= sinceNano
+	})
+	hi := len(idx.entries)
+	if untilNano != 0 {
+		hi = sort.Search(len(idx.entries), func(i int) bool {
+			return idx.entries[i].timeNano > untilNano
+		})
+	}
+	if lo >= hi {
+		return nil
+	}
+
+	var postings []int
+	if eventType != "" {
+		postings = idx.types[eventType]
+		if postings == nil {
+			return nil
+		}
+	}
+	if actor != "" {
+		p := idx.actors[actor]
+		if p == nil {
+			return nil
+		}
+		if postings == nil || len(p) < len(postings) {
+			postings = p
+		}
+	}
+
+	var offsets []int64
+	if postings == nil {
+		for _, e := range idx.entries[lo:hi] {
+			offsets = append(offsets, e.offset)
+		}
+		return offsets
+	}
+	start := sort.SearchInts(postings, lo)
+	for _, i := range postings[start:] {
+		if i >= hi {
+			break
+		}
+		offsets = append(offsets, idx.entries[i].offset)
+	}
+	return offsets
+}
+
+// index returns the index of the journal, building it the first time.
+func (j *Journal) index() (*journalIndex, error) {
+	j.mu.Lock()
+	idx, size := j.idx, j.size
+	j.mu.Unlock()
+	if idx != nil {
+		return idx, nil
+	}
+
+	idx = newJournalIndex()
+	if err := j.indexFrom(idx, 0, size); err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.idx != nil {
+		return j.idx, nil
+	}
+	// The events written while the journal was being indexed are
+	// indexed with the lock held, the following ones by Write.
+	if err := j.indexFrom(idx, idx.size, j.size); err != nil {
+		return nil, err
+	}
+	j.idx = idx
+	return idx, nil
+}
+
+// readIndexed calls fn with the index of the journal, and prevents a
+// compaction from replacing the journal until fn returns, so that the
+// offsets of the index are the ones of the file fn reads.
+func (j *Journal) readIndexed(fn func(idx *journalIndex) error) error {
+	for {
+		idx, err := j.index()
+		if err != nil {
+			return err
+		}
+		j.readers.RLock()
+		j.mu.Lock()
+		current := j.idx == idx
+		j.mu.Unlock()
+		if current {
+			err := fn(idx)
+			j.readers.RUnlock()
+			return err
+		}
+		// The journal was compacted since idx was built.
+		j.readers.RUnlock()
+	}
+}
+
+// indexFrom adds to idx the events stored between the offsets from and
+// limit of the journal.
+func (j *Journal) indexFrom(idx *journalIndex, from, limit int64) error {
+	offset := from
+	return j.WalkFrom(from, limit, func(m eventtypes.Message, next int64) bool {
+		idx.add(m, offset, next)
+		offset = next
+		return true
+	})
+}
@@ -0,0 +1,251 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestJournalSubscribeOlderThanBuffer(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(4)
+	e.SetJournal(j)
+
+	for i := 0; i < 10; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+
+	buffered, l := e.SubscribeTopic(context.Background(), 0, 0, -1, 0, NewFilter(filters.NewArgs()))
+	e.Evict(l)
+	if len(buffered) != 10 {
+		t.Fatalf("Must be 10 events, got %d", len(buffered))
+	}
+	for i, ev := range buffered {
+		if expected := fmt.Sprintf("action_%d", i); ev.Action != expected {
+			t.Fatalf("Event %d is %s, must be %s", i, ev.Action, expected)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// events survive a restart of the events service
+	j, err = NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = New(4)
+	e.SetJournal(j)
+	defer e.Close()
+	e.Log("action_10", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+
+	args := filters.NewArgs()
+	args.Add("event", "action_3")
+	args.Add("event", "action_10")
+	buffered, l = e.SubscribeTopic(context.Background(), 0, 0, -1, 0, NewFilter(args))
+	e.Evict(l)
+	if len(buffered) != 2 {
+		t.Fatalf("Must be 2 events, got %d", len(buffered))
+	}
+	if buffered[0].Action != "action_3" || buffered[1].Action != "action_10" {
+		t.Fatalf("Unexpected events %v", buffered)
+	}
+	// sequence numbers continue after the restart
+	if buffered[0].Sequence != 4 || buffered[1].Sequence != 11 {
+		t.Fatalf("Unexpected sequence numbers %d and %d", buffered[0].Sequence, buffered[1].Sequence)
+	}
+}
+
+func TestJournalSubscribeResumeAfter(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(4)
+	e.SetJournal(j)
+	defer e.Close()
+
+	for i := 0; i < 10; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+
+	buffered, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{Since: -1, Until: -1, ResumeAfter: 3})
+	defer e.Evict(l)
+	if len(buffered) != 7 {
+		t.Fatalf("Must be 7 events, got %d", len(buffered))
+	}
+	for i, ev := range buffered {
+		if expected := uint64(i + 4); ev.Sequence != expected {
+			t.Fatalf("Event %d has sequence number %d, must be %d", i, ev.Sequence, expected)
+		}
+	}
+
+	e.Log("action_10", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	select {
+	case ev := <-l:
+		if seq := ev.Sequence; seq != 11 {
+			t.Fatalf("Expected live event 11, got %d", seq)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the live event")
+	}
+}
+
+func TestJournalCorruptedEntry(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 4; i++ {
+		if err := j.Write(events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "cont"}, TimeNano: int64(i)}, Sequence: uint64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			// an entry in the middle of the journal is corrupted
+			if _, err := j.f.Write([]byte("{\"Type\":\"contai\x00\n")); err != nil {
+				t.Fatal(err)
+			}
+			j.size += 17
+		}
+	}
+	// a partially written last entry, which is terminated when the
+	// journal is opened again
+	if _, err := j.f.Write([]byte(`{"Type":"container","Action":"st`)); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	j, err = NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs := walkJournal(t, j)
+	if len(evs) != 4 || evs[1].Sequence != 2 || evs[2].Sequence != 3 {
+		t.Fatalf("Expected the events around the corrupted entry, got %+v", evs)
+	}
+	if last, err := j.LastSequence(); err != nil || last != 4 {
+		t.Fatalf("Expected the last sequence 4, got %d, %v", last, err)
+	}
+
+	// The sequence numbers continue after the corrupted entry.
+	e := New(0)
+	e.SetJournal(j)
+	e.Log("stop", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Flush()
+	if evs := walkJournal(t, j); evs[len(evs)-1].Sequence != 5 {
+		t.Fatalf("Expected the sequence 5 after the restart, got %+v", evs[len(evs)-1])
+	}
+
+	// The compaction summarizes the events around the corrupted entry,
+	// which it keeps.
+	c, err := j.Compact(3)
+	if err != nil || c.Summaries != 1 || c.Compacted != 2 {
+		t.Fatalf("Unexpected compaction %+v, %v", c, err)
+	}
+	if evs := walkJournal(t, j); len(evs) != 4 || evs[0].Action != compactedAction || evs[0].Sequence != 2 {
+		t.Fatalf("Unexpected events after the compaction %+v", evs)
+	}
+	b, err := ioutil.ReadFile(j.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "contai\x00") {
+		t.Fatal("Expected the corrupted entry kept by the compaction")
+	}
+	e.Close()
+}
+
+func TestJournalTrim(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	for i := 1; i <= 100; i++ {
+		if err := j.Write(events.Message{Message: eventtypes.Message{Type: events.VolumeEventType, Action: "create", Actor: eventtypes.Actor{ID: fmt.Sprintf("vol%d", i)}, TimeNano: int64(i)}, Sequence: uint64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := j.Size()
+	if reclaimed, err := j.Trim(size); err != nil || reclaimed != 0 {
+		t.Fatalf("Expected nothing trimmed under the maximum size, got %d, %v", reclaimed, err)
+	}
+
+	// The newest events are kept, in three quarters of the maximum size.
+	max := size / 2
+	reclaimed, err := j.Trim(max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Size() > max/4*3 || reclaimed != size-j.Size() {
+		t.Fatalf("Expected the journal trimmed to %d bytes, got %d, %d reclaimed", max/4*3, j.Size(), reclaimed)
+	}
+	evs := walkJournal(t, j)
+	if len(evs) == 0 || evs[len(evs)-1].Sequence != 100 {
+		t.Fatalf("Expected the newest events kept, got %+v", evs)
+	}
+	for i, ev := range evs {
+		if ev.Sequence != evs[0].Sequence+uint64(i) {
+			t.Fatalf("Expected the events kept in order, got %d after %d", ev.Sequence, evs[0].Sequence)
+		}
+	}
+
+	// The journal is trimmed in the background once it grows beyond its
+	// maximum size.
+	max = j.Size() + 1
+	j.SetMaxSize(max)
+	if err := j.Write(events.Message{Message: eventtypes.Message{Type: events.VolumeEventType, Action: "destroy", Actor: eventtypes.Actor{ID: "vol1"}}, Sequence: 101}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j.mu.Lock()
+		trimming, size := j.trimming, j.size
+		j.mu.Unlock()
+		if !trimming && size <= max {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the journal trimmed in the background, got %d bytes", size)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if evs := walkJournal(t, j); evs[len(evs)-1].Sequence != 101 {
+		t.Fatalf("Expected the last event kept, got %+v", evs[len(evs)-1])
+	}
+}
@@ -0,0 +1,127 @@
+// Command loadgen measures the publish latency and the drop rate of the
+// events service, with concurrent publishers logging events and
+// subscribers receiving them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/daemon/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <flags>\n", os.Args[0])
+	flag.PrintDefaults()
+	os.Exit(1)
+}
+
+func main() {
+	publishers := flag.Int("publishers", 4, "Number of concurrent publishers")
+	subscribers := flag.Int("subscribers", 16, "Number of subscribers")
+	count := flag.Int("events", 10000, "Number of events logged by each publisher")
+	policy := flag.String("policy", "block", "Delivery policy of the subscribers: block, drop-newest, drop-oldest or disconnect")
+	timeout := flag.Duration("timeout", 0, "Delivery timeout of the subscribers, 100ms when 0")
+	delay := flag.Duration("delay", 0, "Time each subscriber takes to handle an event")
+	history := flag.Int("history", 0, "Number of past events kept in memory, 64 when 0")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 0 || *publishers <= 0 || *count <= 0 || *subscribers < 0 {
+		usage()
+	}
+	p, err := events.ParsePolicy(*policy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	e := events.New(*history)
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		received uint64
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < *subscribers; i++ {
+		_, l := e.SubscribeWithOptions(ctx, events.SubscribeOptions{
+			Since:   -1,
+			Until:   -1,
+			Policy:  p,
+			Timeout: *timeout,
+			Client:  fmt.Sprintf("loadgen-%d", i),
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range l {
+				atomic.AddUint64(&received, 1)
+				if *delay > 0 {
+					time.Sleep(*delay)
+				}
+			}
+		}()
+	}
+
+	latencies := make([][]time.Duration, *publishers)
+	var pwg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < *publishers; i++ {
+		pwg.Add(1)
+		go func(i int) {
+			defer pwg.Done()
+			actor := eventtypes.Actor{
+				ID:         fmt.Sprintf("loadgen-%d", i),
+				Attributes: map[string]string{"name": fmt.Sprintf("loadgen-%d", i)},
+			}
+			l := make([]time.Duration, 0, *count)
+			for j := 0; j < *count; j++ {
+				action := "start"
+				if j%2 == 1 {
+					action = "die"
+				}
+				t := time.Now()
+				e.Log(action, eventtypes.ContainerEventType, actor)
+				l = append(l, time.Since(t))
+			}
+			latencies[i] = l
+		}(i)
+	}
+	pwg.Wait()
+	elapsed := time.Since(start)
+
+	metrics := e.Metrics()
+	cancel()
+	wg.Wait()
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	sort.Sort(durations(all))
+
+	fmt.Printf("Published: %d events in %v (%.0f events/s)\n", metrics.Published, elapsed, float64(metrics.Published)/elapsed.Seconds())
+	fmt.Printf("Publish latency: p50 %v, p99 %v, max %v\n", percentile(all, 50), percentile(all, 99), all[len(all)-1])
+	if *subscribers > 0 {
+		expected := metrics.Published * uint64(*subscribers)
+		fmt.Printf("Received: %d of %d events\n", atomic.LoadUint64(&received), expected)
+		fmt.Printf("Dropped: %d events (%.2f %%)\n", metrics.Dropped, 100.0*float64(metrics.Dropped)/float64(expected))
+	}
+}
+
+// percentile returns the pth percentile of the sorted durations d.
+func percentile(d []time.Duration, p int) time.Duration {
+	return d[(len(d)-1)*p/100]
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"unsafe"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// messageSize is the memory held by an event besides its strings and
+// the entries of its attributes.
+var messageSize = int64(unsafe.Sizeof(eventtypes.Message{}))
+
+// attributeOverhead estimates the memory held by an entry of a map of
+// attributes besides its key and value: the headers of the strings and
+// the share of the bucket of the map.
+const attributeOverhead = 48
+
+// eventMemory returns an estimate of the memory held by the event m.
+func eventMemory(m eventtypes.Message) int64 {
+	n := messageSize + int64(len(m.Status)+len(m.ID)+len(m.From)+len(m.Type)+len(m.Action)+len(m.Actor.ID)+len(m.Signature))
+	for k, v := range m.Actor.Attributes {
+		n += int64(len(k)+len(v)) + attributeOverhead
+	}
+	return n
+}
+
+// SetMemoryLimit makes the events service discard the oldest events kept
+// in memory when they hold more than max bytes, even when there are fewer
+// of them than the buffer size or they are retained by age. The events
+// are not limited by memory when max is not positive.
+func (e *Events) SetMemoryLimit(max int64) {
+	e.mu.Lock()
+	e.recent.setMemoryLimit(max)
+	e.mu.Unlock()
+}
+
+// Memory returns the memory held by the events kept in memory and by the
+// events queued for the subscribers. The events queued are shared with
+// the ones kept in memory until they are discarded, their memory is
+// estimated from the average memory of the events kept.
+func (e *Events) Memory() eventtypes.Memory {
+	e.mu.Lock()
+	buffered, count, limit := e.recent.memory(), len(e.recent.events()), e.recent.maxBytes
+	e.mu.Unlock()
+
+	var queued int
+	for _, s := range e.pub.stats() {
+		queued += s.Queued
+	}
+	m := eventtypes.Memory{
+		Buffered:    buffered,
+		BufferLimit: limit,
+	}
+	if count > 0 {
+		m.Queued = buffered / int64(count) * int64(queued)
+	}
+	return m
+}
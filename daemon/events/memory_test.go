@@ -0,0 +1,83 @@
+package events
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestRingMemory(t *testing.T) {
+	r := newRing(10)
+	small := events.Message{Message: eventtypes.Message{Action: "start"}, Sequence: 1}
+	large := events.Message{
+		Message: eventtypes.Message{
+			Action: "start",
+			Actor: eventtypes.Actor{
+				Attributes: map[string]string{"annotation": strings.Repeat("x", 4096)},
+			},
+		},
+		Sequence: 2,
+	}
+	if eventMemory(large)-eventMemory(small) < 4096 {
+		t.Fatalf("Expected the attributes accounted, got %d and %d", eventMemory(small), eventMemory(large))
+	}
+
+	r.add(small)
+	r.add(large)
+	if m := r.memory(); m != eventMemory(small)+eventMemory(large) {
+		t.Fatalf("Expected the memory of 2 events, got %d", m)
+	}
+
+	// The oldest events are discarded beyond the memory limit, even when
+	// there are fewer than the buffer size.
+	r.setMemoryLimit(3 * eventMemory(small))
+	if evs := r.events(); len(evs) != 1 || evs[0].Sequence != 2 {
+		t.Fatalf("Expected the last event only, got %v", evs)
+	}
+	if m := r.memory(); m != eventMemory(large) {
+		t.Fatalf("Expected the memory of the last event, got %d", m)
+	}
+	for i := uint64(3); i <= 100; i++ {
+		r.add(events.Message{Message: eventtypes.Message{Action: "start"}, Sequence: i})
+	}
+	if evs := r.events(); len(evs) != 3 || evs[0].Sequence != 98 {
+		t.Fatalf("Expected the last 3 events, got %v", evs)
+	}
+	if m := r.memory(); m != 3*eventMemory(small) {
+		t.Fatalf("Expected the memory of 3 events, got %d", m)
+	}
+
+	r.setMemoryLimit(0)
+	for i := uint64(101); i <= 120; i++ {
+		r.add(events.Message{Message: eventtypes.Message{Action: "start"}, Sequence: i})
+	}
+	if evs := r.events(); len(evs) != 10 {
+		t.Fatalf("Expected the buffer size kept without limit, got %d events", len(evs))
+	}
+	if m := r.memory(); m != 10*eventMemory(small) {
+		t.Fatalf("Expected the memory of 10 events, got %d", m)
+	}
+}
+
+func TestEventsMemory(t *testing.T) {
+	e := New(0)
+	e.SetMemoryLimit(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, l := e.Subscribe(ctx)
+	for i := 0; i < 4; i++ {
+		e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "1"})
+	}
+	size := eventMemory(<-l)
+	m := e.Memory()
+	if m.BufferLimit != 1<<20 || m.Buffered != 4*size {
+		t.Fatalf("Unexpected memory %+v", m)
+	}
+	// 3 events are still queued for the subscriber.
+	if m.Queued != 3*m.Buffered/4 {
+		t.Fatalf("Expected the memory of 3 queued events, got %+v", m)
+	}
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"sync/atomic"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// SubscriberStats holds the delivery statistics of a subscriber.
+type SubscriberStats struct {
+	// ID identifies the subscriber for the lifetime of the daemon.
+	ID uint64
+	// Dropped is the number of events not delivered to the subscriber.
+	Dropped uint64
+	// Queued is the number of events waiting to be received by the
+	// subscriber.
+	Queued int
+}
+
+// Metrics holds the delivery statistics of the events service.
+type Metrics struct {
+	// Published is the number of events logged since the daemon started.
+	Published uint64
+	// Dropped is the number of events not delivered to subscribers,
+	// including the subscribers that are gone.
+	Dropped uint64
+	// Subscribers holds the statistics of every current subscriber.
+	Subscribers []SubscriberStats
+	// Buffered is the number of past events kept in memory.
+	Buffered int
+	// BufferSize is the maximum number of past events kept in memory.
+	BufferSize int
+	// Memory is the memory held by the events kept and queued.
+	Memory eventtypes.Memory
+	// PayloadHits and PayloadMisses are the number of encodings of the
+	// events sent found in the payload cache and the number of events
+	// encoded.
+	PayloadHits, PayloadMisses uint64
+	// Dispatching is the number of events logged waiting to be published
+	// to the subscribers.
+	Dispatching int
+	// DispatchDropped is the number of verbose events not published
+	// because too many events were waiting.
+	DispatchDropped uint64
+}
+
+// Metrics returns the current delivery statistics of the events service.
+func (e *Events) Metrics() Metrics {
+	e.mu.Lock()
+	buffered, size := len(e.recent.events()), e.recent.limit()
+	e.mu.Unlock()
+
+	hits, misses := e.payloads.Stats()
+	e.logMu.Lock()
+	var (
+		dispatching     int
+		dispatchDropped uint64
+	)
+	if e.dispatcher != nil {
+		dispatching = e.dispatcher.queued()
+		dispatchDropped = atomic.LoadUint64(&e.dispatcher.dropped)
+	}
+	e.logMu.Unlock()
+	return Metrics{
+		Published:       atomic.LoadUint64(&e.pub.published),
+		Dropped:         atomic.LoadUint64(&e.pub.dropped),
+		Subscribers:     e.pub.stats(),
+		Buffered:        buffered,
+		BufferSize:      size,
+		Memory:          e.Memory(),
+		PayloadHits:     hits,
+		PayloadMisses:   misses,
+		Dispatching:     dispatching,
+		DispatchDropped: dispatchDropped,
+	}
+}
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+// Stage is a stage of the pipeline the events go through before they are
+// published to the subscribers.
+type Stage int
+
+const (
+	// EnrichStage adds attributes to the events.
+	EnrichStage Stage = iota
+	// FilterStage drops the events that must not be published.
+	FilterStage
+	// RateLimitStage drops or delays the events logged too often.
+	RateLimitStage
+)
+
+// Event is an event going through the pipeline of the events service.
+type Event struct {
+	Time   time.Time
+	Action string
+	Type   string
+	Actor  eventtypes.Actor
+	// Origin is the API request that caused the event, if any.
+	Origin *Origin
+	// copied is true once the attributes of the actor are owned by the
+	// pipeline, and can be modified.
+	copied bool
+}
+
+// SetAttribute sets an attribute of the actor of the event, without
+// modifying the attributes given by the caller of Log.
+func (ev *Event) SetAttribute(key, value string) {
+	ev.attributes()[key] = value
+}
+
+// attributes returns the attributes of the actor of the event, copied the
+// first time so that they can be modified.
+func (ev *Event) attributes() map[string]string {
+	if !ev.copied {
+		attributes := make(map[string]string, len(ev.Actor.Attributes)+8)
+		for k, v := range ev.Actor.Attributes {
+			attributes[k] = v
+		}
+		ev.Actor.Attributes = attributes
+		ev.copied = true
+	}
+	return ev.Actor.Attributes
+}
+
+// Middleware processes an event in the pipeline of the events service, it
+// returns false to drop the event. The middlewares of the enrich stage are
+// called concurrently for the events logged by different goroutines, the
+// ones of the other stages are called with the events service locked, in
+// the order the events are logged. A middleware must not log events.
+type Middleware func(ev *Event) bool
+
+// middleware is a middleware registered in the pipeline.
+type middleware struct {
+	name  string
+	stage Stage
+	fn    Middleware
+}
+
+// byStage sorts middlewares by stage, keeping the order they were
+// registered in within a stage.
+type byStage []middleware
+
+func (m byStage) Len() int           { return len(m) }
+func (m byStage) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byStage) Less(i, j int) bool { return m[i].stage < m[j].stage }
+
+// builtinMiddlewares returns the middlewares of the features of the events
+// service, which do nothing until they are configured.
+func (e *Events) builtinMiddlewares() []middleware {
+	return []middleware{
+		{name: "origin", stage: EnrichStage, fn: enrichOrigin},
+		{name: "node", stage: EnrichStage, fn: e.enrichNode},
+		{name: "owner", stage: EnrichStage, fn: e.enrichOwner},
+		{name: "plugins", stage: EnrichStage, fn: e.annotate},
+		{name: "redact", stage: FilterStage, fn: e.redact},
+		{name: "dedup", stage: FilterStage, fn: e.filterDuplicates},
+		{name: "ratelimit", stage: RateLimitStage, fn: e.rateLimit},
+	}
+}
+
+// Use registers the middleware m under name at the given stage of the
+// pipeline, after the middlewares already registered at that stage.
+func (e *Events) Use(name string, stage Stage, m Middleware) error {
+	e.pipelineMu.Lock()
+	defer e.pipelineMu.Unlock()
+	for _, mw := range e.middlewares {
+		if mw.name == name {
+			return fmt.Errorf("events middleware %s is already registered", name)
+		}
+	}
+	e.middlewares = append(e.middlewares, middleware{name: name, stage: stage, fn: m})
+	sort.Stable(byStage(e.middlewares))
+	return nil
+}
+
+// RemoveMiddleware removes the middleware registered under name from the
+// pipeline, if any.
+func (e *Events) RemoveMiddleware(name string) {
+	e.pipelineMu.Lock()
+	defer e.pipelineMu.Unlock()
+	for i, mw := range e.middlewares {
+		if mw.name == name {
+			e.middlewares = append(e.middlewares[:i:i], e.middlewares[i+1:]...)
+			return
+		}
+	}
+}
+
+// enrich runs the event through the enrich stage of the pipeline, and
+// returns false if it was dropped. The caller must hold pipelineMu read
+// locked.
+func (e *Events) enrich(ev *Event) bool {
+	for _, mw := range e.middlewares {
+		if mw.stage != EnrichStage {
+			break
+		}
+		if !mw.fn(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// process runs the event through the stages of the pipeline that follow
+// the enrich stage, and returns false if it was dropped. The caller must
+// hold pipelineMu read locked and logMu.
+func (e *Events) process(ev *Event) bool {
+	for _, mw := range e.middlewares {
+		if mw.stage == EnrichStage {
+			continue
+		}
+		if !mw.fn(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// enrichOrigin adds the origin of the event to its attributes.
+func enrichOrigin(ev *Event) bool {
+	if ev.Origin != nil {
+		ev.Origin.setAttributes(ev.attributes())
+	}
+	return true
+}
+
+// enrichNode adds the identity of the daemon to the attributes of the
+// event.
+func (e *Events) enrichNode(ev *Event) bool {
+	if e.node != nil {
+		e.node.setAttributes(ev.attributes())
+	}
+	return true
+}
+
+// enrichOwner adds the owner of the object of the event to its
+// attributes, when the event stream is scoped per tenant.
+func (e *Events) enrichOwner(ev *Event) bool {
+	if e.owners != nil {
+		if owner := e.owners.assign(ev.Action, ev.Type, ev.Actor, ev.Origin); owner != "" {
+			ev.SetAttribute(ownerAttribute, owner)
+		}
+	}
+	return true
+}
+
+// filterDuplicates drops the events that repeat the previous event of
+// their object.
+func (e *Events) filterDuplicates(ev *Event) bool {
+	return e.dedup == nil || !e.dedup.duplicate(ev.Time, ev.Action, ev.Type, ev.Actor)
+}
+
+// rateLimit drops the events beyond the rate limit of their object.
+func (e *Events) rateLimit(ev *Event) bool {
+	return e.limiter == nil || e.limiter.allow(ev.Time, ev.Action, ev.Type, ev.Actor)
+}
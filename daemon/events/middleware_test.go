@@ -0,0 +1,50 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestUseMiddleware(t *testing.T) {
+	e := New(0)
+	var order []string
+	// Registered first, the filter still runs after the enrichment.
+	if err := e.Use("drop-exec", FilterStage, func(ev *Event) bool {
+		order = append(order, "filter")
+		return ev.Action != "exec_create"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Use("team", EnrichStage, func(ev *Event) bool {
+		order = append(order, "enrich")
+		ev.SetAttribute("team", "infra")
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Use("team", EnrichStage, func(*Event) bool { return true }); err == nil {
+		t.Fatal("Expected an error registering a middleware twice")
+	}
+
+	attributes := map[string]string{"name": "test"}
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont", Attributes: attributes})
+	e.Log("exec_create", events.ContainerEventType, events.Actor{ID: "cont"})
+
+	recent := e.recent.events()
+	if len(recent) != 1 || recent[0].Actor.Attributes["team"] != "infra" {
+		t.Fatalf("Expected only the enriched start event, got %v", recent)
+	}
+	if _, ok := attributes["team"]; ok {
+		t.Fatal("Expected the attributes of the caller not to be modified")
+	}
+	if len(order) != 4 || order[0] != "enrich" || order[1] != "filter" {
+		t.Fatalf("Unexpected middleware order %v", order)
+	}
+
+	e.RemoveMiddleware("drop-exec")
+	e.Log("exec_create", events.ContainerEventType, events.Actor{ID: "cont"})
+	if recent := e.recent.events(); len(recent) != 2 {
+		t.Fatalf("Expected 2 events once the filter is removed, got %d", len(recent))
+	}
+}
@@ -0,0 +1,32 @@
+package events
+
+// Node identifies the daemon that generates the events.
+type Node struct {
+	// ID is the ID of the daemon.
+	ID string
+	// Name is the hostname of the host running the daemon.
+	Name string
+	// Labels are the labels of the daemon.
+	Labels map[string]string
+}
+
+// setAttributes adds the node to the attributes of an event.
+func (n *Node) setAttributes(attributes map[string]string) {
+	if n.ID != "" {
+		attributes["node.id"] = n.ID
+	}
+	if n.Name != "" {
+		attributes["node.name"] = n.Name
+	}
+	for k, v := range n.Labels {
+		attributes["node.label."+k] = v
+	}
+}
+
+// SetNode makes every event logged carry the identity of the daemon in the
+// node.id, node.name and node.label.<key> attributes of its actor.
+func (e *Events) SetNode(node Node) {
+	e.pipelineMu.Lock()
+	e.node = &node
+	e.pipelineMu.Unlock()
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"strings"
+	"sync"
+
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+// Origin identifies the API request that caused events.
+type Origin struct {
+	// Client is the common name of the TLS certificate of the API
+	// client, or its remote address.
+	Client string
+	// User is the user authenticated by the authorization plugins.
+	User string
+	// RequestID identifies the API request.
+	RequestID string
+	// CorrelationID is shared by the API requests sent by a client for
+	// a single operation, such as the create, attach and start requests
+	// of docker run.
+	CorrelationID string
+	// Tenant is the identity of the tenant that sent the request, which
+	// owns the objects it creates when the event stream is scoped per
+	// tenant.
+	Tenant string
+}
+
+// setAttributes adds the origin to the attributes of an event.
+func (o *Origin) setAttributes(attributes map[string]string) {
+	if o.Client != "" {
+		attributes["origin.client"] = o.Client
+	}
+	if o.User != "" {
+		attributes["origin.user"] = o.User
+	}
+	if o.RequestID != "" {
+		attributes["origin.requestID"] = o.RequestID
+	}
+	if o.CorrelationID != "" {
+		attributes["origin.correlationID"] = o.CorrelationID
+	}
+}
+
+// originScope attributes the events of an object to an origin while an
+// API request targeting the object is served.
+type originScope struct {
+	eventType string
+	// target is the ID, ID prefix or name of the object, it is empty
+	// until the object is created for the requests creating one.
+	target string
+	origin Origin
+}
+
+// match returns true if the scope applies to the event.
+func (s *originScope) match(action, eventType string, actor eventtypes.Actor) bool {
+	if s.eventType != eventType {
+		return false
+	}
+	if s.target == "" {
+		return action == "create"
+	}
+	return strings.HasPrefix(actor.ID, s.target) || actor.Attributes["name"] == strings.TrimPrefix(s.target, "/")
+}
+
+// originScopes holds the active origin scopes.
+type originScopes struct {
+	mu     sync.Mutex
+	scopes map[*originScope]struct{}
+}
+
+// lookup returns the origin of an event, or nil when no scope or scopes
+// with different origins apply to it.
+func (s *originScopes) lookup(action, eventType string, actor eventtypes.Actor) *Origin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *originScope
+	for scope := range s.scopes {
+		if !scope.match(action, eventType, actor) {
+			continue
+		}
+		if found != nil && found.origin != scope.origin {
+			return nil
+		}
+		found = scope
+	}
+	if found == nil {
+		return nil
+	}
+	if found.target == "" {
+		// The object of the request is created, the following events
+		// of the request are about it.
+		found.target = actor.ID
+	}
+	origin := found.origin
+	return &origin
+}
+
+// Attribute makes the events of type eventType about the object target,
+// an ID, ID prefix or name, carry origin in their attributes, until done
+// is called. When target is empty, it applies to the object created
+// next. Events to which several origins apply carry none of them.
+func (e *Events) Attribute(eventType, target string, origin Origin) (done func()) {
+	scope := &originScope{
+		eventType: eventType,
+		target:    target,
+		origin:    origin,
+	}
+	e.origins.mu.Lock()
+	if e.origins.scopes == nil {
+		e.origins.scopes = make(map[*originScope]struct{})
+	}
+	e.origins.scopes[scope] = struct{}{}
+	e.origins.mu.Unlock()
+
+	return func() {
+		e.origins.mu.Lock()
+		delete(e.origins.scopes, scope)
+		e.origins.mu.Unlock()
+	}
+}
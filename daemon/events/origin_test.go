@@ -0,0 +1,56 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestAttribute(t *testing.T) {
+	e := New(0)
+	alice := Origin{Client: "alice", RequestID: "1", CorrelationID: "run"}
+	done := e.Attribute(events.ContainerEventType, "web", alice)
+
+	e.Log("stop", events.ContainerEventType, events.Actor{ID: "abc", Attributes: map[string]string{"name": "web"}})
+	e.Log("stop", events.ContainerEventType, events.Actor{ID: "def", Attributes: map[string]string{"name": "db"}})
+	e.Log("destroy", events.VolumeEventType, events.Actor{ID: "web"})
+	done()
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "abc", Attributes: map[string]string{"name": "web"}})
+
+	if a := e.recent.events()[0].Actor.Attributes; a["origin.client"] != "alice" || a["origin.requestID"] != "1" || a["origin.correlationID"] != "run" || a["name"] != "web" {
+		t.Fatalf("Expected event attributed to alice, got %v", a)
+	}
+	for _, ev := range e.recent.events()[1:] {
+		if _, ok := ev.Actor.Attributes["origin.client"]; ok {
+			t.Fatalf("Expected event not to be attributed, got %v", ev)
+		}
+	}
+}
+
+func TestAttributeCreate(t *testing.T) {
+	e := New(0)
+	done := e.Attribute(events.ContainerEventType, "", Origin{Client: "alice"})
+	defer done()
+
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "abc"})
+	e.Log("create", events.ContainerEventType, events.Actor{ID: "def"})
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "def"})
+	e.Log("create", events.ContainerEventType, events.Actor{ID: "ghi"})
+
+	for i, client := range []string{"", "alice", "alice", ""} {
+		if got := e.recent.events()[i].Actor.Attributes["origin.client"]; got != client {
+			t.Fatalf("Expected event %d to have client %q, got %q", i, client, got)
+		}
+	}
+}
+
+func TestAttributeAmbiguous(t *testing.T) {
+	e := New(0)
+	defer e.Attribute(events.ContainerEventType, "abc", Origin{Client: "alice"})()
+	defer e.Attribute(events.ContainerEventType, "web", Origin{Client: "bob"})()
+
+	e.Log("kill", events.ContainerEventType, events.Actor{ID: "abcdef", Attributes: map[string]string{"name": "web"}})
+	if _, ok := e.recent.events()[0].Actor.Attributes["origin.client"]; ok {
+		t.Fatalf("Expected ambiguous event not to be attributed, got %v", e.recent.events()[0])
+	}
+}
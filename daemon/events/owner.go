@@ -0,0 +1,152 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+)
+
+// OwnerLabel is the label setting the owner of a container or an image.
+// The daemon sets it on the containers created by a tenant, and refuses
+// the containers created with it, when the event stream is scoped per
+// tenant.
+const OwnerLabel = "com.docker.owner"
+
+// ownerAttribute is the attribute of the events of the objects that have an
+// owner, when the event stream is scoped per tenant.
+const ownerAttribute = "owner"
+
+// ownedTypes are the types of the events of objects that can have an owner,
+// the tenants only see the events of these types.
+var ownedTypes = map[string]bool{
+	eventtypes.ContainerEventType: true,
+	eventtypes.ImageEventType:     true,
+	eventtypes.VolumeEventType:    true,
+}
+
+// owners remembers the owners of the objects that do not carry the owner
+// label, such as the volumes, which have no labels. They are owned by the
+// tenant whose request created them, and saved in the file at path, so
+// that they keep their owner across the restarts of the daemon.
+type owners struct {
+	mu   sync.Mutex
+	path string
+	ids  map[string]string
+}
+
+// loadOwners returns the owners saved in the file at path, which are only
+// kept in memory when path is empty.
+func loadOwners(path string) (*owners, error) {
+	o := &owners{path: path, ids: make(map[string]string)}
+	if path == "" {
+		return o, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &o.ids); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// save writes the owners to their file, replacing it, it must be called
+// with o.mu held.
+func (o *owners) save() {
+	if o.path == "" {
+		return
+	}
+	b, err := json.Marshal(o.ids)
+	if err == nil {
+		err = writeFile(o.path, b)
+	}
+	if err != nil {
+		logrus.Errorf("Error saving the owners of the objects to %s: %v", o.path, err)
+	}
+}
+
+// writeFile writes b to a temporary file and renames it to path, so that
+// the file at path is never partially written.
+func writeFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// assign returns the owner of the object of an event, or an empty string
+// when it has none.
+func (o *owners) assign(action, eventType string, actor engineevents.Actor, origin *Origin) string {
+	if !ownedTypes[eventType] {
+		return ""
+	}
+	key := eventType + " " + actor.ID
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	owner := actor.Attributes[OwnerLabel]
+	switch {
+	case owner != "":
+	case action == "create" && origin != nil:
+		owner = origin.Tenant
+		if owner != "" {
+			o.ids[key] = owner
+			o.save()
+		}
+	default:
+		owner = o.ids[key]
+	}
+	if action == "destroy" || action == "delete" {
+		if _, ok := o.ids[key]; ok {
+			delete(o.ids, key)
+			o.save()
+		}
+	}
+	return owner
+}
+
+// ownedBy returns true if the event is the event of an object owned by the
+// tenant.
+func ownedBy(ev eventtypes.Message, tenant string) bool {
+	return ownedTypes[ev.Type] && ev.Actor.Attributes[ownerAttribute] == tenant
+}
+
+// SetTenantScoping makes every event logged of an object that has an owner
+// carry it in its owner attribute, so that the subscriptions of a tenant
+// only return the events of the objects it owns. The owners of the objects
+// without the owner label are saved in the file at path, and loaded from
+// it, they are only kept in memory when path is empty.
+func (e *Events) SetTenantScoping(enabled bool, path string) error {
+	var o *owners
+	if enabled {
+		var err error
+		if o, err = loadOwners(path); err != nil {
+			return err
+		}
+	}
+	e.pipelineMu.Lock()
+	e.owners = o
+	e.pipelineMu.Unlock()
+	return nil
+}
+
+// TenantScoping returns true if the event stream is scoped per tenant.
+func (e *Events) TenantScoping() bool {
+	e.pipelineMu.RLock()
+	defer e.pipelineMu.RUnlock()
+	return e.owners != nil
+}
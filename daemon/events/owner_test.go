@@ -0,0 +1,97 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestTenantScoping(t *testing.T) {
+	e := New(0)
+	e.SetTenantScoping(true, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// alice creates a container, bob's image carries the owner label.
+	done := e.Attribute(events.ContainerEventType, "web", Origin{User: "alice", Tenant: "alice"})
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web"}})
+	done()
+	e.Log("pull", events.ImageEventType, eventtypes.Actor{ID: "i1", Attributes: map[string]string{OwnerLabel: "bob"}})
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web"}})
+	e.Log("start", events.DaemonEventType, eventtypes.Actor{ID: "daemon"})
+	e.Log("destroy", events.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web"}})
+	// The container is no longer owned once it has been destroyed.
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "c1"})
+
+	actions := func(tenant string) []string {
+		past, _ := e.SubscribeWithOptions(ctx, SubscribeOptions{Until: -1, Owner: tenant})
+		var got []string
+		for _, ev := range past {
+			got = append(got, ev.Type+" "+ev.Action)
+		}
+		return got
+	}
+	expected := map[string][]string{
+		"alice": {"container create", "container start", "container destroy"},
+		"bob":   {"image pull"},
+		"carol": nil,
+	}
+	for tenant, want := range expected {
+		got := actions(tenant)
+		if len(got) != len(want) {
+			t.Fatalf("Expected events %v for %s, got %v", want, tenant, got)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("Expected events %v for %s, got %v", want, tenant, got)
+			}
+		}
+	}
+	if all, _ := e.SubscribeWithOptions(ctx, SubscribeOptions{Until: -1}); len(all) != 6 {
+		t.Fatalf("Expected 6 events without tenant, got %d", len(all))
+	}
+}
+
+func TestTenantScopingRestart(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-owners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "events", "owners.json")
+
+	e := New(0)
+	if err := e.SetTenantScoping(true, path); err != nil {
+		t.Fatal(err)
+	}
+	done := e.Attribute(events.VolumeEventType, "", Origin{User: "alice", Tenant: "alice"})
+	e.Log("create", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	done()
+
+	// The volume, which has no labels, is still owned by alice after a
+	// restart, until it is destroyed.
+	e = New(0)
+	if err := e.SetTenantScoping(true, path); err != nil {
+		t.Fatal(err)
+	}
+	e.Log("mount", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	e.Log("destroy", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	past, _ := e.SubscribeWithOptions(context.Background(), SubscribeOptions{Until: -1, Owner: "alice"})
+	if len(past) != 2 || past[0].Action != "mount" || past[1].Action != "destroy" {
+		t.Fatalf("Expected the events of the volume of alice after the restart, got %+v", past)
+	}
+
+	e = New(0)
+	if err := e.SetTenantScoping(true, path); err != nil {
+		t.Fatal(err)
+	}
+	e.Log("create", events.VolumeEventType, eventtypes.Actor{ID: "data"})
+	if past, _ := e.SubscribeWithOptions(context.Background(), SubscribeOptions{Until: -1, Owner: "alice"}); len(past) != 0 {
+		t.Fatalf("Expected the volume destroyed no longer owned, got %+v", past)
+	}
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// maxPausedEvents is the largest number of events queued for a paused
+// subscriber, the following ones are dropped.
+const maxPausedEvents = 10000
+
+// pause holds the events published while the delivery to a subscriber is
+// paused, and while they are delivered after it resumes, so that they are
+// received in order.
+type pause struct {
+	mu       sync.Mutex
+	paused   bool
+	flushing bool
+	limit    int
+	pending  []eventtypes.Message
+}
+
+// hold queues ev if the delivery is paused or the queued events are still
+// being delivered. It returns false if ev can be sent right away, and
+// true if it was queued or dropped, in which case dropped is true.
+func (p *pause) hold(ev eventtypes.Message) (held, dropped bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused && !p.flushing {
+		return false, false
+	}
+	// The events published while the queued ones are delivered are
+	// queued too, up to the largest limit, to be received after them.
+	limit := p.limit
+	if !p.paused {
+		limit = maxPausedEvents
+	}
+	if len(p.pending) >= limit {
+		return true, true
+	}
+	p.pending = append(p.pending, ev)
+	return true, false
+}
+
+// queued returns the number of events held.
+func (p *pause) queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// holding returns true if the delivery is paused.
+func (p *pause) holding() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// findSubscriber returns the subscriber with the given ID, if it is a
+// subscriber of the tenant owner or owner is empty.
+func (e *Events) findSubscriber(id uint64, owner string) (*subscriber, error) {
+	e.pub.mu.RLock()
+	defer e.pub.mu.RUnlock()
+	for _, s := range e.pub.subscribers {
+		if s.id == id && (owner == "" || s.owner == owner) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no such events subscriber: %d", id)
+}
+
+// PauseSubscriber stops the delivery of events to the subscriber with the
+// given ID, among the subscribers of the tenant owner when it is set, until
+// it is resumed. Up to limit events published in the
+// meantime are queued, and delivered once it resumes, the others are
+// dropped. The limit defaults to, and cannot exceed, 10000 events.
+func (e *Events) PauseSubscriber(id uint64, limit int, owner string) error {
+	s, err := e.findSubscriber(id, owner)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 || limit > maxPausedEvents {
+		limit = maxPausedEvents
+	}
+	s.pause.mu.Lock()
+	s.pause.paused = true
+	s.pause.limit = limit
+	s.pause.mu.Unlock()
+	return nil
+}
+
+// ResumeSubscriber resumes the delivery of events to the subscriber with
+// the given ID, among the subscribers of the tenant owner when it is set,
+// starting with the events queued while it was paused.
+func (e *Events) ResumeSubscriber(id uint64, owner string) error {
+	s, err := e.findSubscriber(id, owner)
+	if err != nil {
+		return err
+	}
+	s.pause.mu.Lock()
+	defer s.pause.mu.Unlock()
+	if !s.pause.paused {
+		return nil
+	}
+	s.pause.paused = false
+	if len(s.pause.pending) > 0 && !s.pause.flushing {
+		s.pause.flushing = true
+		go e.pub.flush(s)
+	}
+	return nil
+}
+
+// flush delivers the events queued for the subscriber s while it was
+// paused, until none is left, s is paused again or s is evicted.
+func (p *publisher) flush(s *subscriber) {
+	for {
+		s.pause.mu.Lock()
+		if s.pause.paused || len(s.pause.pending) == 0 {
+			s.pause.flushing = false
+			if len(s.pause.pending) == 0 {
+				s.pause.pending = nil
+			}
+			s.pause.mu.Unlock()
+			return
+		}
+		ev := s.pause.pending[0]
+		s.pause.mu.Unlock()
+
+		// The channel of s is only closed by evict, which cannot happen
+		// while the publisher is read locked. The lock is released
+		// regularly for a subscriber that does not read its events, so
+		// that it can still be evicted.
+		p.mu.RLock()
+		select {
+		case <-s.evicted:
+			p.mu.RUnlock()
+			return
+		default:
+		}
+		t := time.NewTimer(publishTimeout)
+		sent := false
+		select {
+		case s.ch <- ev:
+			sent = true
+		case <-t.C:
+		}
+		t.Stop()
+		p.mu.RUnlock()
+
+		if sent {
+			s.pause.mu.Lock()
+			s.pause.pending = s.pause.pending[1:]
+			s.pause.mu.Unlock()
+		}
+	}
+}
+
+// drop counts an event not delivered to the subscriber s.
+func (p *publisher) drop(s *subscriber) {
+	atomic.AddUint64(&s.dropped, 1)
+	atomic.AddUint64(&p.dropped, 1)
+}
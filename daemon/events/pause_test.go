@@ -0,0 +1,84 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestPauseSubscriber(t *testing.T) {
+	e := New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+	id := e.Subscribers("")[0].ID
+
+	if err := e.PauseSubscriber(id, 3, ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	select {
+	case ev := <-l:
+		t.Fatalf("Unexpected event while paused: %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+	s := e.Subscribers("")[0]
+	if !s.Paused || s.Queued != 3 || s.Dropped != 2 {
+		t.Fatalf("Unexpected paused subscriber %+v", s)
+	}
+
+	if err := e.ResumeSubscriber(id, ""); err != nil {
+		t.Fatal(err)
+	}
+	e.Log("action_5", events.ContainerEventType, events.Actor{ID: "cont"})
+	for _, action := range []string{"action_0", "action_1", "action_2", "action_5"} {
+		select {
+		case ev := <-l:
+			if ev.Action != action {
+				t.Fatalf("Expected %s, got %s", action, ev.Action)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for %s", action)
+		}
+	}
+	if e.Subscribers("")[0].Paused {
+		t.Fatal("Expected the subscriber to be resumed")
+	}
+
+	if err := e.PauseSubscriber(id+1, 0, ""); err == nil {
+		t.Fatal("Expected an error pausing an unknown subscriber")
+	}
+}
+
+func TestPauseSubscriberEvict(t *testing.T) {
+	e := New(0)
+	_, l := e.Subscribe(context.Background())
+	id := e.Subscribers("")[0].ID
+
+	if err := e.PauseSubscriber(id, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	// The queued events cannot all be buffered in the channel, which is
+	// not read.
+	for i := 0; i < bufferSize+10; i++ {
+		e.Log("test", events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	if err := e.ResumeSubscriber(id, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.Evict(l)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout evicting a resumed subscriber")
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// payloadCacheSize is the number of encodings kept by the payload cache.
+const payloadCacheSize = 1024
+
+// payloadKey identifies the encoding of an event in a schema.
+type payloadKey struct {
+	sequence uint64
+	schema   SchemaVersion
+}
+
+// PayloadCache keeps the JSON encodings of the last events sent to the
+// subscribers, so that the subscribers receiving an event in the same
+// form share its encoding instead of encoding it again.
+type PayloadCache struct {
+	// hits and misses are accessed atomically, they are kept first to
+	// be 64-bit aligned on 32-bit platforms.
+	hits     uint64
+	misses   uint64
+	mu       sync.Mutex
+	payloads map[payloadKey][]byte
+	// keys holds the keys of the encodings in the order they were
+	// added, the oldest one is replaced at next.
+	keys []payloadKey
+	next int
+}
+
+// NewPayloadCache returns a cache keeping the encodings of the last size
+// events.
+func NewPayloadCache(size int) *PayloadCache {
+	return &PayloadCache{
+		payloads: make(map[payloadKey][]byte, size),
+		keys:     make([]payloadKey, 0, size),
+	}
+}
+
+// JSON returns the JSON encoding of the event m, in the form of schema,
+// followed by a newline. The events without a sequence number, which are
+// not logged events, are not cached. The encoding must not be modified.
+func (c *PayloadCache) JSON(m eventtypes.Message, schema SchemaVersion) ([]byte, error) {
+	key := payloadKey{sequence: m.Sequence, schema: schema}
+	if m.Sequence != 0 {
+		c.mu.Lock()
+		b, ok := c.payloads[key]
+		c.mu.Unlock()
+		if ok {
+			atomic.AddUint64(&c.hits, 1)
+			return b, nil
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, '\n')
+	if m.Sequence == 0 {
+		return b, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.payloads[key]; ok {
+		// Another subscriber encoded it in the meantime.
+		return cached, nil
+	}
+	if len(c.keys) < cap(c.keys) {
+		c.keys = append(c.keys, key)
+	} else {
+		delete(c.payloads, c.keys[c.next])
+		c.keys[c.next] = key
+		c.next = (c.next + 1) % len(c.keys)
+	}
+	c.payloads[key] = b
+	return b, nil
+}
+
+// Stats returns the number of encodings found in the cache, and the
+// number of events encoded.
+func (c *PayloadCache) Stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+// Payloads returns the cache of the encodings of the events sent to the
+// subscribers.
+func (e *Events) Payloads() *PayloadCache {
+	return e.payloads
+}
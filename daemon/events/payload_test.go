@@ -0,0 +1,52 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestPayloadCache(t *testing.T) {
+	c := NewPayloadCache(2)
+	m := events.Message{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "start", Actor: eventtypes.Actor{ID: "1"}}, Sequence: 1}
+
+	b, err := c.JSON(m, ActorSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	json.NewEncoder(&expected).Encode(m)
+	if !bytes.Equal(b, expected.Bytes()) {
+		t.Fatalf("Expected %q, got %q", expected.Bytes(), b)
+	}
+	// The subscribers receiving the event in the same form share its
+	// encoding, the ones receiving it in another form do not.
+	if again, _ := c.JSON(m, ActorSchema); &again[0] != &b[0] {
+		t.Fatal("Expected the encoding shared")
+	}
+	if legacy, _ := c.JSON(Translate(m, LegacySchema), LegacySchema); bytes.Equal(legacy, b) {
+		t.Fatalf("Expected another encoding for the legacy schema, got %q", legacy)
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 2 {
+		t.Fatalf("Expected 1 hit and 2 misses, got %d and %d", hits, misses)
+	}
+
+	// Only the last encodings are kept.
+	m.Sequence = 2
+	c.JSON(m, ActorSchema)
+	m.Sequence = 1
+	if again, _ := c.JSON(m, ActorSchema); &again[0] == &b[0] {
+		t.Fatal("Expected the oldest encoding discarded")
+	}
+
+	// The messages that are not logged events are not cached.
+	dropped := DroppedMessage(3)
+	c.JSON(dropped, ActorSchema)
+	c.JSON(dropped, ActorSchema)
+	if hits, misses := c.Stats(); hits != 1 || misses != 4 {
+		t.Fatalf("Expected 1 hit and 4 misses, got %d and %d", hits, misses)
+	}
+}
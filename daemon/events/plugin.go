@@ -0,0 +1,228 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/plugins"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+const (
+	// EventsApiAnnotate is the url for the annotation of events by plugins
+	EventsApiAnnotate = "EventsPlugin.Annotate"
+
+	// EventsApiImplements is the name of the interface all events plugins
+	// implement
+	EventsApiImplements = "events"
+)
+
+// AnnotateRequest holds the event sent to events plugins before it is
+// published.
+type AnnotateRequest struct {
+	Type   string
+	Action string
+	Actor  eventtypes.Actor
+}
+
+// AnnotateResponse holds the attributes that an events plugin adds to an
+// event.
+type AnnotateResponse struct {
+	// Attributes are added to the attributes of the actor of the event,
+	// except the ones it already has.
+	Attributes map[string]string `json:"Attributes,omitempty"`
+
+	// Err stores a message in case there's an error
+	Err string `json:"Err,omitempty"`
+}
+
+// Annotator adds attributes to the events before they are published.
+type Annotator interface {
+	// Name returns the registered plugin name
+	Name() string
+
+	// Annotate returns the attributes to add to an event.
+	Annotate(*AnnotateRequest) (*AnnotateResponse, error)
+}
+
+// NewAnnotatorPlugins constructs the events plugins based on plugin names.
+func NewAnnotatorPlugins(names []string) []Annotator {
+	annotators := []Annotator{}
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		annotators = append(annotators, &eventsPlugin{name: name})
+	}
+	return annotators
+}
+
+// SetAnnotators makes the events service send every event to annotators,
+// in order, before it is published, and add the attributes they return to
+// the event. The events are published without the attributes of the
+// annotators that fail or do not answer within a second. An annotator that
+// fails is not called again until its backoff expires.
+func (e *Events) SetAnnotators(annotators []Annotator) {
+	states := make([]*annotator, 0, len(annotators))
+	for _, a := range annotators {
+		states = append(states, &annotator{Annotator: a})
+	}
+	e.pipelineMu.Lock()
+	e.annotators = states
+	e.pipelineMu.Unlock()
+}
+
+// annotate adds to the event the attributes returned by the annotators. It
+// is the middleware of the plugins in the enrich stage, so that the
+// attributes of the annotators go through the rest of the pipeline.
+func (e *Events) annotate(ev *Event) bool {
+	for _, a := range e.annotators {
+		// the request outlives the event when the annotator times out
+		actor := eventtypes.Actor{ID: ev.Actor.ID, Attributes: make(map[string]string, len(ev.Actor.Attributes))}
+		for k, v := range ev.Actor.Attributes {
+			actor.Attributes[k] = v
+		}
+		req := &AnnotateRequest{Type: ev.Type, Action: ev.Action, Actor: actor}
+		res, err := a.call(req, time.Now())
+		if err == errAnnotatorBackoff {
+			continue
+		}
+		if err == nil && res.Err != "" {
+			err = errors.New(res.Err)
+		}
+		if err != nil {
+			logrus.Errorf("Error annotating %s event %s of %s with plugin %s: %v", ev.Type, ev.Action, ev.Actor.ID, a.Name(), err)
+			continue
+		}
+		for k, v := range res.Attributes {
+			if _, exists := ev.Actor.Attributes[k]; exists {
+				continue
+			}
+			ev.SetAttribute(k, v)
+		}
+	}
+	return true
+}
+
+// annotateTimeout is how long an annotator is waited for.
+var annotateTimeout = time.Second
+
+const (
+	// minAnnotatorBackoff and maxAnnotatorBackoff bound the time an
+	// annotator that failed is not called.
+	minAnnotatorBackoff = time.Second
+	maxAnnotatorBackoff = time.Minute
+)
+
+// errAnnotatorBackoff is returned for the events an annotator is not
+// called for, because it failed recently or a call is still in progress.
+var errAnnotatorBackoff = errors.New("annotator is backing off")
+
+// annotator calls an Annotator with a timeout, and backs off after it
+// fails, so that a missing or slow plugin does not delay every event.
+type annotator struct {
+	Annotator
+
+	mu sync.Mutex
+	// busy is true while a call is in progress, including a call that
+	// timed out.
+	busy    bool
+	backoff time.Duration
+	retryAt time.Time
+}
+
+type annotateResult struct {
+	res *AnnotateResponse
+	err error
+}
+
+// call sends req to the annotator, unless it is backing off, and waits
+// for its response for at most annotateTimeout.
+func (a *annotator) call(req *AnnotateRequest, now time.Time) (*AnnotateResponse, error) {
+	a.mu.Lock()
+	if a.busy || now.Before(a.retryAt) {
+		a.mu.Unlock()
+		return nil, errAnnotatorBackoff
+	}
+	a.busy = true
+	a.mu.Unlock()
+
+	done := make(chan annotateResult, 1)
+	go func() {
+		res, err := a.Annotate(req)
+		if err == nil && res == nil {
+			res = &AnnotateResponse{}
+		}
+		a.mu.Lock()
+		a.busy = false
+		if err != nil {
+			a.fail(time.Now())
+		} else {
+			a.backoff = 0
+		}
+		a.mu.Unlock()
+		done <- annotateResult{res, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-time.After(annotateTimeout):
+		a.mu.Lock()
+		a.fail(time.Now())
+		a.mu.Unlock()
+		return nil, fmt.Errorf("no response within %v", annotateTimeout)
+	}
+}
+
+// fail doubles the backoff of the annotator, the caller must hold mu.
+func (a *annotator) fail(now time.Time) {
+	a.backoff *= 2
+	if a.backoff < minAnnotatorBackoff {
+		a.backoff = minAnnotatorBackoff
+	}
+	if a.backoff > maxAnnotatorBackoff {
+		a.backoff = maxAnnotatorBackoff
+	}
+	a.retryAt = now.Add(a.backoff)
+}
+
+// eventsPlugin is an internal adapter to docker plugin system
+type eventsPlugin struct {
+	plugin *plugins.Plugin
+	name   string
+}
+
+func (p *eventsPlugin) Name() string {
+	return p.name
+}
+
+func (p *eventsPlugin) Annotate(req *AnnotateRequest) (*AnnotateResponse, error) {
+	if err := p.initPlugin(); err != nil {
+		return nil, err
+	}
+
+	res := &AnnotateResponse{}
+	if err := p.plugin.Client.Call(EventsApiAnnotate, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// initPlugin initialize the events plugin if needed
+func (p *eventsPlugin) initPlugin() error {
+	// Lazy loading of plugins
+	if p.plugin == nil {
+		var err error
+		p.plugin, err = plugins.Get(p.name, EventsApiImplements)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
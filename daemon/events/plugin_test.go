@@ -0,0 +1,137 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+type testAnnotator struct {
+	name       string
+	attributes map[string]string
+	err        error
+	block      chan struct{}
+	calls      int32
+}
+
+func (a *testAnnotator) Name() string {
+	return a.name
+}
+
+func (a *testAnnotator) Annotate(req *AnnotateRequest) (*AnnotateResponse, error) {
+	atomic.AddInt32(&a.calls, 1)
+	if a.block != nil {
+		<-a.block
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &AnnotateResponse{Attributes: a.attributes}, nil
+}
+
+func TestAnnotate(t *testing.T) {
+	e := New(0)
+	e.SetAnnotators([]Annotator{
+		&testAnnotator{name: "scanner", attributes: map[string]string{"scan": "clean", "name": "forged"}},
+		&testAnnotator{name: "broken", err: errors.New("unavailable")},
+		&testAnnotator{name: "policy", attributes: map[string]string{"verdict": "allow"}},
+	})
+
+	actor := events.Actor{ID: "cont", Attributes: map[string]string{"name": "web"}}
+	e.Log("start", events.ContainerEventType, actor)
+
+	expected := map[string]string{"name": "web", "scan": "clean", "verdict": "allow"}
+	if a := e.recent.events()[0].Actor.Attributes; !reflect.DeepEqual(a, expected) {
+		t.Fatalf("Expected attributes %v, got %v", expected, a)
+	}
+	if len(actor.Attributes) != 1 {
+		t.Fatalf("Expected attributes of the actor not to be modified, got %v", actor.Attributes)
+	}
+}
+
+func TestAnnotateRedacted(t *testing.T) {
+	r, err := NewRedactor([]RedactionRule{{Attributes: []string{"token"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetRedactor(r)
+	e.SetAnnotators([]Annotator{
+		&testAnnotator{name: "vault", attributes: map[string]string{"token": "s3cr3t", "scan": "clean"}},
+	})
+
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+
+	expected := map[string]string{"scan": "clean"}
+	if a := e.recent.events()[0].Actor.Attributes; !reflect.DeepEqual(a, expected) {
+		t.Fatalf("Expected attributes %v, got %v", expected, a)
+	}
+}
+
+func TestAnnotateBackoff(t *testing.T) {
+	defer func(timeout time.Duration) { annotateTimeout = timeout }(annotateTimeout)
+	annotateTimeout = 10 * time.Millisecond
+
+	slow := &testAnnotator{name: "slow", attributes: map[string]string{"scan": "clean"}, block: make(chan struct{})}
+	broken := &testAnnotator{name: "broken", err: errors.New("unavailable")}
+	e := New(0)
+	e.SetAnnotators([]Annotator{slow, broken})
+
+	for i := 0; i < 3; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	close(slow.block)
+
+	if len(e.recent.events()) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(e.recent.events()))
+	}
+	for _, ev := range e.recent.events() {
+		if len(ev.Actor.Attributes) != 0 {
+			t.Fatalf("Expected events without attributes, got %v", ev.Actor.Attributes)
+		}
+	}
+	if atomic.LoadInt32(&slow.calls) != 1 || atomic.LoadInt32(&broken.calls) != 1 {
+		t.Fatalf("Expected annotators to be called once, got %d and %d calls", slow.calls, broken.calls)
+	}
+}
+
+func TestAnnotatePlugin(t *testing.T) {
+	var recorded AnnotateRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+EventsApiAnnotate, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&recorded); err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(w).Encode(AnnotateResponse{Attributes: map[string]string{"scan": "clean"}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := plugins.NewClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &eventsPlugin{name: "scanner", plugin: &plugins.Plugin{Client: client}}
+
+	req := &AnnotateRequest{Type: events.ImageEventType, Action: "pull", Actor: events.Actor{ID: "busybox"}}
+	res, err := p.Annotate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recorded, *req) {
+		t.Fatalf("Expected request %v, got %v", *req, recorded)
+	}
+	if res.Attributes["scan"] != "clean" {
+		t.Fatalf("Unexpected response %v", res)
+	}
+}
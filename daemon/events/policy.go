@@ -0,0 +1,73 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+)
+
+// droppedEventType is the type of the messages that tell a subscriber
+// that some events were not delivered to it.
+const droppedEventType = "events"
+
+// Policy defines what the publisher does with an event when a subscriber
+// is not ready to receive it.
+type Policy int
+
+const (
+	// BlockWithDeadline waits up to the subscriber timeout for the subscriber
+	// to receive the event, and drops the event after that.
+	BlockWithDeadline Policy = iota
+	// DropNewest drops the event without waiting for the subscriber.
+	DropNewest
+	// DropOldest discards the oldest event queued for the subscriber to
+	// make room for the new one.
+	DropOldest
+	// Disconnect waits up to the subscriber timeout like BlockWithDeadline,
+	// and evicts the subscriber, closing its channel, if it does not
+	// receive the event.
+	Disconnect
+)
+
+var policies = map[string]Policy{
+	"block":       BlockWithDeadline,
+	"drop-newest": DropNewest,
+	"drop-oldest": DropOldest,
+	"disconnect":  Disconnect,
+}
+
+// ParsePolicy returns the delivery policy named by s. Valid names are
+// block, drop-newest, drop-oldest and disconnect. An empty name
+// returns the default policy, block.
+func ParsePolicy(s string) (Policy, error) {
+	if s == "" {
+		return BlockWithDeadline, nil
+	}
+	p, ok := policies[s]
+	if !ok {
+		return 0, fmt.Errorf("bad parameter: invalid events delivery policy %q, must be block, drop-newest, drop-oldest or disconnect", s)
+	}
+	return p, nil
+}
+
+// DroppedMessage returns the message that tells a subscriber that
+// count events were not delivered to it.
+func DroppedMessage(count uint64) eventtypes.Message {
+	now := time.Now().UTC()
+	return eventtypes.Message{
+		Message: engineevents.Message{
+			Type:   droppedEventType,
+			Action: "dropped",
+			Actor: engineevents.Actor{
+				Attributes: map[string]string{
+					"count": strconv.FormatUint(count, 10),
+				},
+			},
+			Time:     now.Unix(),
+			TimeNano: now.UnixNano(),
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"fmt"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// Priority is the importance of an event. Under backpressure, the
+// verbose events are dropped first, and subscribers can ask for the
+// events of a minimum priority only.
+type Priority int
+
+const (
+	// PriorityVerbose is the priority of the events that detail the
+	// activity of an object, such as the execs and attaches of a
+	// container.
+	PriorityVerbose Priority = iota
+	// PriorityNormal is the priority of most events.
+	PriorityNormal
+	// PriorityCritical is the priority of the events reporting that a
+	// container failed: died, ran out of memory or became unhealthy.
+	PriorityCritical
+)
+
+var priorities = map[string]Priority{
+	"verbose":  PriorityVerbose,
+	"normal":   PriorityNormal,
+	"critical": PriorityCritical,
+}
+
+// criticalEvents and verboseEvents are the classes of the events of
+// critical and verbose priority, the other events are of normal priority.
+var (
+	criticalEvents = []eventClass{
+		{eventType: eventtypes.ContainerEventType, action: "die"},
+		{eventType: eventtypes.ContainerEventType, action: "oom"},
+		{eventType: eventtypes.ContainerEventType, action: "health_status: unhealthy"},
+	}
+	verboseEvents = []eventClass{
+		{eventType: eventtypes.ContainerEventType, action: "exec_*"},
+		{eventType: eventtypes.ContainerEventType, action: "attach"},
+		{eventType: eventtypes.ContainerEventType, action: "detach"},
+		{eventType: eventtypes.ContainerEventType, action: "resize"},
+		{eventType: eventtypes.ContainerEventType, action: "top"},
+		{eventType: eventtypes.ContainerEventType, action: "archive-path"},
+		{eventType: eventtypes.ContainerEventType, action: "extract-to-dir"},
+		{eventType: eventtypes.ContainerEventType, action: "health_status: healthy"},
+		{eventType: eventtypes.NetworkEventType, action: "endpoint_*"},
+	}
+)
+
+// ParsePriority returns the priority named by s. Valid names are verbose,
+// normal and critical. An empty name returns the lowest priority,
+// verbose.
+func ParsePriority(s string) (Priority, error) {
+	if s == "" {
+		return PriorityVerbose, nil
+	}
+	p, ok := priorities[s]
+	if !ok {
+		return 0, fmt.Errorf("bad parameter: invalid events priority %q, must be verbose, normal or critical", s)
+	}
+	return p, nil
+}
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	for name, priority := range priorities {
+		if priority == p {
+			return name
+		}
+	}
+	return fmt.Sprintf("priority %d", int(p))
+}
+
+// priorityOf returns the priority of the event ev.
+func priorityOf(ev eventtypes.Message) Priority {
+	for _, c := range criticalEvents {
+		if c.match(ev.Type, ev.Action) {
+			return PriorityCritical
+		}
+	}
+	for _, c := range verboseEvents {
+		if c.match(ev.Type, ev.Action) {
+			return PriorityVerbose
+		}
+	}
+	return PriorityNormal
+}
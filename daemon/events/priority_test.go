@@ -0,0 +1,128 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestParsePriority(t *testing.T) {
+	for s, expected := range map[string]Priority{"": PriorityVerbose, "verbose": PriorityVerbose, "normal": PriorityNormal, "critical": PriorityCritical} {
+		p, err := ParsePriority(s)
+		if err != nil || p != expected {
+			t.Fatalf("Expected %v for %q, got %v, %v", expected, s, p, err)
+		}
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Fatal("Expected an error for an unknown priority")
+	}
+}
+
+func TestPriorityOf(t *testing.T) {
+	for _, c := range []struct {
+		eventType, action string
+		expected          Priority
+	}{
+		{events.ContainerEventType, "die", PriorityCritical},
+		{events.ContainerEventType, "oom", PriorityCritical},
+		{events.ContainerEventType, "health_status: unhealthy", PriorityCritical},
+		{events.ContainerEventType, "health_status: healthy", PriorityVerbose},
+		{events.ContainerEventType, "exec_start: ls /", PriorityVerbose},
+		{events.ContainerEventType, "attach", PriorityVerbose},
+		{events.NetworkEventType, "endpoint_join", PriorityVerbose},
+		{events.ContainerEventType, "start", PriorityNormal},
+		{events.ImageEventType, "die", PriorityNormal},
+	} {
+		if p := priorityOf(events.Message{Message: eventtypes.Message{Type: c.eventType, Action: c.action}}); p != c.expected {
+			t.Fatalf("Expected %v for %s %s, got %v", c.expected, c.eventType, c.action, p)
+		}
+	}
+}
+
+func TestSubscribePriority(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, l := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Priority: PriorityCritical})
+
+	for _, action := range []string{"start", "exec_start: ls /", "die", "health_status: unhealthy"} {
+		e.Log(action, events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	for _, expected := range []string{"die", "health_status: unhealthy"} {
+		select {
+		case ev := <-l:
+			if ev.Action != expected {
+				t.Fatalf("Expected the event %s, got %s", expected, ev.Action)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for the event %s", expected)
+		}
+	}
+	if s := e.Subscribers(""); len(s) != 1 || s[0].Priority != "critical" {
+		t.Fatalf("Expected the priority of the subscriber, got %+v", s)
+	}
+}
+
+func TestDispatchDropsVerbose(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, slow := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Timeout: 5 * time.Second})
+	_, critical := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Priority: PriorityCritical})
+
+	// The dispatcher is held by the subscriber, the events that follow
+	// wait in its queue.
+	for i := 0; i < bufferSize+1+verboseQueueLimit; i++ {
+		e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	e.Log("exec_start: ls /", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	if m := e.Metrics(); m.DispatchDropped != 1 {
+		t.Fatalf("Expected the verbose event dropped, got %+v", m)
+	}
+
+	// The verbose event dropped is not logged, the sequence numbers have
+	// no gap.
+	e.mu.Lock()
+	recent := e.recent.events()
+	e.mu.Unlock()
+	for _, ev := range recent {
+		if ev.Action == "exec_start: ls /" {
+			t.Fatalf("Expected the verbose event dropped not kept, got %+v", ev)
+		}
+	}
+	var last events.Message
+	for last.Action != "die" {
+		select {
+		case ev := <-slow:
+			if ev.Action != "start" && ev.Action != "die" {
+				t.Fatalf("Unexpected event %s", ev.Action)
+			}
+			if ev.Sequence != last.Sequence+1 {
+				t.Fatalf("Expected the event %d, got %d", last.Sequence+1, ev.Sequence)
+			}
+			last = ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for the die event")
+		}
+	}
+
+	// The verbose event dropped is reported to the subscribers that
+	// would have received it, and in the statistics.
+	e.Flush()
+	if stats, _ := e.pub.stat(slow); stats.Dropped != 1 {
+		t.Fatalf("Expected the verbose event dropped for the subscriber, got %+v", stats)
+	}
+	if stats, _ := e.pub.stat(critical); stats.Dropped != 0 {
+		t.Fatalf("Expected no event dropped for the critical subscriber, got %+v", stats)
+	}
+	if d := e.Dropped(slow); d != 1 {
+		t.Fatalf("Expected the drop reported, got %d", d)
+	}
+	if stats := e.Stats([]time.Duration{time.Minute}); stats[0].Shed != 1 || stats[0].Counts[events.ContainerEventType]["exec_start"] != 0 {
+		t.Fatalf("Expected the verbose event counted as shed, got %+v", stats[0])
+	}
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"golang.org/x/net/context"
+)
+
+// publisher broadcasts events to subscribers like pkg/pubsub, on
+// channels of event messages, and evicts the subscribers whose context
+// is done.
+type publisher struct {
+	// published and dropped are accessed atomically, they are kept first
+	// to be 64-bit aligned on 32-bit platforms.
+	published   uint64
+	dropped     uint64
+	mu          sync.RWMutex
+	buffer      int
+	timeout     time.Duration
+	subscribers map[<-chan eventtypes.Message]*subscriber
+	lastID      uint64
+}
+
+// newPublisher returns a publisher that creates the channels of the
+// subscribers with the given buffer size.
+func newPublisher(publishTimeout time.Duration, buffer int) *publisher {
+	return &publisher{
+		buffer:      buffer,
+		timeout:     publishTimeout,
+		subscribers: make(map[<-chan eventtypes.Message]*subscriber),
+	}
+}
+
+// len returns the number of subscribers.
+func (p *publisher) len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.subscribers)
+}
+
+// subscribe adds the subscriber s and returns its channel, which is
+// closed when it is evicted or ctx is done. If init is not nil, it is
+// called before s is added, while no event is being published.
+func (p *publisher) subscribe(ctx context.Context, s *subscriber, init func()) <-chan eventtypes.Message {
+	s.ch = make(chan eventtypes.Message, p.buffer)
+	s.evicted = make(chan struct{})
+	if s.timeout == 0 {
+		s.timeout = p.timeout
+	}
+	p.mu.Lock()
+	if init != nil {
+		init()
+	}
+	p.lastID++
+	s.id = p.lastID
+	p.subscribers[s.ch] = s
+	p.mu.Unlock()
+
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				p.evict(s.ch)
+			case <-s.evicted:
+			}
+		}()
+	}
+	return s.ch
+}
+
+// evict removes the subscriber of the channel l and closes l. Evicting a
+// subscriber that was already removed is a no-op.
+func (p *publisher) evict(l <-chan eventtypes.Message) {
+	p.mu.Lock()
+	if s, ok := p.subscribers[l]; ok {
+		delete(p.subscribers, l)
+		close(s.ch)
+		close(s.evicted)
+	}
+	p.mu.Unlock()
+}
+
+// stat returns the delivery statistics of the subscriber of the channel
+// l, and false if it was removed.
+func (p *publisher) stat(l <-chan eventtypes.Message) (SubscriberStats, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s, ok := p.subscribers[l]
+	if !ok {
+		return SubscriberStats{}, false
+	}
+	return s.stats(), true
+}
+
+// stats returns the delivery statistics of every subscriber.
+func (p *publisher) stats() []SubscriberStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stats := make([]SubscriberStats, 0, len(p.subscribers))
+	for _, s := range p.subscribers {
+		stats = append(stats, s.stats())
+	}
+	return stats
+}
+
+// publish sends ev to every subscriber whose topic it matches. The
+// subscribers ready to receive ev get it without waiting, only the ones
+// whose policy is to wait for them are waited for, concurrently.
+func (p *publisher) publish(ev eventtypes.Message) {
+	var (
+		wg        sync.WaitGroup
+		evictLock sync.Mutex
+		evicted   []<-chan eventtypes.Message
+	)
+	failed := func(s *subscriber) {
+		if s.policy == Disconnect {
+			evictLock.Lock()
+			evicted = append(evicted, s.ch)
+			evictLock.Unlock()
+		}
+	}
+
+	atomic.AddUint64(&p.published, 1)
+	p.mu.RLock()
+	for _, s := range p.subscribers {
+		if s.topic != nil && !s.topic(ev) {
+			continue
+		}
+		if held, dropped := s.pause.hold(ev); held {
+			if dropped {
+				p.drop(s)
+			}
+			continue
+		}
+		select {
+		case s.ch <- ev:
+			s.observe(true, 0)
+			continue
+		default:
+		}
+		if !s.waits() {
+			if !p.send(s, ev) {
+				failed(s)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(s *subscriber) {
+			defer wg.Done()
+			if !p.send(s, ev) {
+				failed(s)
+			}
+		}(s)
+	}
+	wg.Wait()
+	p.mu.RUnlock()
+
+	for _, l := range evicted {
+		p.evict(l)
+	}
+}
+
+// shed counts ev, a verbose event dropped instead of logged, as dropped for
+// every subscriber whose topic it matches.
+func (p *publisher) shed(ev eventtypes.Message) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, s := range p.subscribers {
+		if s.match == nil || s.match(ev) {
+			p.drop(s)
+		}
+	}
+}
+
+// send delivers ev to the subscriber s according to its policy, and
+// returns false when s was not able to receive it.
+func (p *publisher) send(s *subscriber, ev eventtypes.Message) bool {
+	var sent bool
+	switch {
+	case s.policy == DropOldest && cap(s.ch) > 0:
+		sent = sendDropOldest(s.ch, ev)
+	case s.waits():
+		start := time.Now()
+		t := time.NewTimer(s.currentTimeout())
+		select {
+		case s.ch <- ev:
+			sent = true
+		case <-t.C:
+		}
+		t.Stop()
+		s.observe(sent, time.Since(start))
+	default:
+		select {
+		case s.ch <- ev:
+			sent = true
+		default:
+		}
+	}
+
+	if !sent {
+		p.drop(s)
+	}
+	return sent
+}
+
+// sendDropOldest discards the oldest events queued in ch until ev fits in
+// it. It returns false if an event was discarded.
+func sendDropOldest(ch chan eventtypes.Message, ev eventtypes.Message) bool {
+	sent := true
+	for {
+		select {
+		case ch <- ev:
+			return sent
+		default:
+		}
+		select {
+		case <-ch:
+			sent = false
+		default:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func publishActions(p *publisher, count int) {
+	for i := 0; i < count; i++ {
+		p.publish(eventtypes.Message{Message: engineevents.Message{Action: string('a' + rune(i))}})
+	}
+}
+
+func TestPublisherDropOldest(t *testing.T) {
+	p := newPublisher(100*time.Millisecond, 2)
+	l := p.subscribe(context.Background(), &subscriber{policy: DropOldest}, nil)
+
+	publishActions(p, 5)
+	if s, _ := p.stat(l); s.Dropped != 3 {
+		t.Fatalf("Expected 3 dropped events, got %d", s.Dropped)
+	}
+	for _, expected := range []string{"d", "e"} {
+		if ev := <-l; ev.Action != expected {
+			t.Fatalf("Expected event %s, got %s", expected, ev.Action)
+		}
+	}
+}
+
+func TestPublisherDropNewest(t *testing.T) {
+	p := newPublisher(100*time.Millisecond, 2)
+	l := p.subscribe(context.Background(), &subscriber{policy: DropNewest}, nil)
+
+	start := time.Now()
+	publishActions(p, 5)
+	if time.Since(start) >= 100*time.Millisecond {
+		t.Fatal("Expected publish to not wait for the subscriber")
+	}
+	s, ok := p.stat(l)
+	if !ok || s.Dropped != 3 || s.Queued != 2 {
+		t.Fatalf("Unexpected stats %+v, %v", s, ok)
+	}
+	for _, expected := range []string{"a", "b"} {
+		if ev := <-l; ev.Action != expected {
+			t.Fatalf("Expected event %s, got %s", expected, ev.Action)
+		}
+	}
+}
+
+func TestPublisherDisconnect(t *testing.T) {
+	p := newPublisher(100*time.Millisecond, 1)
+	l := p.subscribe(context.Background(), &subscriber{policy: Disconnect, timeout: 10 * time.Millisecond}, nil)
+
+	publishActions(p, 2)
+	if p.len() != 0 {
+		t.Fatalf("Expected the subscriber to be evicted, got %d subscribers", p.len())
+	}
+	if ev := <-l; ev.Action != "a" {
+		t.Fatalf("Expected event a, got %s", ev.Action)
+	}
+	if _, ok := <-l; ok {
+		t.Fatal("Expected the subscriber channel to be closed")
+	}
+	if _, ok := p.stat(l); ok {
+		t.Fatal("Expected no stats for an evicted subscriber")
+	}
+	// evicting an evicted subscriber must not panic
+	p.evict(l)
+}
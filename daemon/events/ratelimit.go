@@ -0,0 +1,153 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+// RestartLoopAction is the action of the event logged in place of the
+// start, die and restart events of a container that exceeded the rate
+// limit.
+const RestartLoopAction = "restart-loop"
+
+// CoalescedAction is the action of the event logged in place of the
+// other events of an actor that exceeded the rate limit.
+const CoalescedAction = "coalesced"
+
+// restartActions are the actions of a container restarting in a loop.
+var restartActions = map[string]bool{
+	"start":   true,
+	"die":     true,
+	"restart": true,
+}
+
+// actorRate counts the events of an actor during the current window.
+type actorRate struct {
+	start      time.Time
+	count      int
+	suppressed int
+	restarts   bool
+	actor      eventtypes.Actor
+	timer      *time.Timer
+}
+
+// rateLimiter lets at most limit events per actor be logged during each
+// window. The events that exceed the limit are counted instead, and a
+// single event with a count attribute is logged for them when the window
+// ends.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	flush  func(r *rateLimiter, eventType, action string, actor eventtypes.Actor)
+
+	mu        sync.Mutex
+	actors    map[string]*actorRate
+	lastSweep time.Time
+}
+
+// SetRateLimit makes the events service log at most limit events per
+// actor during each window. The remaining events of the window are
+// coalesced into a single event, logged when the window ends, whose count
+// attribute is the number of events it replaces. Its action is
+// restart-loop for a container that was only started, restarted or that
+// died, and coalesced otherwise. Rate limiting is disabled when limit is
+// not positive.
+func (e *Events) SetRateLimit(limit int, window time.Duration) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+	if e.limiter != nil {
+		e.limiter.stop()
+		e.limiter = nil
+	}
+	if limit <= 0 || window <= 0 {
+		return
+	}
+	e.limiter = &rateLimiter{
+		limit:  limit,
+		window: window,
+		flush:  e.logCoalesced,
+		actors: make(map[string]*actorRate),
+	}
+}
+
+// allow returns true if the event is within the rate limit of its actor.
+func (r *rateLimiter) allow(now time.Time, action, eventType string, actor eventtypes.Actor) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastSweep) >= r.window {
+		r.sweep(now)
+	}
+
+	key := eventType + "/" + actor.ID
+	a, ok := r.actors[key]
+	if !ok || (a.suppressed == 0 && now.Sub(a.start) >= r.window) {
+		a = &actorRate{start: now}
+		r.actors[key] = a
+	}
+	a.count++
+	// The last event of a container is always logged, so that the
+	// subscribers following it know that it is gone.
+	if a.count <= r.limit || (eventType == eventtypes.ContainerEventType && action == "destroy") {
+		return true
+	}
+
+	if a.suppressed == 0 {
+		a.restarts = true
+		d := r.window - now.Sub(a.start)
+		a.timer = time.AfterFunc(d, func() { r.end(key, a, eventType) })
+	}
+	a.suppressed++
+	a.restarts = a.restarts && eventType == eventtypes.ContainerEventType && restartActions[action]
+	a.actor = actor
+	return false
+}
+
+// end logs the coalesced event of the actor a when its window ends.
+func (r *rateLimiter) end(key string, a *actorRate, eventType string) {
+	r.mu.Lock()
+	if r.actors[key] != a || a.suppressed == 0 {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.actors, key)
+	action := CoalescedAction
+	if a.restarts {
+		action = RestartLoopAction
+	}
+	attributes := make(map[string]string, len(a.actor.Attributes)+1)
+	for k, v := range a.actor.Attributes {
+		attributes[k] = v
+	}
+	attributes["count"] = strconv.Itoa(a.suppressed)
+	actor := eventtypes.Actor{ID: a.actor.ID, Attributes: attributes}
+	r.mu.Unlock()
+
+	r.flush(r, eventType, action, actor)
+}
+
+// sweep forgets the actors whose window ended without any event in
+// excess of the limit.
+func (r *rateLimiter) sweep(now time.Time) {
+	for key, a := range r.actors {
+		if a.suppressed == 0 && now.Sub(a.start) >= r.window {
+			delete(r.actors, key)
+		}
+	}
+	r.lastSweep = now
+}
+
+// stop discards the events waiting to be coalesced.
+func (r *rateLimiter) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, a := range r.actors {
+		if a.timer != nil {
+			a.timer.Stop()
+		}
+		delete(r.actors, key)
+	}
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestRateLimitRestartLoop(t *testing.T) {
+	e := New(0)
+	e.SetRateLimit(2, 100*time.Millisecond)
+	defer e.Close()
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	actor := eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "flappy"}}
+	other := eventtypes.Actor{ID: "other"}
+	for i := 0; i < 5; i++ {
+		e.Log("start", events.ContainerEventType, actor)
+		e.Log("die", events.ContainerEventType, actor)
+	}
+	e.Log("start", events.ContainerEventType, other)
+
+	var got []events.Message
+	for len(got) < 4 {
+		select {
+		case v := <-l:
+			got = append(got, v)
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for events, got %v", got)
+		}
+	}
+	for i, action := range []string{"start", "die"} {
+		if got[i].Action != action || got[i].Actor.ID != "cont" {
+			t.Fatalf("Expected %s event of cont, got %v", action, got[i])
+		}
+	}
+	if got[2].Actor.ID != "other" {
+		t.Fatalf("Expected event of other, got %v", got[2])
+	}
+	ev := got[3]
+	if ev.Action != RestartLoopAction || ev.Actor.ID != "cont" {
+		t.Fatalf("Expected restart-loop event of cont, got %v", ev)
+	}
+	if ev.Actor.Attributes["count"] != "8" || ev.Actor.Attributes["name"] != "flappy" {
+		t.Fatalf("Unexpected attributes %v", ev.Actor.Attributes)
+	}
+
+	// A new window starts after the coalesced event.
+	e.Log("start", events.ContainerEventType, actor)
+	select {
+	case ev := <-l:
+		if ev.Action != "start" {
+			t.Fatalf("Expected start event, got %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for start event")
+	}
+}
+
+func TestRateLimitCoalesced(t *testing.T) {
+	e := New(0)
+	e.SetRateLimit(1, 50*time.Millisecond)
+	defer e.Close()
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	actor := eventtypes.Actor{ID: "img"}
+	e.Log("pull", events.ImageEventType, actor)
+	e.Log("tag", events.ImageEventType, actor)
+	e.Log("untag", events.ImageEventType, actor)
+
+	for _, action := range []string{"pull", CoalescedAction} {
+		select {
+		case v := <-l:
+			if ev := v; ev.Action != action {
+				t.Fatalf("Expected %s event, got %v", action, ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for %s event", action)
+		}
+	}
+}
+
+func TestRateLimitKeepsDestroy(t *testing.T) {
+	e := New(0)
+	e.SetRateLimit(1, time.Minute)
+	defer e.Close()
+
+	actor := eventtypes.Actor{ID: "cont"}
+	e.Log("start", events.ContainerEventType, actor)
+	e.Log("die", events.ContainerEventType, actor)
+	e.Log("destroy", events.ContainerEventType, actor)
+
+	if len(e.recent.events()) != 2 || e.recent.events()[1].Action != "destroy" {
+		t.Fatalf("Expected start and destroy events, got %v", e.recent.events())
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	e := New(0)
+	e.SetRateLimit(1, time.Minute)
+	e.SetRateLimit(0, 0)
+
+	actor := eventtypes.Actor{ID: "cont"}
+	for i := 0; i < 3; i++ {
+		e.Log("start", events.ContainerEventType, actor)
+	}
+	if len(e.recent.events()) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(e.recent.events()))
+	}
+}
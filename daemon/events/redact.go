@@ -0,0 +1,114 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// redacted replaces the parts of the attribute values that are redacted.
+const redacted = "[redacted]"
+
+// RedactionRule is the configuration of a rule removing sensitive data
+// from the attributes of the events, before they reach the subscribers,
+// the journal and the exporters.
+type RedactionRule struct {
+	// Types are the types of the events the rule applies to, it applies
+	// to all events when it is empty.
+	Types []string `json:"types,omitempty"`
+	// Attributes are patterns, as in path.Match, of the names of the
+	// attributes removed from the events.
+	Attributes []string `json:"attributes,omitempty"`
+	// Values is a regular expression, the parts of the attribute values
+	// it matches are replaced by "[redacted]".
+	Values string `json:"values,omitempty"`
+}
+
+// redactionRule is a parsed redaction rule.
+type redactionRule struct {
+	types      map[string]bool
+	attributes []string
+	values     *regexp.Regexp
+}
+
+// Redactor removes sensitive data from the attributes of the events.
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor returns a redactor applying the rules, after checking their
+// patterns.
+func NewRedactor(rules []RedactionRule) (*Redactor, error) {
+	r := &Redactor{}
+	for _, rule := range rules {
+		if len(rule.Attributes) == 0 && rule.Values == "" {
+			return nil, fmt.Errorf("invalid events redaction rule: no attributes nor values")
+		}
+		parsed := redactionRule{attributes: rule.Attributes}
+		for _, pattern := range rule.Attributes {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid events redaction attribute %q: %v", pattern, err)
+			}
+		}
+		if rule.Values != "" {
+			re, err := regexp.Compile(rule.Values)
+			if err != nil {
+				return nil, fmt.Errorf("invalid events redaction values %q: %v", rule.Values, err)
+			}
+			parsed.values = re
+		}
+		if len(rule.Types) > 0 {
+			parsed.types = make(map[string]bool, len(rule.Types))
+			for _, t := range rule.Types {
+				parsed.types[t] = true
+			}
+		}
+		r.rules = append(r.rules, parsed)
+	}
+	return r, nil
+}
+
+// redact applies the rules to the event.
+func (r *Redactor) redact(ev *Event) {
+	for _, rule := range r.rules {
+		if rule.types != nil && !rule.types[ev.Type] {
+			continue
+		}
+		for name, value := range ev.Actor.Attributes {
+			if rule.removes(name) {
+				delete(ev.attributes(), name)
+				continue
+			}
+			if rule.values != nil && rule.values.MatchString(value) {
+				ev.SetAttribute(name, rule.values.ReplaceAllLiteralString(value, redacted))
+			}
+		}
+	}
+}
+
+// removes returns true if the rule removes the attribute name.
+func (r *redactionRule) removes(name string) bool {
+	for _, pattern := range r.attributes {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRedactor makes the events service remove sensitive data from the
+// attributes of every event logged, or stops it when r is nil.
+func (e *Events) SetRedactor(r *Redactor) {
+	e.logMu.Lock()
+	e.redactor = r
+	e.logMu.Unlock()
+}
+
+// redact removes sensitive data from the attributes of the event, once it
+// is enriched.
+func (e *Events) redact(ev *Event) bool {
+	if e.redactor != nil {
+		e.redactor.redact(ev)
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor([]RedactionRule{
+		{Types: []string{events.ContainerEventType}, Attributes: []string{"env", "env.*"}},
+		{Values: "[^/@:]+:[^/@]+@"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetRedactor(r)
+
+	attributes := map[string]string{"env": "prod", "env.secret": "s3cr3t", "name": "test"}
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont", Attributes: attributes})
+	e.Log("pull", events.ImageEventType, events.Actor{ID: "img", Attributes: map[string]string{
+		"env":  "prod",
+		"name": "alice:hunter2@registry.example.com/app",
+	}})
+
+	recent := e.recent.events()
+	if len(recent) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(recent))
+	}
+	if a := recent[0].Actor.Attributes; len(a) != 1 || a["name"] != "test" {
+		t.Fatalf("Expected the env attributes to be removed, got %v", a)
+	}
+	if len(attributes) != 3 {
+		t.Fatalf("Expected the attributes of the caller not to be modified, got %v", attributes)
+	}
+	a := recent[1].Actor.Attributes
+	if a["env"] != "prod" || a["name"] != "[redacted]registry.example.com/app" {
+		t.Fatalf("Unexpected redacted image attributes %v", a)
+	}
+
+	for _, rules := range [][]RedactionRule{
+		{{}},
+		{{Attributes: []string{"["}}},
+		{{Values: "("}},
+	} {
+		if _, err := NewRedactor(rules); err == nil {
+			t.Fatalf("Expected an error for %v", rules)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/events/eventspb"
+	"golang.org/x/net/context"
+)
+
+const (
+	// relayClient identifies the subscribers of the relay.
+	relayClient = "relay"
+	// relayWriteTimeout is how long an event can take to be written to a
+	// client of the relay before it is disconnected.
+	relayWriteTimeout = 10 * time.Second
+)
+
+// Relay sends the events to every client connected to a local socket, as
+// a stream of protocol buffers each preceded by its length, the encoding
+// of eventspb. The clients only need access to the socket, not to the
+// API, and cannot send anything to the daemon.
+type Relay struct {
+	l      net.Listener
+	events *Events
+	mu     sync.Mutex
+	conns  map[net.Conn]context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay returns a relay of the events to the clients connecting to l.
+func NewRelay(l net.Listener) *Relay {
+	return &Relay{
+		l:     l,
+		conns: make(map[net.Conn]context.CancelFunc),
+	}
+}
+
+// Start accepts the clients of the relay and sends them the events of e
+// logged after they connect.
+func (r *Relay) Start(e *Events) {
+	r.events = e
+	r.wg.Add(1)
+	go r.accept()
+}
+
+// Stop closes the socket and disconnects the clients.
+func (r *Relay) Stop() {
+	r.l.Close()
+	r.mu.Lock()
+	for c, cancel := range r.conns {
+		cancel()
+		c.Close()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+func (r *Relay) accept() {
+	defer r.wg.Done()
+	for {
+		c, err := r.l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		r.mu.Lock()
+		r.conns[c] = cancel
+		r.mu.Unlock()
+		r.wg.Add(1)
+		go r.serve(ctx, c)
+	}
+}
+
+// serve sends the events to the client c until it disconnects. A client
+// that does not keep up loses the oldest events, as the other
+// subscribers of the events.
+func (r *Relay) serve(ctx context.Context, c net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		if cancel, ok := r.conns[c]; ok {
+			cancel()
+			delete(r.conns, c)
+		}
+		r.mu.Unlock()
+		c.Close()
+	}()
+
+	_, l := r.events.SubscribeWithOptions(ctx, SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Policy: DropOldest,
+		Client: relayClient,
+	})
+	// The clients do not send anything, reading only detects that they
+	// disconnected.
+	go func() {
+		io.Copy(ioutil.Discard, c)
+		r.mu.Lock()
+		if cancel, ok := r.conns[c]; ok {
+			cancel()
+		}
+		r.mu.Unlock()
+	}()
+
+	enc := eventspb.NewEncoder(c)
+	for ev := range l {
+		c.SetWriteDeadline(time.Now().Add(relayWriteTimeout))
+		if err := enc.Encode(ev); err != nil {
+			logrus.Debugf("Disconnecting client of the events relay: %v", err)
+			r.events.Evict(l)
+			return
+		}
+	}
+}
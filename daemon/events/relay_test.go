@@ -0,0 +1,69 @@
+package events
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/events/eventspb"
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestRelay(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "events.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(0)
+	r := NewRelay(l)
+	r.Start(e)
+	defer r.Stop()
+
+	var clients []net.Conn
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.Subscribers("")) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 subscribers, got %d", len(e.Subscribers("")))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "ctr"})
+	for _, c := range clients {
+		c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		m, err := eventspb.NewDecoder(c).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Action != "start" || m.Actor.ID != "ctr" || m.Sequence != 1 {
+			t.Fatalf("Unexpected event %v", m)
+		}
+	}
+
+	// A client disconnecting is unsubscribed.
+	clients[0].Close()
+	for len(e.Subscribers("")) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 subscriber, got %d", len(e.Subscribers("")))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// maxReplaySpeed is the largest multiplier of the pace of a replay.
+const maxReplaySpeed = 1000
+
+// ParseReplaySpeed parses the speed of a replay of the events, a
+// multiplier of their original pace such as 1, 10, 0.5 or 10x.
+func ParseReplaySpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 || speed > maxReplaySpeed {
+		return 0, fmt.Errorf("bad parameter: replay must be a speed greater than 0 and up to %d, got %q", maxReplaySpeed, s)
+	}
+	return speed, nil
+}
+
+// Replay sends the events evs in order, waiting between two events the
+// time that separated them when they were logged divided by speed. It
+// returns when all the events are sent, when sending one fails, or
+// when stop is closed.
+func Replay(evs []eventtypes.Message, speed float64, send func(eventtypes.Message) error, stop <-chan bool) error {
+	for i, ev := range evs {
+		if i > 0 {
+			if d := replayDelay(evs[i-1], ev, speed); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-stop:
+					return nil
+				}
+			}
+		}
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayDelay returns how long to wait between the events prev and ev
+// replayed at speed.
+func replayDelay(prev, ev eventtypes.Message, speed float64) time.Duration {
+	return time.Duration(float64(ev.TimeNano-prev.TimeNano) / speed)
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestParseReplaySpeed(t *testing.T) {
+	for s, expected := range map[string]float64{"1": 1, "10x": 10, "0.5": 0.5, "1000": 1000} {
+		speed, err := ParseReplaySpeed(s)
+		if err != nil || speed != expected {
+			t.Fatalf("Expected %v for %q, got %v, %v", expected, s, speed, err)
+		}
+	}
+	for _, s := range []string{"", "x", "0", "-2", "fast", "1001"} {
+		if _, err := ParseReplaySpeed(s); err == nil {
+			t.Fatalf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now().UnixNano()
+	evs := []events.Message{
+		{Message: eventtypes.Message{Action: "create", TimeNano: start}},
+		{Message: eventtypes.Message{Action: "start", TimeNano: start + int64(200*time.Millisecond)}},
+		{Message: eventtypes.Message{Action: "die", TimeNano: start + int64(400*time.Millisecond)}},
+	}
+
+	var sent []time.Time
+	send := func(m events.Message) error {
+		sent = append(sent, time.Now())
+		return nil
+	}
+	if err := Replay(evs, 4, send, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("Expected 3 events replayed, got %d", len(sent))
+	}
+	// At 4x, the events are replayed 50ms apart.
+	for i := 1; i < len(sent); i++ {
+		if d := sent[i].Sub(sent[i-1]); d < 50*time.Millisecond || d > time.Second {
+			t.Fatalf("Expected the events %d and %d replayed 50ms apart, got %s", i-1, i, d)
+		}
+	}
+
+	// The replay stops when the client disconnects.
+	stop := make(chan bool)
+	close(stop)
+	sent = nil
+	evs[1].TimeNano = start + int64(time.Hour)
+	if err := Replay(evs, 1, send, stop); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 event replayed before stopping, got %d", len(sent))
+	}
+}
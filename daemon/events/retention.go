@@ -0,0 +1,54 @@
+package events
+
+import "time"
+
+// SetRetention makes the events service keep in memory the events logged
+// during the last age, even when there are more of them than the buffer
+// size, up to max events. The events older than age that are not among
+// the last buffer size ones are discarded by a background pruner.
+// Retention by age is disabled when age is not positive.
+func (e *Events) SetRetention(age time.Duration, max int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pruner != nil {
+		close(e.pruner)
+		e.pruner = nil
+	}
+	e.recent.setRetention(age, max)
+	if age <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	e.pruner = stop
+	go e.prune(pruneInterval(age), stop)
+}
+
+// pruneInterval returns how often the events older than age are
+// discarded, a tenth of age, between a second and a minute.
+func pruneInterval(age time.Duration) time.Duration {
+	interval := age / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// prune discards the events that are no longer retained every interval,
+// until stop is closed.
+func (e *Events) prune(interval time.Duration, stop chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			e.mu.Lock()
+			e.recent.trim(now.UnixNano())
+			e.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestRetention(t *testing.T) {
+	e := New(2)
+	e.SetRetention(time.Hour, 5)
+	defer e.Close()
+
+	for i := 0; i < 4; i++ {
+		e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	if n := len(e.recent.events()); n != 4 {
+		t.Fatalf("Expected the 4 recent events to be kept, got %d", n)
+	}
+	for i := 0; i < 4; i++ {
+		e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	evs := e.recent.events()
+	if len(evs) != 5 || evs[4].Sequence != 8 {
+		t.Fatalf("Expected the last 5 events to be kept, got %v", evs)
+	}
+	if m := e.Metrics(); m.Buffered != 5 || m.BufferSize != 5 {
+		t.Fatalf("Unexpected metrics %+v", m)
+	}
+
+	e.SetRetention(0, 0)
+	if n := len(e.recent.events()); n != 2 {
+		t.Fatalf("Expected 2 events when retention is disabled, got %d", n)
+	}
+}
+
+func TestRetentionTrim(t *testing.T) {
+	r := newRing(2)
+	r.setRetention(time.Minute, 100)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		r.add(events.Message{Message: eventtypes.Message{TimeNano: now.Add(time.Duration(i) * time.Second).UnixNano()}, Sequence: uint64(i + 1)})
+	}
+	if n := len(r.events()); n != 10 {
+		t.Fatalf("Expected 10 events, got %d", n)
+	}
+
+	// Once the events are older than a minute, only the last 2 ones are
+	// kept.
+	r.trim(now.Add(65 * time.Second).UnixNano())
+	evs := r.events()
+	if len(evs) != 5 || evs[0].Sequence != 6 {
+		t.Fatalf("Expected the events of the last minute, got %v", evs)
+	}
+	r.trim(now.Add(time.Hour).UnixNano())
+	evs = r.events()
+	if len(evs) != 2 || evs[0].Sequence != 9 {
+		t.Fatalf("Expected the last 2 events, got %v", evs)
+	}
+}
+
+func TestPruneInterval(t *testing.T) {
+	for age, interval := range map[time.Duration]time.Duration{
+		time.Second:    time.Second,
+		time.Minute:    6 * time.Second,
+		24 * time.Hour: time.Minute,
+	} {
+		if got := pruneInterval(age); got != interval {
+			t.Fatalf("Expected prune interval of %v for %v, got %v", interval, age, got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// ring holds the last events logged. It has a single writer, which must
+// be serialized by the caller, and is read without locks: readers get the
+// events logged so far in a slice that is never modified.
+type ring struct {
+	// size is the number of events kept regardless of their age.
+	size int
+	// age is how long the events are kept in addition to the last size
+	// ones, up to max events. The events are only kept by count when it
+	// is 0.
+	age time.Duration
+	max int
+	// maxBytes is the largest memory held by the events kept, the oldest
+	// ones are discarded beyond it, except the last one. The events are
+	// not limited by memory when it is 0.
+	maxBytes int64
+	// buf is the backing array of the slices returned to readers, the
+	// writer appends to it past the end of the last slice returned. The
+	// events kept are buf[start:].
+	buf   []eventtypes.Message
+	start int
+	// sizes holds the memory held by each event of buf, and bytes the
+	// sum for the events kept. bytes is accessed atomically.
+	sizes []int64
+	bytes int64
+	// last holds the slice of the events in buf that are kept.
+	last atomic.Value
+}
+
+// newRing returns a ring keeping the last size events.
+func newRing(size int) *ring {
+	r := &ring{
+		size:  size,
+		max:   size,
+		buf:   make([]eventtypes.Message, 0, 2*size),
+		sizes: make([]int64, 0, 2*size),
+	}
+	r.last.Store(r.buf)
+	return r
+}
+
+// setRetention makes the ring keep the events younger than age, even when
+// there are more than size of them, up to max events. The events are only
+// kept by count when age is not positive.
+func (r *ring) setRetention(age time.Duration, max int) {
+	if age <= 0 || max < r.size {
+		max = r.size
+	}
+	if age < 0 {
+		age = 0
+	}
+	r.age, r.max = age, max
+	r.trim(time.Now().UnixNano())
+}
+
+// setMemoryLimit makes the ring discard the oldest events when the events
+// kept hold more than max bytes, keeping at least the last one. The
+// events are not limited by memory when max is not positive.
+func (r *ring) setMemoryLimit(max int64) {
+	if max < 0 {
+		max = 0
+	}
+	r.maxBytes = max
+	r.trim(time.Now().UnixNano())
+}
+
+// memory returns the memory held by the events kept, in bytes.
+func (r *ring) memory() int64 {
+	return atomic.LoadInt64(&r.bytes)
+}
+
+// limit returns the maximum number of events kept.
+func (r *ring) limit() int {
+	return r.max
+}
+
+// add appends ev to the ring, discarding the events that are no longer
+// retained.
+func (r *ring) add(ev eventtypes.Message) {
+	if len(r.buf) == cap(r.buf) {
+		// Readers may still use buf, the events kept are moved to a new
+		// backing array instead of being shifted in place.
+		kept := r.buf[r.start:]
+		n := len(kept)
+		if n < r.size {
+			n = r.size
+		}
+		buf := make([]eventtypes.Message, len(kept), 2*n)
+		copy(buf, kept)
+		sizes := make([]int64, len(kept), 2*n)
+		copy(sizes, r.sizes[r.start:])
+		r.buf, r.sizes, r.start = buf, sizes, 0
+	}
+	size := eventMemory(ev)
+	r.buf = append(r.buf, ev)
+	r.sizes = append(r.sizes, size)
+	atomic.AddInt64(&r.bytes, size)
+	r.trim(ev.TimeNano)
+}
+
+// trim discards the events that are no longer retained at the time now,
+// in nanoseconds.
+func (r *ring) trim(now int64) {
+	n := len(r.buf)
+	start := n - r.size
+	if r.age > 0 {
+		kept := r.buf[r.start:]
+		cutoff := now - int64(r.age)
+		young := r.start + sort.Search(len(kept), func(i int) bool {
+			return kept[i].TimeNano >= cutoff
+		})
+		if young < start {
+			start = young
+		}
+		if start < n-r.max {
+			start = n - r.max
+		}
+	}
+	if start < r.start {
+		start = r.start
+	}
+	var discarded int64
+	for _, size := range r.sizes[r.start:start] {
+		discarded += size
+	}
+	if r.maxBytes > 0 {
+		for bytes := r.memory() - discarded; bytes > r.maxBytes && start < n-1; start++ {
+			bytes -= r.sizes[start]
+			discarded += r.sizes[start]
+		}
+	}
+	atomic.AddInt64(&r.bytes, -discarded)
+	r.start = start
+	r.last.Store(r.buf[start:n:n])
+}
+
+// events returns the events in the ring, oldest first. The slice must
+// not be modified.
+func (r *ring) events() []eventtypes.Message {
+	return r.last.Load().([]eventtypes.Message)
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	if n := len(r.events()); n != 0 {
+		t.Fatalf("Expected no events, got %d", n)
+	}
+
+	var snapshots [][]events.Message
+	for i := uint64(1); i <= 10; i++ {
+		r.add(events.Message{Sequence: i})
+		snapshots = append(snapshots, r.events())
+	}
+
+	// Every snapshot still holds the last events at the time it was
+	// taken.
+	for i, snapshot := range snapshots {
+		last := uint64(i + 1)
+		first := uint64(1)
+		if last > 3 {
+			first = last - 2
+		}
+		if len(snapshot) != int(last-first+1) {
+			t.Fatalf("Expected %d events in snapshot %d, got %v", last-first+1, i, snapshot)
+		}
+		for j, ev := range snapshot {
+			if ev.Sequence != first+uint64(j) {
+				t.Fatalf("Unexpected snapshot %d: %v", i, snapshot)
+			}
+		}
+	}
+}
+
+func TestRingConcurrentReaders(t *testing.T) {
+	r := newRing(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				evs := r.events()
+				for k := 1; k < len(evs); k++ {
+					if evs[k].Sequence != evs[k-1].Sequence+1 {
+						t.Errorf("Unexpected events %v", evs)
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := uint64(1); i <= 1000; i++ {
+		r.add(events.Message{Sequence: i})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,334 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+// The actions of the rules.
+const (
+	// RuleRestart restarts the container of the event.
+	RuleRestart = "restart"
+	// RuleLabel adds the labels of the rule to the attributes of the
+	// following events of the container of the event.
+	RuleLabel = "label"
+	// RuleHook runs the command of the rule with the event.
+	RuleHook = "hook"
+)
+
+const (
+	// rulesClient identifies the subscriber of the rules, and names the
+	// middleware adding the labels of the rules to the events.
+	rulesClient = "rules"
+	// defaultRuleCooldown is how long a rule does not trigger again for
+	// the same object, so that the events generated by its action, such
+	// as the die event of a restarted container, do not trigger it in a
+	// loop.
+	defaultRuleCooldown = 30 * time.Second
+	// ruleHookTimeout is how long the command of a hook can run before it
+	// is killed, for the rules and for the hooks of the containers.
+	ruleHookTimeout = 30 * time.Second
+	// maxHookOutput is the largest part of the output of a hook kept for
+	// its error.
+	maxHookOutput = 4 * 1024
+	// ruleRestartTimeout is how many seconds a container restarted by a
+	// rule has to stop before it is killed.
+	ruleRestartTimeout = 10
+)
+
+// RuleConfig is the configuration of a rule, run when an event matches
+// its filters.
+type RuleConfig struct {
+	// Name identifies the rule in the daemon logs.
+	Name string `json:"name"`
+	// Filters selects the events triggering the rule, using the same
+	// filters as the events API. At least one filter is required.
+	Filters map[string][]string `json:"filters"`
+	// Action is restart, label or hook.
+	Action string `json:"action"`
+	// Command is the command run by a hook, the first element being the
+	// path of the executable.
+	Command []string `json:"command,omitempty"`
+	// Labels are the labels added to the events of the container by a
+	// label rule.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Cooldown is the number of seconds during which the rule does not
+	// trigger again for the same object, it defaults to 30. Use -1 to
+	// trigger the rule for every event.
+	Cooldown int `json:"cooldown,omitempty"`
+}
+
+// RuleActions performs the actions of the rules on the containers.
+type RuleActions interface {
+	// RestartContainer restarts the container, waiting seconds for it
+	// to stop.
+	RestartContainer(id string, seconds int) error
+}
+
+type rule struct {
+	config   RuleConfig
+	filter   *Filter
+	cooldown time.Duration
+	// last is when the rule was triggered for each object.
+	last map[string]time.Time
+}
+
+// Rules runs the actions of the rules triggered by the events, from a
+// single subscriber of the events.
+type Rules struct {
+	rules   []*rule
+	actions RuleActions
+	events  *Events
+	l       <-chan eventtypes.Message
+	wg      sync.WaitGroup
+	done    chan struct{}
+
+	// labels are the labels added by the label rules to each container.
+	// They are kept with the rules rather than in the configuration of
+	// the containers, which the rules must not modify.
+	labelsMu sync.Mutex
+	labels   map[string]map[string]string
+}
+
+// NewRules validates the configuration of the rules and returns them. The
+// rules are not triggered until they are started.
+func NewRules(configs []RuleConfig, actions RuleActions) (*Rules, error) {
+	r := &Rules{actions: actions, done: make(chan struct{}), labels: make(map[string]map[string]string)}
+	for _, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("invalid events rule: name is required")
+		}
+		if len(c.Filters) == 0 {
+			return nil, fmt.Errorf("invalid events rule %s: filters are required", c.Name)
+		}
+		args := filters.NewArgs()
+		for name, values := range c.Filters {
+			for _, value := range values {
+				args.Add(name, value)
+			}
+		}
+		ef, err := ParseFilter(args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid events rule %s: %v", c.Name, err)
+		}
+		switch c.Action {
+		case RuleRestart:
+		case RuleLabel:
+			if len(c.Labels) == 0 {
+				return nil, fmt.Errorf("invalid events rule %s: labels are required", c.Name)
+			}
+		case RuleHook:
+			if len(c.Command) == 0 {
+				return nil, fmt.Errorf("invalid events rule %s: command is required", c.Name)
+			}
+		default:
+			return nil, fmt.Errorf("invalid events rule %s: action must be %s, %s or %s, got %q", c.Name, RuleRestart, RuleLabel, RuleHook, c.Action)
+		}
+		cooldown := defaultRuleCooldown
+		if c.Cooldown < 0 {
+			cooldown = 0
+		} else if c.Cooldown > 0 {
+			cooldown = time.Duration(c.Cooldown) * time.Second
+		}
+		r.rules = append(r.rules, &rule{
+			config:   c,
+			filter:   ef,
+			cooldown: cooldown,
+			last:     make(map[string]time.Time),
+		})
+	}
+	return r, nil
+}
+
+// Start subscribes the rules to e and runs their actions in the
+// background.
+func (r *Rules) Start(e *Events) {
+	r.events = e
+	if err := e.Use(rulesClient, EnrichStage, r.enrich); err != nil {
+		logrus.Errorf("Error adding the labels of the events rules: %v", err)
+	}
+	_, r.l = e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Policy: DropOldest,
+		Client: rulesClient,
+	})
+	go r.run()
+}
+
+// Stop unsubscribes the rules and waits for the actions running.
+func (r *Rules) Stop() {
+	r.events.RemoveMiddleware(rulesClient)
+	r.events.Evict(r.l)
+	<-r.done
+	r.wg.Wait()
+}
+
+func (r *Rules) run() {
+	defer close(r.done)
+	for ev := range r.l {
+		for _, rl := range r.rules {
+			if !rl.trigger(ev) {
+				continue
+			}
+			r.wg.Add(1)
+			go func(rl *rule, ev eventtypes.Message) {
+				defer r.wg.Done()
+				logrus.Infof("Running events rule %s for %s %s of %s", rl.config.Name, ev.Type, ev.Action, ev.Actor.ID)
+				if err := r.apply(rl.config, ev); err != nil {
+					logrus.Errorf("Error running events rule %s: %v", rl.config.Name, err)
+				}
+			}(rl, ev)
+		}
+	}
+}
+
+// trigger returns true if ev triggers the rule, and records when.
+func (rl *rule) trigger(ev eventtypes.Message) bool {
+	if !rl.filter.Include(ev) {
+		return false
+	}
+	if rl.config.Action != RuleHook && ev.Type != eventtypes.ContainerEventType {
+		// Only the containers can be restarted or labeled.
+		return false
+	}
+	now := time.Now()
+	if last, ok := rl.last[ev.Actor.ID]; ok && now.Sub(last) < rl.cooldown {
+		return false
+	}
+	// Forget the objects whose cooldown ended, not to grow forever.
+	for id, last := range rl.last {
+		if now.Sub(last) >= rl.cooldown {
+			delete(rl.last, id)
+		}
+	}
+	if rl.cooldown > 0 {
+		rl.last[ev.Actor.ID] = now
+	}
+	return true
+}
+
+// apply runs the action of the rule c for the event ev.
+func (r *Rules) apply(c RuleConfig, ev eventtypes.Message) error {
+	switch c.Action {
+	case RuleRestart:
+		return r.actions.RestartContainer(ev.Actor.ID, ruleRestartTimeout)
+	case RuleLabel:
+		r.label(ev.Actor.ID, c.Labels)
+		return nil
+	}
+	cmd := exec.Command(c.Command[0], c.Command[1:]...)
+	cmd.Env = os.Environ()
+	return runHook(cmd, ev)
+}
+
+// label adds labels to the labels of the container id.
+func (r *Rules) label(id string, labels map[string]string) {
+	r.labelsMu.Lock()
+	defer r.labelsMu.Unlock()
+	l := r.labels[id]
+	if l == nil {
+		l = make(map[string]string, len(labels))
+		r.labels[id] = l
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+}
+
+// enrich adds the labels of the rules to the attributes of the events of
+// the containers, except the attributes they already have. The labels of
+// a container are forgotten once it is destroyed.
+func (r *Rules) enrich(ev *Event) bool {
+	if ev.Type != eventtypes.ContainerEventType {
+		return true
+	}
+	r.labelsMu.Lock()
+	defer r.labelsMu.Unlock()
+	for k, v := range r.labels[ev.Actor.ID] {
+		if _, exists := ev.Actor.Attributes[k]; !exists {
+			ev.SetAttribute(k, v)
+		}
+	}
+	if ev.Action == "destroy" {
+		delete(r.labels, ev.Actor.ID)
+	}
+	return true
+}
+
+// runHook runs cmd with the event encoded in JSON on its standard input,
+// and the details of the event added to its DOCKER_EVENT_* environment
+// variables. The command is killed if it runs for too long.
+func runHook(cmd *exec.Cmd, ev eventtypes.Message) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	vars := journaldVars(ev)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd.Env = append(cmd.Env, name+"="+vars[name])
+	}
+	var output hookOutput
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(ruleHookTimeout):
+		killHook(cmd)
+		<-done
+		err = fmt.Errorf("timeout after %s", ruleHookTimeout)
+	}
+	if err != nil {
+		out := bytes.TrimSpace(output.buf.Bytes())
+		if output.truncated {
+			return fmt.Errorf("%s: %v: %s (truncated)", cmd.Args[0], err, out)
+		}
+		return fmt.Errorf("%s: %v: %s", cmd.Args[0], err, out)
+	}
+	return nil
+}
+
+// hookOutput keeps the first maxHookOutput bytes of the output of a hook
+// and discards the rest, so that a hook cannot make the daemon hold all of
+// its output.
+type hookOutput struct {
+	// buf is not embedded, so that the output is not copied with its
+	// ReadFrom method.
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	if n := maxHookOutput - o.buf.Len(); n < len(p) {
+		o.truncated = true
+		if n > 0 {
+			o.buf.Write(p[:n])
+		}
+		return len(p), nil
+	}
+	return o.buf.Write(p)
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+type fakeRuleActions struct {
+	restarted chan string
+}
+
+func (a *fakeRuleActions) RestartContainer(id string, seconds int) error {
+	a.restarted <- id
+	return nil
+}
+
+func TestRulesRestartWithCooldown(t *testing.T) {
+	actions := &fakeRuleActions{restarted: make(chan string, 10)}
+	r, err := NewRules([]RuleConfig{
+		{
+			Name:    "crashed",
+			Filters: map[string][]string{"event": {"die"}},
+			Action:  RuleRestart,
+		},
+		{
+			Name:    "oom",
+			Filters: map[string][]string{"event": {"oom"}},
+			Action:  RuleLabel,
+			Labels:  map[string]string{"oom": "true"},
+		},
+	}, actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	r.Start(e)
+
+	e.Log("kill", events.ContainerEventType, eventtypes.Actor{ID: "web"})
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "web"})
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "web"})
+	e.Log("oom", events.ContainerEventType, eventtypes.Actor{ID: "db"})
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "db"})
+
+	restarted := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-actions.restarted:
+			restarted[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for the containers to be restarted")
+		}
+	}
+	if !restarted["web"] || !restarted["db"] {
+		t.Fatalf("Unexpected restarted containers %v", restarted)
+	}
+	waitFor(t, "the container to be labeled", func() bool {
+		r.labelsMu.Lock()
+		defer r.labelsMu.Unlock()
+		return r.labels["db"]["oom"] == "true"
+	})
+	e.Log("destroy", events.ContainerEventType, eventtypes.Actor{ID: "db"})
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "db"})
+	recent := e.recent.events()
+	if a := recent[len(recent)-2].Actor.Attributes; a["oom"] != "true" {
+		t.Fatalf("Expected the destroy event of db to carry the label, got %v", a)
+	}
+	if a := recent[len(recent)-1].Actor.Attributes; len(a) != 0 {
+		t.Fatalf("Expected the label of db to be removed with it, got %v", a)
+	}
+	r.Stop()
+	// The kill event matches no rule, and the second die event of web was
+	// within the cooldown.
+	if len(actions.restarted) != 0 {
+		t.Fatalf("Unexpected restart of %s", <-actions.restarted)
+	}
+}
+
+func TestRulesHook(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	out := filepath.Join(tmp, "event.json")
+
+	ev := events.Message{Message: eventtypes.Message{Type: events.NetworkEventType, Action: "connect", Actor: eventtypes.Actor{ID: "net"}}}
+	script := `test "$DOCKER_EVENT_ACTION" = connect && cat > ` + out
+	if err := runHook(exec.Command("/bin/sh", "-c", script), ev); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m events.Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Actor.ID != "net" {
+		t.Fatalf("Unexpected event %v", m)
+	}
+	if err := runHook(exec.Command("/bin/sh", "-c", "echo failed; exit 3"), ev); err == nil || err.Error() != "/bin/sh: exit status 3: failed" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Only the beginning of a large output is kept.
+	err = runHook(exec.Command("/bin/sh", "-c", "head -c 1048576 /dev/zero | tr '\\0' x; exit 3"), ev)
+	if err == nil || !strings.HasSuffix(err.Error(), " (truncated)") || len(err.Error()) > maxHookOutput+64 {
+		t.Fatalf("Expected the output of the hook truncated, got %d bytes", len(err.Error()))
+	}
+	if !strings.Contains(err.Error(), strings.Repeat("x", maxHookOutput)) {
+		t.Fatal("Expected the beginning of the output of the hook kept")
+	}
+}
+
+func TestNewRulesInvalid(t *testing.T) {
+	for _, c := range []RuleConfig{
+		{Filters: map[string][]string{"event": {"die"}}, Action: RuleRestart},
+		{Name: "all", Action: RuleRestart},
+		{Name: "bad", Filters: map[string][]string{"colour": {"red"}}, Action: RuleRestart},
+		{Name: "stop", Filters: map[string][]string{"event": {"die"}}, Action: "stop"},
+		{Name: "label", Filters: map[string][]string{"event": {"die"}}, Action: RuleLabel},
+		{Name: "hook", Filters: map[string][]string{"event": {"die"}}, Action: RuleHook},
+	} {
+		if _, err := NewRules([]RuleConfig{c}, nil); err == nil {
+			t.Fatalf("Expected an error for rule %v", c)
+		}
+	}
+}
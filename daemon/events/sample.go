@@ -0,0 +1,65 @@
+package events
+
+import (
+	"sync"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// errorActions are the actions of the events reporting a failure, which
+// are never left out by sampling.
+var errorActions = map[string]bool{
+	"oom":                      true,
+	"oom_warning":              true,
+	"restart_backoff":          true,
+	"restart_giveup":           true,
+	"cpu_high":                 true,
+	"memory_high":              true,
+	"disk_high":                true,
+	"health_status: unhealthy": true,
+	"dropped":                  true,
+}
+
+// isErrorEvent returns true if ev reports a failure: an error action, a
+// container that exited with a non zero code, or an event with an error
+// attribute.
+func isErrorEvent(ev eventtypes.Message) bool {
+	if errorActions[ev.Action] || ev.Actor.Attributes["error"] != "" {
+		return true
+	}
+	if ev.Action == "die" {
+		code := ev.Actor.Attributes["exitCode"]
+		return code != "" && code != "0"
+	}
+	return false
+}
+
+// sampler keeps one in every events of each type and action, and all the
+// events reporting a failure.
+type sampler struct {
+	mu     sync.Mutex
+	every  uint64
+	counts map[string]uint64
+}
+
+// newSampler returns a sampler keeping one in every events, or nil if
+// every event is kept.
+func newSampler(every int) *sampler {
+	if every <= 1 {
+		return nil
+	}
+	return &sampler{every: uint64(every), counts: make(map[string]uint64)}
+}
+
+// keep returns true if ev is kept.
+func (s *sampler) keep(ev eventtypes.Message) bool {
+	if isErrorEvent(ev) {
+		return true
+	}
+	key := ev.Type + " " + ev.Action
+	s.mu.Lock()
+	n := s.counts[key]
+	s.counts[key] = n + 1
+	s.mu.Unlock()
+	return n%s.every == 0
+}
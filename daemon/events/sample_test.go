@@ -0,0 +1,43 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+func TestSubscribeSample(t *testing.T) {
+	e := New(256)
+	for i := 0; i < 10; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+		e.Log("die", events.ContainerEventType, events.Actor{ID: "cont", Attributes: map[string]string{"exitCode": "0"}})
+	}
+	e.Log("die", events.ContainerEventType, events.Actor{ID: "cont", Attributes: map[string]string{"exitCode": "1"}})
+	e.Log("oom", events.ContainerEventType, events.Actor{ID: "cont"})
+
+	buffered, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  0,
+		Until:  -1,
+		Sample: 5,
+	})
+	defer e.Evict(l)
+
+	counts := make(map[string]int)
+	for _, ev := range buffered {
+		action := ev.Action
+		if isErrorEvent(ev) {
+			action += " error"
+		}
+		counts[action]++
+	}
+	expected := map[string]int{"start": 2, "die": 2, "die error": 1, "oom error": 1}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, counts)
+	}
+	for action, n := range expected {
+		if counts[action] != n {
+			t.Fatalf("Expected %v, got %v", expected, counts)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/version"
+)
+
+// SchemaVersion identifies a form of the event messages sent to API
+// clients. The events service logs and publishes the events in the form
+// of CurrentSchema, they are translated to the form known by each client
+// when they are sent.
+type SchemaVersion int
+
+const (
+	// LegacySchema is the form of the events sent to the clients of API
+	// 1.23 and older. The events of containers, images, volumes
+	// and networks carry the deprecated ID, Status and From fields in
+	// addition to their actor.
+	LegacySchema SchemaVersion = iota
+	// ActorSchema is the form of the events sent to the clients of API
+	// 1.24 and later. The object of an event is only described by its
+	// actor.
+	ActorSchema
+
+	// CurrentSchema is the form of the events logged by the daemon.
+	CurrentSchema = LegacySchema
+)
+
+// actorSchemaAPIVersion is the first API version whose clients receive
+// the events in the form of ActorSchema.
+const actorSchemaAPIVersion = "1.24"
+
+// SchemaForAPIVersion returns the schema version of the events sent to
+// the clients of an API version. The clients that don't tell their API
+// version receive the current form.
+func SchemaForAPIVersion(v version.Version) SchemaVersion {
+	if v == "" || v.LessThan(actorSchemaAPIVersion) {
+		return LegacySchema
+	}
+	return ActorSchema
+}
+
+// Translate returns an event message in the form of a schema version.
+func Translate(m eventtypes.Message, schema SchemaVersion) eventtypes.Message {
+	switch schema {
+	case LegacySchema:
+		switch m.Type {
+		case eventtypes.ContainerEventType:
+			m.ID = m.Actor.ID
+			m.Status = m.Action
+			m.From = m.Actor.Attributes["image"]
+		case eventtypes.ImageEventType, eventtypes.VolumeEventType, eventtypes.NetworkEventType:
+			m.ID = m.Actor.ID
+			m.Status = m.Action
+		}
+	case ActorSchema:
+		// The events read from journals written by older daemons
+		// carry the deprecated fields.
+		m.ID = ""
+		m.Status = ""
+		m.From = ""
+	}
+	return m
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/version"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestSchemaForAPIVersion(t *testing.T) {
+	for v, expected := range map[version.Version]SchemaVersion{
+		"":     CurrentSchema,
+		"1.21": LegacySchema,
+		"1.22": LegacySchema,
+		"1.23": LegacySchema,
+		"1.24": ActorSchema,
+	} {
+		if schema := SchemaForAPIVersion(v); schema != expected {
+			t.Fatalf("Expected schema %d for API %q, got %d", expected, v, schema)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	e := New(0)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{
+		ID:         "cont",
+		Attributes: map[string]string{"image": "busybox"},
+	})
+	e.Log("foo", events.DaemonEventType, eventtypes.Actor{ID: "daemon"})
+
+	m := e.recent.events()[0]
+	if m.ID != "cont" || m.Status != "start" || m.From != "busybox" {
+		t.Fatalf("Expected deprecated fields to be set, got %v", m)
+	}
+
+	actor := Translate(m, ActorSchema)
+	if actor.ID != "" || actor.Status != "" || actor.From != "" {
+		t.Fatalf("Expected deprecated fields to be removed, got %v", actor)
+	}
+	if actor.Action != "start" || actor.Actor.ID != "cont" {
+		t.Fatalf("Expected actor to be kept, got %v", actor)
+	}
+	if legacy := Translate(actor, LegacySchema); legacy.ID != "cont" || legacy.Status != "start" || legacy.From != "busybox" {
+		t.Fatalf("Expected deprecated fields to be set back, got %v", legacy)
+	}
+
+	if d := Translate(e.recent.events()[1], LegacySchema); d.ID != "" || d.Status != "" {
+		t.Fatalf("Expected daemon event without deprecated fields, got %v", d)
+	}
+}
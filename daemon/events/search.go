@@ -0,0 +1,232 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	derr "github.com/docker/docker/errors"
+	timetypes "github.com/docker/engine-api/types/time"
+)
+
+// Query is a search of the events of the journal.
+type Query struct {
+	// sinceNano and untilNano bound the time of the events, they are 0
+	// when not set.
+	sinceNano, untilNano int64
+	// terms are the patterns of the values of each field of the events,
+	// by field name. An event matches when each of its fields matches
+	// one of the patterns of the field.
+	terms map[string][]string
+}
+
+// ParseQuery parses a search of the events, made of space separated
+// field:pattern terms, such as "type:container action:die image:nginx*
+// since:-2h". The fields are type, action, id, since and until, the other
+// fields are attributes of the events. Since and until are timestamps, or
+// durations before now. The patterns are as in path.Match.
+func ParseQuery(q string, now time.Time) (*Query, error) {
+	query := &Query{terms: make(map[string][]string)}
+	for _, term := range strings.Fields(q) {
+		parts := strings.SplitN(term, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("bad parameter: invalid search term %q, must be field:value", term)
+		}
+		field, value := parts[0], parts[1]
+		switch field {
+		case "since", "until":
+			ts, err := queryTime(value, now)
+			if err != nil {
+				return nil, fmt.Errorf("bad parameter: invalid search term %q: %v", term, err)
+			}
+			if field == "since" {
+				query.sinceNano = ts
+			} else {
+				query.untilNano = ts
+			}
+			continue
+		case "event":
+			field = "action"
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("bad parameter: invalid search term %q: %v", term, err)
+		}
+		query.terms[field] = append(query.terms[field], value)
+	}
+	return query, nil
+}
+
+// queryTime returns the time in nanoseconds of a timestamp, or of a
+// duration before now, which may start with a minus sign.
+func queryTime(value string, now time.Time) (int64, error) {
+	value = strings.TrimPrefix(value, "-")
+	ts, err := timetypes.GetTimestamp(value, now)
+	if err != nil {
+		return 0, err
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return 0, err
+	}
+	return time.Unix(sec, nsec).UnixNano(), nil
+}
+
+// exact returns the value of the field if the query matches a single
+// literal value of it, to look the events up in the index.
+func (q *Query) exact(field string) string {
+	values := q.terms[field]
+	if len(values) != 1 || strings.ContainsAny(values[0], `*?[\`) {
+		return ""
+	}
+	return values[0]
+}
+
+// Match returns true if the event matches the query.
+func (q *Query) Match(ev eventtypes.Message) bool {
+	if q.sinceNano != 0 && ev.TimeNano < q.sinceNano {
+		return false
+	}
+	if q.untilNano != 0 && ev.TimeNano > q.untilNano {
+		return false
+	}
+	for field, patterns := range q.terms {
+		var value string
+		var ok bool
+		switch field {
+		case "type":
+			value, ok = ev.Type, true
+		case "action":
+			value, ok = ev.Action, true
+		case "id":
+			value, ok = ev.Actor.ID, true
+		default:
+			value, ok = ev.Actor.Attributes[field]
+		}
+		if !ok || !matchAny(patterns, field, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny returns true if value matches one of the patterns. The action
+// of the exec and health status events also matches without its details.
+func matchAny(patterns []string, field, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+		if field == "action" {
+			if i := strings.Index(value, ":"); i > 0 {
+				if ok, _ := path.Match(p, value[:i]); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// SearchOptions holds the parameters of a search of the events.
+type SearchOptions struct {
+	// Query selects the events returned.
+	Query *Query
+	// Limit is the maximum number of events returned, it defaults to
+	// 100 and cannot be more than 1000.
+	Limit int
+	// Cursor is the position returned with the previous page, it is
+	// empty to get the first page.
+	Cursor string
+	// Owner is the tenant searching when the event stream is scoped per
+	// tenant, only the events of the objects it owns are returned.
+	Owner string
+	// ACL restricts the returned events, if any.
+	ACL *ACL
+}
+
+// Search returns a page of the events stored in the journal that match
+// the query, from the oldest to the newest, and the cursor of the next
+// page if any. Only the events that the index of the journal finds for
+// the time, type and ID of the query are read.
+func (e *Events) Search(opts SearchOptions) (eventtypes.History, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		return eventtypes.History{}, fmt.Errorf("bad parameter: limit cannot be more than %d", maxHistoryLimit)
+	}
+	var from int64
+	if opts.Cursor != "" {
+		var err error
+		from, err = strconv.ParseInt(opts.Cursor, 10, 64)
+		if err != nil || from < 0 {
+			return eventtypes.History{}, fmt.Errorf("bad parameter: invalid cursor %q", opts.Cursor)
+		}
+	}
+	q := opts.Query
+	if q == nil {
+		q = &Query{}
+	}
+
+	e.mu.Lock()
+	journal := e.journal
+	e.mu.Unlock()
+	if journal == nil {
+		return eventtypes.History{}, derr.ErrorCodeNoEventsJournal
+	}
+	history := eventtypes.History{Events: []eventtypes.Message{}}
+	err := journal.readIndexed(func(idx *journalIndex) error {
+		offsets := idx.lookup(from, q.sinceNano, q.untilNano, q.exact("type"), q.exact("id"))
+		if len(offsets) == 0 {
+			return nil
+		}
+		f, err := os.Open(journal.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r := bufio.NewReader(f)
+		for i, offset := range offsets {
+			if _, err := f.Seek(offset, 0); err != nil {
+				return err
+			}
+			r.Reset(f)
+			line, err := r.ReadBytes('\n')
+			if err != nil {
+				return err
+			}
+			var ev eventtypes.Message
+			if err := json.Unmarshal(line, &ev); err != nil {
+				return err
+			}
+			if !q.Match(ev) {
+				continue
+			}
+			if opts.Owner != "" && !ownedBy(ev, opts.Owner) {
+				continue
+			}
+			if opts.ACL != nil && !opts.ACL.Include(ev) {
+				continue
+			}
+			history.Events = append(history.Events, ev)
+			if len(history.Events) == limit {
+				if i+1 < len(offsets) {
+					history.Cursor = strconv.FormatInt(offsets[i+1], 10)
+				}
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return eventtypes.History{}, err
+	}
+	return history, nil
+}
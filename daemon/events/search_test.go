@@ -0,0 +1,75 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestSearch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetJournal(j)
+	defer e.Close()
+
+	for _, image := range []string{"nginx:latest", "redis", "nginx:1.9"} {
+		actor := eventtypes.Actor{ID: image + "-cont", Attributes: map[string]string{"image": image}}
+		e.Log("start", events.ContainerEventType, actor)
+		e.Log("die", events.ContainerEventType, actor)
+	}
+	e.Log("pull", events.ImageEventType, eventtypes.Actor{ID: "nginx:latest"})
+
+	search := func(q, cursor string, limit int) events.History {
+		query, err := ParseQuery(q, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		history, err := e.Search(SearchOptions{Query: query, Cursor: cursor, Limit: limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return history
+	}
+
+	history := search("type:container action:die image:nginx* since:-2h", "", 0)
+	if len(history.Events) != 2 || history.Cursor != "" {
+		t.Fatalf("Expected the 2 die events of nginx, got %v", history)
+	}
+	for _, ev := range history.Events {
+		if ev.Action != "die" || ev.Actor.Attributes["image"][:5] != "nginx" {
+			t.Fatalf("Unexpected event %v", ev)
+		}
+	}
+
+	// Events logged after the index is built are indexed too.
+	e.Log("die", events.ContainerEventType, eventtypes.Actor{ID: "redis-cont", Attributes: map[string]string{"image": "redis"}})
+	history = search("id:redis-cont", "", 2)
+	if len(history.Events) != 2 || history.Cursor == "" {
+		t.Fatalf("Expected a first page of 2 events, got %v", history)
+	}
+	history = search("id:redis-cont", history.Cursor, 2)
+	if len(history.Events) != 1 || history.Events[0].Action != "die" || history.Cursor != "" {
+		t.Fatalf("Expected a last page with the new event, got %v", history)
+	}
+
+	if history := search("until:-1h", "", 0); len(history.Events) != 0 {
+		t.Fatalf("Expected no events older than 1 hour, got %v", history)
+	}
+	for _, q := range []string{"nginx", "since:yesterday", "image:["} {
+		if _, err := ParseQuery(q, time.Now()); err == nil {
+			t.Fatalf("Expected an error parsing %q", q)
+		}
+	}
+}
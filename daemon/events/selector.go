@@ -0,0 +1,109 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// setSelectorRegexp matches the label selectors on a set of
+// values, like "env in (prod,staging)" or "env notin (dev)".
+var setSelectorRegexp = regexp.MustCompile(`^\s*([^\s=]+)\s+(in|notin)\s+\((.*)\)\s*$`)
+
+// labelSelector matches the attributes of an event against a label
+// filter. The supported forms are "key", "key=value",
+// "key in (value1,value2)" and "key notin (value1,value2)".
+type labelSelector struct {
+	key    string
+	values map[string]bool
+	// hasValue is true when only the given values match.
+	hasValue bool
+	// notIn is true when the given values do not match.
+	notIn bool
+}
+
+func parseLabelSelector(s string) labelSelector {
+	if m := setSelectorRegexp.FindStringSubmatch(s); m != nil {
+		l := labelSelector{
+			key:      m[1],
+			values:   make(map[string]bool),
+			hasValue: m[2] == "in",
+			notIn:    m[2] == "notin",
+		}
+		for _, v := range strings.Split(m[3], ",") {
+			l.values[strings.TrimSpace(v)] = true
+		}
+		return l
+	}
+
+	kv := strings.SplitN(s, "=", 2)
+	l := labelSelector{key: kv[0]}
+	if len(kv) == 2 {
+		l.hasValue = true
+		l.values = map[string]bool{kv[1]: true}
+	}
+	return l
+}
+
+// validateLabelSelector returns an error if s is not a label selector
+// of one of the forms parsed by parseLabelSelector.
+func validateLabelSelector(s string) error {
+	if m := setSelectorRegexp.FindStringSubmatch(s); m != nil {
+		for _, v := range strings.Split(m[3], ",") {
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("empty value in set")
+			}
+		}
+		return nil
+	}
+	key := strings.SplitN(s, "=", 2)[0]
+	if key == "" {
+		return fmt.Errorf("empty label key")
+	}
+	if strings.ContainsAny(key, " \t()") {
+		return fmt.Errorf("malformed label key %q", key)
+	}
+	return nil
+}
+
+func (l labelSelector) match(attributes map[string]string) bool {
+	v, ok := attributes[l.key]
+	if l.notIn {
+		return !ok || !l.values[v]
+	}
+	if !ok {
+		return false
+	}
+	return !l.hasValue || l.values[v]
+}
+
+// compilePattern returns the regular expression matching a filter value
+// that is either a regular expression prefixed by "~", or a glob pattern.
+// It returns nil if the value is a plain name. A regular expression that
+// doesn't compile never matches.
+func compilePattern(value string) *regexp.Regexp {
+	if strings.HasPrefix(value, "~") {
+		re, err := regexp.Compile(value[1:])
+		if err != nil {
+			return neverMatch
+		}
+		return re
+	}
+	if strings.ContainsAny(value, "*?") {
+		return compileGlob(value)
+	}
+	return nil
+}
+
+// neverMatch is a regular expression that matches no string.
+var neverMatch = regexp.MustCompile(`a^`)
+
+// compileGlob returns a regular expression matching the glob pattern,
+// where "*" matches any sequence of characters and "?" matches any
+// single character.
+func compileGlob(pattern string) *regexp.Regexp {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.Replace(re, `\*`, ".*", -1)
+	re = strings.Replace(re, `\?`, ".", -1)
+	return regexp.MustCompile("^" + re + "$")
+}
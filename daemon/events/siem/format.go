@@ -0,0 +1,199 @@
+// Package siem provides the formatting of the daemon events in the Common
+// Event Format (CEF) of ArcSight and the Log Event Extended Format (LEEF) of
+// QRadar, and the events exporter sending them to the syslog endpoint of a
+// SIEM.
+package siem
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/dockerversion"
+)
+
+const (
+	vendor  = "Docker"
+	product = "Docker Engine"
+
+	// leefTimeFormat is the layout of devTime, described to the SIEM by
+	// leefTimeFormatSpec.
+	leefTimeFormat     = "Jan 02 2006 15:04:05.000 MST"
+	leefTimeFormatSpec = "MMM dd yyyy HH:mm:ss.SSS z"
+)
+
+// severities are the severities of the actions, on the scale of 0 to 10 of
+// CEF. The actions not listed have a severity of 3.
+var severities = map[string]int{
+	"oom":         8,
+	"kill":        6,
+	"die":         5,
+	"destroy":     5,
+	"delete":      5,
+	"exec_create": 5,
+	"exec_start":  5,
+	"attach":      4,
+	"commit":      4,
+	"export":      4,
+	"copy":        4,
+	"pause":       4,
+}
+
+const defaultSeverity = 3
+
+// Severity returns the severity of the event m, from 0 to 10.
+func Severity(m eventtypes.Message) int {
+	action := m.Action
+	if i := strings.IndexAny(action, ": "); i >= 0 {
+		// exec_start: sh -c ... and health_status: healthy
+		action = action[:i]
+	}
+	if s, ok := severities[action]; ok {
+		return s
+	}
+	return defaultSeverity
+}
+
+// FormatCEF renders the event m, generated by the daemon on host, in the
+// Common Event Format:
+//
+//	CEF:0|Docker|Docker Engine|<version>|<type>:<action>|<type> <action>|<severity>|<extension>
+//
+// The extension has the time of the event (rt), the host (dvchost), the
+// type (cat) and the action (act) of the event, the ID (duid) and the name
+// (cs1) of the object it is about, and its attributes (cs2).
+func FormatCEF(m eventtypes.Message, host string) string {
+	var b bytes.Buffer
+	b.WriteString("CEF:0|")
+	b.WriteString(cefHeader(vendor))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(product))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(dockerversion.Version))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(m.Type + ":" + m.Action))
+	b.WriteByte('|')
+	b.WriteString(cefHeader(m.Type + " " + m.Action))
+	b.WriteByte('|')
+	b.WriteString(strconv.Itoa(Severity(m)))
+	b.WriteByte('|')
+
+	ext := [][2]string{
+		{"rt", strconv.FormatInt(m.TimeNano/int64(time.Millisecond), 10)},
+		{"dvchost", host},
+		{"cat", m.Type},
+		{"act", m.Action},
+		{"duid", m.Actor.ID},
+	}
+	if name := m.Actor.Attributes["name"]; name != "" {
+		ext = append(ext, [2]string{"cs1Label", "name"}, [2]string{"cs1", name})
+	}
+	if attrs := formatAttributes(m.Actor.Attributes); attrs != "" {
+		ext = append(ext, [2]string{"cs2Label", "attributes"}, [2]string{"cs2", attrs})
+	}
+	for i, kv := range ext {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kv[0])
+		b.WriteByte('=')
+		b.WriteString(cefValue(kv[1]))
+	}
+	return b.String()
+}
+
+// FormatLEEF renders the event m, generated by the daemon on host, in the
+// Log Event Extended Format 1.0:
+//
+//	LEEF:1.0|Docker|Docker Engine|<version>|<type>:<action>|<attributes>
+//
+// The attributes, separated by tabs, are the type (cat) and the severity
+// (sev) of the event, its time (devTime), the host, the action, the ID of
+// the object it is about, and each of its attributes.
+func FormatLEEF(m eventtypes.Message, host string) string {
+	var b bytes.Buffer
+	b.WriteString("LEEF:1.0|")
+	b.WriteString(leefHeader(vendor))
+	b.WriteByte('|')
+	b.WriteString(leefHeader(product))
+	b.WriteByte('|')
+	b.WriteString(leefHeader(dockerversion.Version))
+	b.WriteByte('|')
+	b.WriteString(leefHeader(m.Type + ":" + m.Action))
+	b.WriteByte('|')
+
+	attrs := [][2]string{
+		{"cat", m.Type},
+		{"sev", strconv.Itoa(Severity(m))},
+		{"devTime", time.Unix(0, m.TimeNano).UTC().Format(leefTimeFormat)},
+		{"devTimeFormat", leefTimeFormatSpec},
+		{"host", host},
+		{"action", m.Action},
+		{"id", m.Actor.ID},
+	}
+	for _, k := range sortedKeys(m.Actor.Attributes) {
+		attrs = append(attrs, [2]string{k, m.Actor.Attributes[k]})
+	}
+	for i, kv := range attrs {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		b.WriteString(leefValue(kv[0]))
+		b.WriteByte('=')
+		b.WriteString(leefValue(kv[1]))
+	}
+	return b.String()
+}
+
+// formatAttributes renders the attributes as key=value pairs, sorted by
+// key and separated by commas.
+func formatAttributes(attrs map[string]string) string {
+	var pairs []string
+	for _, k := range sortedKeys(attrs) {
+		pairs = append(pairs, k+"="+attrs[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	// The header fields of CEF and LEEF are escaped the same way.
+	headerReplacer    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueReplacer  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefValueReplacer = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+// cefHeader escapes the backslashes and the pipes of a header field of
+// CEF.
+func cefHeader(s string) string {
+	return headerReplacer.Replace(s)
+}
+
+// cefValue escapes the backslashes and the equal signs of a value of the
+// extension of CEF.
+func cefValue(s string) string {
+	return cefValueReplacer.Replace(s)
+}
+
+// leefHeader escapes the backslashes and the pipes of a header field of
+// LEEF.
+func leefHeader(s string) string {
+	return headerReplacer.Replace(s)
+}
+
+// leefValue replaces the tabs and the line breaks of an attribute of LEEF,
+// since the attributes are separated by tabs.
+func leefValue(s string) string {
+	return leefValueReplacer.Replace(s)
+}
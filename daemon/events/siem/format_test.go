@@ -0,0 +1,92 @@
+package siem
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/dockerversion"
+	engineevents "github.com/docker/engine-api/types/events"
+)
+
+func testMessage() eventtypes.Message {
+	t := time.Date(2016, 3, 1, 10, 20, 30, 400e6, time.UTC)
+	return eventtypes.Message{
+		Message: engineevents.Message{
+			Type:   "container",
+			Action: "kill",
+			Actor: engineevents.Actor{
+				ID: "cont",
+				Attributes: map[string]string{
+					"name":   "web",
+					"image":  "busybox",
+					"signal": "9",
+					"label":  "a=b|c",
+				},
+			},
+			Time:     t.Unix(),
+			TimeNano: t.UnixNano(),
+		},
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	s := FormatCEF(testMessage(), "host1")
+	prefix := "CEF:0|Docker|Docker Engine|" + dockerversion.Version + "|container:kill|container kill|6|"
+	if !strings.HasPrefix(s, prefix) {
+		t.Fatalf("Expected the header %q, got %q", prefix, s)
+	}
+	ext := strings.TrimPrefix(s, prefix)
+	expected := `rt=1456827630400 dvchost=host1 cat=container act=kill duid=cont cs1Label=name cs1=web cs2Label=attributes cs2=image\=busybox, label\=a\=b|c, name\=web, signal\=9`
+	if ext != expected {
+		t.Fatalf("Expected the extension %q, got %q", expected, ext)
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	m := testMessage()
+	m.Action = "exec_start: sh -c\tls"
+	s := FormatLEEF(m, "host1")
+	prefix := "LEEF:1.0|Docker|Docker Engine|" + dockerversion.Version + "|container:exec_start: sh -c\tls|"
+	if !strings.HasPrefix(s, prefix) {
+		t.Fatalf("Expected the header %q, got %q", prefix, s)
+	}
+	attrs := strings.Split(strings.TrimPrefix(s, prefix), "\t")
+	expected := []string{
+		"cat=container",
+		"sev=5",
+		"devTime=Mar 01 2016 10:20:30.400 UTC",
+		"devTimeFormat=MMM dd yyyy HH:mm:ss.SSS z",
+		"host=host1",
+		"action=exec_start: sh -c ls",
+		"id=cont",
+		"image=busybox",
+		"label=a=b|c",
+		"name=web",
+		"signal=9",
+	}
+	if strings.Join(attrs, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected the attributes %q, got %q", expected, attrs)
+	}
+}
+
+func TestHeaderEscaping(t *testing.T) {
+	if s := headerReplacer.Replace(`a|b\c`); s != `a\|b\\c` {
+		t.Fatalf("Unexpected escaping %q", s)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	cases := map[string]int{
+		"oom":                    8,
+		"start":                  defaultSeverity,
+		"exec_create: sh":        5,
+		"health_status: healthy": defaultSeverity,
+	}
+	for action, sev := range cases {
+		if s := Severity(eventtypes.Message{Message: engineevents.Message{Action: action}}); s != sev {
+			t.Fatalf("Expected severity %d for %q, got %d", sev, action, s)
+		}
+	}
+}
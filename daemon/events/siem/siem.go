@@ -0,0 +1,215 @@
+// +build linux
+
+package siem
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	syslog "github.com/RackSec/srslog"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/pkg/urlutil"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+const (
+	name        = "siem"
+	secureProto = "tcp+tls"
+	tag         = "docker-events"
+
+	addressKey       = "siem-address"
+	formatKey        = "siem-format"
+	facilityKey      = "siem-facility"
+	tlsCACertKey     = "siem-tls-ca-cert"
+	tlsCertKey       = "siem-tls-cert"
+	tlsKeyKey        = "siem-tls-key"
+	tlsSkipVerifyKey = "siem-tls-skip-verify"
+)
+
+var formats = map[string]func(eventtypes.Message, string) string{
+	"cef":  FormatCEF,
+	"leef": FormatLEEF,
+}
+
+var facilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+func init() {
+	if err := events.RegisterExporter(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+type exporter struct {
+	host   string
+	format func(eventtypes.Message, string) string
+	writer *syslog.Writer
+}
+
+// New creates a siem exporter with the given options. Supported options
+// are siem-address, siem-format, siem-facility, siem-tls-ca-cert,
+// siem-tls-cert, siem-tls-key and siem-tls-skip-verify. The events are
+// sent to the local syslog daemon when siem-address is not set.
+func New(options map[string]string) (events.Exporter, error) {
+	for key := range options {
+		switch key {
+		case addressKey:
+		case formatKey:
+		case facilityKey:
+		case tlsCACertKey:
+		case tlsCertKey:
+		case tlsKeyKey:
+		case tlsSkipVerifyKey:
+		default:
+			return nil, fmt.Errorf("unknown option '%s' for %s events exporter", key, name)
+		}
+	}
+
+	format := options[formatKey]
+	if format == "" {
+		format = "cef"
+	}
+	formatter, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("%s should be cef or leef, got %q", formatKey, format)
+	}
+	proto, address, err := parseAddress(options[addressKey])
+	if err != nil {
+		return nil, err
+	}
+	facility, err := parseFacility(options[facilityKey])
+	if err != nil {
+		return nil, err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	var w *syslog.Writer
+	if proto == secureProto {
+		tlsConfig, tlsErr := parseTLSConfig(options)
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		w, err = syslog.DialWithTLSConfig(proto, address, facility, tag, tlsConfig)
+	} else {
+		w, err = syslog.Dial(proto, address, facility, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exporter{host: host, format: formatter, writer: w}, nil
+}
+
+// Export sends m, formatted in CEF or LEEF, with the syslog severity
+// matching the severity of the event.
+func (x *exporter) Export(m eventtypes.Message) error {
+	s := x.format(m, x.host)
+	switch sev := Severity(m); {
+	case sev >= 8:
+		return x.writer.Crit(s)
+	case sev >= 6:
+		return x.writer.Err(s)
+	case sev >= 4:
+		return x.writer.Warning(s)
+	default:
+		return x.writer.Info(s)
+	}
+}
+
+// Close closes the connection to the syslog endpoint.
+func (x *exporter) Close() error {
+	return x.writer.Close()
+}
+
+func parseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", nil
+	}
+	if !urlutil.IsTransportURL(address) {
+		return "", "", fmt.Errorf("%s should be in form proto://address, got %v", addressKey, address)
+	}
+	url, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	// unix socket validation
+	if url.Scheme == "unix" {
+		if _, err := os.Stat(url.Path); err != nil {
+			return "", "", err
+		}
+		return url.Scheme, url.Path, nil
+	}
+
+	// here we process tcp|udp
+	host := url.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		if !strings.Contains(err.Error(), "missing port in address") {
+			return "", "", err
+		}
+		host = host + ":514"
+	}
+
+	return url.Scheme, host, nil
+}
+
+func parseFacility(facility string) (syslog.Priority, error) {
+	if facility == "" {
+		return syslog.LOG_AUTHPRIV, nil
+	}
+
+	if syslogFacility, valid := facilities[facility]; valid {
+		return syslogFacility, nil
+	}
+
+	fInt, err := strconv.Atoi(facility)
+	if err == nil && 0 <= fInt && fInt <= 23 {
+		return syslog.Priority(fInt << 3), nil
+	}
+
+	return syslog.Priority(0), errors.New("invalid syslog facility")
+}
+
+func parseTLSConfig(options map[string]string) (*tls.Config, error) {
+	_, skipVerify := options[tlsSkipVerifyKey]
+
+	opts := tlsconfig.Options{
+		CAFile:             options[tlsCACertKey],
+		CertFile:           options[tlsCertKey],
+		KeyFile:            options[tlsKeyKey],
+		InsecureSkipVerify: skipVerify,
+	}
+
+	return tlsconfig.Client(opts)
+}
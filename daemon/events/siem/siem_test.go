@@ -0,0 +1,59 @@
+// +build linux
+
+package siem
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	x, err := New(map[string]string{
+		addressKey: "udp://" + c.LocalAddr().String(),
+		formatKey:  "leef",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.Export(testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	buf := make([]byte, 64*1024)
+	n, _, err := c.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// kill events are sent with the error severity, of the authpriv
+	// facility by default.
+	if !strings.HasPrefix(msg, "<83>") {
+		t.Fatalf("Unexpected priority in %q", msg)
+	}
+	if !strings.Contains(msg, tag+"[") || !strings.Contains(msg, "LEEF:1.0|Docker|") {
+		t.Fatalf("Expected a LEEF event, got %q", msg)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	for _, options := range []map[string]string{
+		{"siem-unknown": "x"},
+		{formatKey: "json"},
+		{facilityKey: "nope"},
+		{addressKey: "localhost:514"},
+	} {
+		if _, err := New(options); err == nil {
+			t.Fatalf("Expected an error for %v", options)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/libtrust"
+)
+
+// hmacAlgorithm identifies the signatures made with an HMAC secret.
+const hmacAlgorithm = "HS256"
+
+// Signer signs the events, so that the systems receiving them can verify
+// they were not modified after the daemon logged them.
+type Signer interface {
+	// Sign returns the signature of a payload, as the name of the
+	// algorithm and the signature encoded in base64, separated by a colon.
+	Sign(payload []byte) (string, error)
+}
+
+// SignedPayload returns the payload of the signature of an event: its JSON
+// encoding without its signature and its deprecated fields, which are only
+// added to the events sent to the clients of older API versions.
+func SignedPayload(m eventtypes.Message) ([]byte, error) {
+	m.Signature = ""
+	m.ID = ""
+	m.Status = ""
+	m.From = ""
+	return json.Marshal(m)
+}
+
+type hmacSigner struct {
+	secret []byte
+}
+
+// NewHMACSigner returns a signer of the events computing the HMAC-SHA256 of
+// their payload with a secret.
+func NewHMACSigner(secret []byte) Signer {
+	return &hmacSigner{secret: secret}
+}
+
+func (s *hmacSigner) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return hmacAlgorithm + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+type keySigner struct {
+	key libtrust.PrivateKey
+}
+
+// NewKeySigner returns a signer of the events signing the SHA-256 of their
+// payload with a private key, such as the trust key of the daemon.
+func NewKeySigner(key libtrust.PrivateKey) Signer {
+	return &keySigner{key: key}
+}
+
+func (s *keySigner) Sign(payload []byte) (string, error) {
+	signature, alg, err := s.key.Sign(bytes.NewReader(payload), crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return alg + ":" + base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// NewSigner returns the signer configured by the --events-signing option of
+// the daemon: "trust" signs the events with the trust key of the daemon, and
+// "hmac:<path>" with the secret read from a file.
+func NewSigner(config string, trustKey libtrust.PrivateKey) (Signer, error) {
+	switch {
+	case config == "trust":
+		return NewKeySigner(trustKey), nil
+	case strings.HasPrefix(config, "hmac:"):
+		path := strings.TrimPrefix(config, "hmac:")
+		secret, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't read the events signing secret: %v", err)
+		}
+		secret = bytes.TrimSpace(secret)
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("The events signing secret %s is empty", path)
+		}
+		return NewHMACSigner(secret), nil
+	}
+	return nil, fmt.Errorf("invalid events signing %q, expected trust or hmac:<path>", config)
+}
+
+// errBadSignature is returned when the signature of an event does not match
+// its payload.
+var errBadSignature = errors.New("the signature of the event does not match")
+
+// splitSignature returns the algorithm and the signature of an event.
+func splitSignature(m eventtypes.Message) (string, []byte, error) {
+	i := strings.Index(m.Signature, ":")
+	if i < 0 {
+		return "", nil, errors.New("the event is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(m.Signature[i+1:])
+	if err != nil {
+		return "", nil, fmt.Errorf("malformed signature: %v", err)
+	}
+	return m.Signature[:i], signature, nil
+}
+
+// VerifyHMAC verifies the signature of an event made with an HMAC secret.
+func VerifyHMAC(m eventtypes.Message, secret []byte) error {
+	alg, signature, err := splitSignature(m)
+	if err != nil {
+		return err
+	}
+	if alg != hmacAlgorithm {
+		return fmt.Errorf("unexpected signature algorithm %s", alg)
+	}
+	payload, err := SignedPayload(m)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errBadSignature
+	}
+	return nil
+}
+
+// VerifyKey verifies the signature of an event made with the private key of
+// a public key.
+func VerifyKey(m eventtypes.Message, key libtrust.PublicKey) error {
+	alg, signature, err := splitSignature(m)
+	if err != nil {
+		return err
+	}
+	payload, err := SignedPayload(m)
+	if err != nil {
+		return err
+	}
+	if err := key.Verify(bytes.NewReader(payload), alg, signature); err != nil {
+		return errBadSignature
+	}
+	return nil
+}
+
+// SetSigner makes every event logged carry its signature by s, or stops
+// signing the events when s is nil.
+func (e *Events) SetSigner(s Signer) {
+	e.logMu.Lock()
+	e.signer = s
+	e.logMu.Unlock()
+}
+
+// sign sets the signature of an event, the caller must hold logMu.
+func (e *Events) sign(m *eventtypes.Message) {
+	payload, err := SignedPayload(*m)
+	if err != nil {
+		logrus.Errorf("Error encoding event to sign it: %v", err)
+		return
+	}
+	signature, err := e.signer.Sign(payload)
+	if err != nil {
+		logrus.Errorf("Error signing event: %v", err)
+		return
+	}
+	m.Signature = signature
+}
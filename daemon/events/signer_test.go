@@ -0,0 +1,88 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"github.com/docker/libtrust"
+)
+
+func logSigned(t *testing.T, s Signer) events.Message {
+	e := New(0)
+	e.SetSigner(s)
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{
+		ID:         "cont",
+		Attributes: map[string]string{"image": "busybox", "name": "test"},
+	})
+	recent := e.recent.events()
+	if len(recent) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(recent))
+	}
+	if recent[0].Signature == "" {
+		t.Fatal("Expected the event to be signed")
+	}
+	return recent[0]
+}
+
+func TestHMACSigner(t *testing.T) {
+	secret := []byte("secret")
+	m := logSigned(t, NewHMACSigner(secret))
+	if err := VerifyHMAC(m, secret); err != nil {
+		t.Fatal(err)
+	}
+	// The signature covers the events sent to older API clients too.
+	if err := VerifyHMAC(Translate(m, LegacySchema), secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyHMAC(m, []byte("other")); err != errBadSignature {
+		t.Fatalf("Expected a bad signature with another secret, got %v", err)
+	}
+	m.Actor.Attributes = map[string]string{"image": "evil", "name": "test"}
+	if err := VerifyHMAC(m, secret); err != errBadSignature {
+		t.Fatalf("Expected a bad signature for a modified event, got %v", err)
+	}
+}
+
+func TestKeySigner(t *testing.T) {
+	key, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := logSigned(t, NewKeySigner(key))
+	if err := VerifyKey(m, key.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	m.Sequence++
+	if err := VerifyKey(m, key.PublicKey()); err != errBadSignature {
+		t.Fatalf("Expected a bad signature for a modified event, got %v", err)
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(path, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSigner("hmac:"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyHMAC(logSigned(t, s), []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	for _, config := range []string{"hmac:" + filepath.Join(dir, "missing"), "rsa", ""} {
+		if _, err := NewSigner(config, nil); err == nil {
+			t.Fatalf("Expected an error for %q", config)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// NewLogSink returns an exporter writing the events to the system log
+// named by kind, which is either syslog or journald.
+func NewLogSink(kind string) (Exporter, error) {
+	var (
+		x   Exporter
+		err error
+	)
+	switch kind {
+	case "syslog":
+		x, err = newSyslogWriter()
+	case "journald":
+		x, err = newJournaldWriter()
+	default:
+		return nil, fmt.Errorf("invalid events sink: %s", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s events sink: %v", kind, err)
+	}
+	return x, nil
+}
+
+// journaldVars returns the journal fields describing ev. Attribute
+// names are upper cased, and characters not allowed in field names
+// are replaced by underscores.
+func journaldVars(ev eventtypes.Message) map[string]string {
+	vars := map[string]string{
+		"DOCKER_EVENT_TYPE":      ev.Type,
+		"DOCKER_EVENT_ACTION":    ev.Action,
+		"DOCKER_EVENT_ACTOR_ID":  ev.Actor.ID,
+		"DOCKER_EVENT_TIME_NANO": strconv.FormatInt(ev.TimeNano, 10),
+	}
+	for k, v := range ev.Actor.Attributes {
+		vars["DOCKER_EVENT_ATTR_"+journaldFieldName(k)] = v
+	}
+	return vars
+}
+
+func journaldFieldName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, s)
+}
+
+// sinkMessage returns the human readable summary of ev used as the
+// message of system log entries.
+func sinkMessage(ev eventtypes.Message) string {
+	msg := ev.Type + " " + ev.Action
+	if ev.Actor.ID != "" {
+		msg += " " + ev.Actor.ID
+	}
+	return msg
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	syslog "github.com/RackSec/srslog"
+	"github.com/coreos/go-systemd/journal"
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+const sinkTag = "docker-events"
+
+// syslogWriter writes events to the local syslog, as JSON encoded
+// messages.
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func newSyslogWriter() (Exporter, error) {
+	w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, sinkTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) Export(ev eventtypes.Message) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(b))
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+// journaldWriter writes events to the systemd journal, with the event
+// details stored in DOCKER_EVENT_* fields.
+type journaldWriter struct{}
+
+func newJournaldWriter() (Exporter, error) {
+	if !journal.Enabled() {
+		return nil, fmt.Errorf("journald is not enabled on this host")
+	}
+	return journaldWriter{}, nil
+}
+
+func (journaldWriter) Export(ev eventtypes.Message) error {
+	vars := journaldVars(ev)
+	vars["SYSLOG_IDENTIFIER"] = sinkTag
+	return journal.Send(sinkMessage(ev), journal.PriInfo, vars)
+}
+
+func (journaldWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestJournaldVars(t *testing.T) {
+	ev := events.Message{
+		Message: eventtypes.Message{
+			Type:   events.ContainerEventType,
+			Action: "start",
+			Actor: eventtypes.Actor{
+				ID: "cont",
+				Attributes: map[string]string{
+					"name":              "web",
+					"com.example.owner": "ops",
+				},
+			},
+			TimeNano: 42,
+		},
+	}
+	expected := map[string]string{
+		"DOCKER_EVENT_TYPE":                   "container",
+		"DOCKER_EVENT_ACTION":                 "start",
+		"DOCKER_EVENT_ACTOR_ID":               "cont",
+		"DOCKER_EVENT_TIME_NANO":              "42",
+		"DOCKER_EVENT_ATTR_NAME":              "web",
+		"DOCKER_EVENT_ATTR_COM_EXAMPLE_OWNER": "ops",
+	}
+	vars := journaldVars(ev)
+	if len(vars) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, vars)
+	}
+	for k, v := range expected {
+		if vars[k] != v {
+			t.Fatalf("Expected %s=%s, got %s", k, v, vars[k])
+		}
+	}
+	if msg := sinkMessage(ev); msg != "container start cont" {
+		t.Fatalf("Unexpected message %q", msg)
+	}
+}
+
+func TestNewLogSinkInvalid(t *testing.T) {
+	if _, err := NewLogSink("file"); err == nil {
+		t.Fatal("Expected an error for an invalid sink")
+	}
+}
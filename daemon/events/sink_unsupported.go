@@ -0,0 +1,15 @@
+// +build !linux
+
+package events
+
+import "errors"
+
+var errSinkNotSupported = errors.New("events sinks are only supported on linux")
+
+func newSyslogWriter() (Exporter, error) {
+	return nil, errSinkNotSupported
+}
+
+func newJournaldWriter() (Exporter, error) {
+	return nil, errSinkNotSupported
+}
@@ -0,0 +1,351 @@
+package events
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+const (
+	// snapshotVersion is the version of the format of the snapshots.
+	snapshotVersion = 1
+	// snapshotMetadataFile and snapshotEventsFile are the entries of the
+	// archive of a snapshot, the events are stored as in the journal.
+	snapshotMetadataFile = "metadata.json"
+	snapshotEventsFile   = journalFileName
+	// maxSnapshotMetadata is the largest size of the metadata of a
+	// snapshot imported.
+	maxSnapshotMetadata = 1 << 20
+	// maxSnapshotEntry is the largest size of an event of a snapshot
+	// imported, and maxSnapshotEvents the largest size of all its events.
+	maxSnapshotEntry  = 1 << 20
+	maxSnapshotEvents = 256 << 20
+	// maxSnapshots is the number of snapshots kept, the oldest are
+	// removed by the imports of new ones.
+	maxSnapshots = 8
+	// snapshotsDir is the directory of the snapshots imported, next to
+	// the journal.
+	snapshotsDir = "snapshots"
+)
+
+// SnapshotOptions holds the parameters of an export of the events.
+type SnapshotOptions struct {
+	// Since and SinceNano are the timestamp of the oldest event exported.
+	// Since is -1 to start at the oldest event in the journal.
+	Since, SinceNano int64
+	// Until and UntilNano are the timestamp of the newest event
+	// exported. Until is -1 to not set an upper bound.
+	Until, UntilNano int64
+	// Owner is the tenant exporting the events when the event stream is
+	// scoped per tenant, only the events of the objects it owns are
+	// exported.
+	Owner string
+	// ACL restricts the events exported, if any.
+	ACL *ACL
+	// Anonymizer hashes the events exported, if it is set.
+	Anonymizer *Anonymizer
+}
+
+// ExportSnapshot writes to w the events of the journal logged between the
+// timestamps of opts, as a gzip compressed tar archive holding the events
+// in the format of the journal and the metadata of the snapshot, so that
+// they can be imported by another daemon with ImportSnapshot.
+func (e *Events) ExportSnapshot(w io.Writer, opts SnapshotOptions) error {
+	e.mu.Lock()
+	journal := e.journal
+	e.mu.Unlock()
+	if journal == nil {
+		return derr.ErrorCodeNoEventsJournal
+	}
+
+	meta := eventtypes.Snapshot{
+		Version: snapshotVersion,
+		Schema:  int(CurrentSchema),
+		Created: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if opts.Since != -1 {
+		meta.Since = time.Unix(opts.Since, opts.SinceNano).UTC().Format(time.RFC3339Nano)
+	}
+	if opts.Until != -1 {
+		meta.Until = time.Unix(opts.Until, opts.UntilNano).UTC().Format(time.RFC3339Nano)
+	}
+
+	// The size of the events must be known before they are archived.
+	tmp, err := ioutil.TempFile("", "events-snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	bw := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(bw)
+	sinceTime := time.Unix(opts.Since, opts.SinceNano).UnixNano()
+	var encErr error
+	err = journal.Walk(journal.Size(), func(ev eventtypes.Message) bool {
+		if opts.Until != -1 && after(ev, opts.Until, opts.UntilNano) {
+			return false
+		}
+		if opts.Since != -1 && ev.TimeNano < sinceTime {
+			return true
+		}
+		if opts.Owner != "" && !ownedBy(ev, opts.Owner) {
+			return true
+		}
+		if opts.ACL != nil && !opts.ACL.Include(ev) {
+			return true
+		}
+		if opts.Anonymizer != nil {
+			ev = opts.Anonymizer.Anonymize(ev)
+		}
+		if encErr = enc.Encode(ev); encErr != nil {
+			return false
+		}
+		if meta.Events == 0 {
+			meta.FirstSequence = ev.Sequence
+		}
+		meta.Events++
+		meta.LastSequence = ev.Sequence
+		return true
+	})
+	if err == nil {
+		err = encErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, 2)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+	if err := tw.WriteHeader(&tar.Header{Name: snapshotMetadataFile, Mode: 0600, Size: int64(len(b)), ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: snapshotEventsFile, Mode: 0600, Size: size, ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, tmp); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ImportSnapshot stores the snapshot read from r, as written by
+// ExportSnapshot, next to the journal, and returns its metadata with the
+// ID its events can be read with by History. The events of a snapshot
+// cannot be larger than 256MB, and only the last 8 snapshots imported are
+// kept.
+func (e *Events) ImportSnapshot(r io.Reader) (eventtypes.Snapshot, error) {
+	e.mu.Lock()
+	journal := e.journal
+	e.mu.Unlock()
+	if journal == nil {
+		return eventtypes.Snapshot{}, derr.ErrorCodeNoEventsJournal
+	}
+
+	e.importMu.Lock()
+	defer e.importMu.Unlock()
+	root := filepath.Join(filepath.Dir(journal.path), snapshotsDir)
+	id := stringid.TruncateID(stringid.GenerateRandomID())
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return eventtypes.Snapshot{}, err
+	}
+	meta, err := importSnapshot(r, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return eventtypes.Snapshot{}, err
+	}
+	meta.ID = id
+	b, err := json.Marshal(meta)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, snapshotMetadataFile), b, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return eventtypes.Snapshot{}, err
+	}
+	if err := pruneSnapshots(root, maxSnapshots); err != nil {
+		logrus.Errorf("Error removing the old events snapshots: %v", err)
+	}
+	return meta, nil
+}
+
+// pruneSnapshots removes the oldest snapshots imported in root, to keep
+// only max of them.
+func pruneSnapshots(root string, max int) error {
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	var snapshots []os.FileInfo
+	for _, d := range dirs {
+		if d.IsDir() && stringid.IsShortID(d.Name()) {
+			snapshots = append(snapshots, d)
+		}
+	}
+	if len(snapshots) <= max {
+		return nil
+	}
+	sort.Sort(byModTime(snapshots))
+	for _, d := range snapshots[:len(snapshots)-max] {
+		if err := os.RemoveAll(filepath.Join(root, d.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byModTime sorts files from the least recently modified.
+type byModTime []os.FileInfo
+
+func (f byModTime) Len() int           { return len(f) }
+func (f byModTime) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f byModTime) Less(i, j int) bool { return f[i].ModTime().Before(f[j].ModTime()) }
+
+// importSnapshot extracts the snapshot read from r in dir, and checks
+// that its events match its metadata.
+func importSnapshot(r io.Reader, dir string) (eventtypes.Snapshot, error) {
+	var (
+		meta      eventtypes.Snapshot
+		hasMeta   bool
+		hasEvents bool
+		events    int
+		last      uint64
+	)
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return meta, fmt.Errorf("bad parameter: invalid events snapshot: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return meta, fmt.Errorf("bad parameter: invalid events snapshot: %v", err)
+		}
+		switch hdr.Name {
+		case snapshotMetadataFile:
+			if err := json.NewDecoder(io.LimitReader(tr, maxSnapshotMetadata)).Decode(&meta); err != nil {
+				return meta, fmt.Errorf("bad parameter: invalid events snapshot metadata: %v", err)
+			}
+			hasMeta = true
+		case snapshotEventsFile:
+			if hdr.Size > maxSnapshotEvents {
+				return meta, fmt.Errorf("bad parameter: invalid events snapshot: the events cannot be larger than %d bytes", maxSnapshotEvents)
+			}
+			if events, last, err = copySnapshotEvents(filepath.Join(dir, snapshotEventsFile), tr); err != nil {
+				return meta, err
+			}
+			hasEvents = true
+		}
+	}
+	if !hasMeta || !hasEvents {
+		return meta, fmt.Errorf("bad parameter: invalid events snapshot: %s or %s is missing", snapshotMetadataFile, snapshotEventsFile)
+	}
+	if meta.Version < 1 || meta.Version > snapshotVersion {
+		return meta, fmt.Errorf("bad parameter: unsupported events snapshot version %d", meta.Version)
+	}
+	if events != meta.Events || last != meta.LastSequence {
+		return meta, fmt.Errorf("bad parameter: invalid events snapshot: %d events up to %d, the metadata tells %d up to %d", events, last, meta.Events, meta.LastSequence)
+	}
+	return meta, nil
+}
+
+// copySnapshotEvents writes the events read from r to the file path, and
+// returns their number and the sequence number of the last one. The
+// events must be in the order of their sequence numbers, and at most
+// maxSnapshotEntry bytes long each.
+func copySnapshotEvents(path string, r io.Reader) (int, uint64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	// The reader is as large as the longest entry, ReadSlice fails on
+	// the longer ones.
+	br := bufio.NewReaderSize(io.LimitReader(r, maxSnapshotEvents), maxSnapshotEntry)
+	var (
+		count int
+		last  uint64
+	)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err == bufio.ErrBufferFull {
+			return 0, 0, fmt.Errorf("bad parameter: invalid events snapshot: entry %d is longer than %d bytes", count+1, maxSnapshotEntry)
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad parameter: invalid events snapshot: entry %d is incomplete", count+1)
+		}
+		var ev eventtypes.Message
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return 0, 0, fmt.Errorf("bad parameter: invalid events snapshot: entry %d: %v", count+1, err)
+		}
+		if count > 0 && ev.Sequence != 0 && ev.Sequence <= last {
+			return 0, 0, fmt.Errorf("bad parameter: invalid events snapshot: entry %d is out of order", count+1)
+		}
+		if _, err := w.Write(line); err != nil {
+			return 0, 0, err
+		}
+		count++
+		last = ev.Sequence
+	}
+	if err := w.Flush(); err != nil {
+		return 0, 0, err
+	}
+	return count, last, f.Sync()
+}
+
+// snapshotJournal opens the journal of the events of the snapshot id
+// imported next to journal.
+func snapshotJournal(journal *Journal, id string) (*Journal, error) {
+	if !stringid.IsShortID(id) {
+		return nil, derr.ErrorCodeNoSuchEventsSnapshot.WithArgs(id)
+	}
+	dir := filepath.Join(filepath.Dir(journal.path), snapshotsDir, id)
+	if _, err := os.Stat(filepath.Join(dir, snapshotMetadataFile)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, derr.ErrorCodeNoSuchEventsSnapshot.WithArgs(id)
+		}
+		return nil, err
+	}
+	return NewJournal(dir)
+}
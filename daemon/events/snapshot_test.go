@@ -0,0 +1,169 @@
+package events
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestSnapshot(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(tmp + "/source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := New(0)
+	source.SetJournal(j)
+	defer source.Close()
+	for _, action := range []string{"create", "start", "die"} {
+		source.Log(action, events.ContainerEventType, eventtypes.Actor{ID: "cont", Attributes: map[string]string{"name": "db"}})
+	}
+	var buf bytes.Buffer
+	if err := source.ExportSnapshot(&buf, SnapshotOptions{Since: -1, Until: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = NewJournal(tmp + "/target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := New(0)
+	target.SetJournal(j)
+	defer target.Close()
+	target.Log("start", events.DaemonEventType, eventtypes.Actor{ID: "daemon"})
+	snapshot, err := target.ImportSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.ID == "" || snapshot.Version != snapshotVersion || snapshot.Events != 3 || snapshot.FirstSequence != 1 || snapshot.LastSequence != 3 || snapshot.Since != "" {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, snapshot.Created); err != nil {
+		t.Fatalf("Unexpected creation time of the snapshot %q", snapshot.Created)
+	}
+
+	// The events of the snapshot are read from the history, apart from
+	// the events of the daemon.
+	history, err := target.History(HistoryOptions{Since: -1, Until: -1, Snapshot: snapshot.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Events) != 3 || history.Events[2].Action != "die" || history.Events[2].Actor.Attributes["name"] != "db" {
+		t.Fatalf("Unexpected events of the snapshot %+v", history.Events)
+	}
+	if history, err = target.History(HistoryOptions{Since: -1, Until: -1}); err != nil || len(history.Events) != 1 {
+		t.Fatalf("Expected the journal not modified, got %+v, %v", history.Events, err)
+	}
+	for _, id := range []string{"0123456789ab", "../source"} {
+		if _, err := target.History(HistoryOptions{Since: -1, Until: -1, Snapshot: id}); err == nil {
+			t.Fatalf("Expected an error reading the snapshot %q", id)
+		}
+	}
+
+	// The export can be anonymized and bounded in time.
+	buf.Reset()
+	if err := source.ExportSnapshot(&buf, SnapshotOptions{Since: -1, Until: -1, Anonymizer: NewAnonymizer()}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot, err = target.ImportSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	history, err = target.History(HistoryOptions{Since: -1, Until: -1, Snapshot: snapshot.ID})
+	if err != nil || len(history.Events) != 3 || history.Events[0].Actor.Attributes["name"] == "db" {
+		t.Fatalf("Expected the events anonymized, got %+v, %v", history.Events, err)
+	}
+	buf.Reset()
+	if err := source.ExportSnapshot(&buf, SnapshotOptions{Since: -1, Until: 0, UntilNano: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot, err = target.ImportSnapshot(&buf); err != nil || snapshot.Events != 0 || snapshot.Until == "" {
+		t.Fatalf("Unexpected snapshot %+v, %v", snapshot, err)
+	}
+
+	if _, err := target.ImportSnapshot(bytes.NewReader([]byte("not a snapshot"))); err == nil {
+		t.Fatal("Expected an error importing an invalid snapshot")
+	}
+}
+
+func TestSnapshotLimits(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "events-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	j, err := NewJournal(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	e.SetJournal(j)
+	defer e.Close()
+
+	// An event longer than maxSnapshotEntry is not read in memory.
+	entry := `{"Type":"container","Action":"` + strings.Repeat("x", maxSnapshotEntry) + `"}` + "\n"
+	if _, err := e.ImportSnapshot(snapshotArchive(t, `{"Version":1,"Events":1}`, entry)); err == nil || !strings.Contains(err.Error(), "longer than") {
+		t.Fatalf("Expected an error importing a too long event, got %v", err)
+	}
+	if dirs, _ := ioutil.ReadDir(filepath.Join(tmp, snapshotsDir)); len(dirs) != 0 {
+		t.Fatalf("Expected the snapshot removed, got %d", len(dirs))
+	}
+
+	// Only the last snapshots imported are kept.
+	var ids []string
+	for i := 0; i < 4; i++ {
+		snapshot, err := e.ImportSnapshot(snapshotArchive(t, `{"Version":1,"Events":1,"LastSequence":1}`, `{"Type":"container","Action":"start","Sequence":1}`+"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, snapshot.ID)
+		// The modification times order the snapshots.
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(filepath.Join(tmp, snapshotsDir, snapshot.ID), old, old)
+	}
+	if err := pruneSnapshots(filepath.Join(tmp, snapshotsDir), 2); err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		_, err := e.History(HistoryOptions{Since: -1, Until: -1, Snapshot: id})
+		if kept := err == nil; kept != (i >= 2) {
+			t.Fatalf("Expected the snapshot %d kept: %v, got %v", i, i >= 2, err)
+		}
+	}
+}
+
+// snapshotArchive returns the archive of a snapshot holding the metadata
+// and the events.
+func snapshotArchive(t *testing.T, metadata, events string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct{ name, content string }{{snapshotMetadataFile, metadata}, {snapshotEventsFile, events}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
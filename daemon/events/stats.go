@@ -0,0 +1,134 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+const (
+	// statsResolution is the duration counted by each bucket of the
+	// statistics of the events.
+	statsResolution = 10 * time.Second
+	// maxStatsWindow is the longest window of the statistics.
+	maxStatsWindow = time.Hour
+	statsBuckets   = int64(maxStatsWindow / statsResolution)
+)
+
+// DefaultStatsWindows are the windows of the statistics of the events
+// returned when none is requested.
+var DefaultStatsWindows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// statsKey is the type and the action of events.
+type statsKey struct {
+	eventType, action string
+}
+
+// statsBucket counts the events logged during a period of statsResolution.
+type statsBucket struct {
+	// period is the number of the period counted, since the epoch.
+	period int64
+	counts map[statsKey]uint64
+	// shed is the number of verbose events dropped instead of logged.
+	shed uint64
+}
+
+// eventStats counts the events logged during the last hour, by type and
+// action, in buckets of 10 seconds. The action of the exec and health
+// status events is counted without its details.
+type eventStats struct {
+	mu      sync.Mutex
+	buckets [statsBuckets]statsBucket
+}
+
+// add counts an event logged at now.
+func (s *eventStats) add(now time.Time, eventType, action string) {
+	if i := strings.Index(action, ":"); i > 0 {
+		action = action[:i]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bucket(now).counts[statsKey{eventType, action}]++
+}
+
+// addShed counts a verbose event dropped at now instead of logged.
+func (s *eventStats) addShed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bucket(now).shed++
+}
+
+// bucket returns the bucket counting the events logged at now, the caller
+// must hold mu.
+func (s *eventStats) bucket(now time.Time) *statsBucket {
+	period := now.UnixNano() / int64(statsResolution)
+	b := &s.buckets[period%statsBuckets]
+	if b.period != period || b.counts == nil {
+		*b = statsBucket{period: period, counts: make(map[statsKey]uint64)}
+	}
+	return b
+}
+
+// window returns the number of events logged during the window ending at
+// now.
+func (s *eventStats) window(now time.Time, window time.Duration) eventtypes.WindowStats {
+	last := now.UnixNano() / int64(statsResolution)
+	first := last - int64(window/statsResolution) + 1
+	stats := eventtypes.WindowStats{
+		Window: window.String(),
+		Counts: make(map[string]map[string]uint64),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		if b.period < first || b.period > last {
+			continue
+		}
+		stats.Shed += b.shed
+		for k, n := range b.counts {
+			actions := stats.Counts[k.eventType]
+			if actions == nil {
+				actions = make(map[string]uint64)
+				stats.Counts[k.eventType] = actions
+			}
+			actions[k.action] += n
+			stats.Total += n
+		}
+	}
+	return stats
+}
+
+// ParseStatsWindows parses the comma separated durations of the windows
+// of the statistics of the events, which are multiples of 10 seconds up to
+// an hour.
+func ParseStatsWindows(value string) ([]time.Duration, error) {
+	if value == "" {
+		return DefaultStatsWindows, nil
+	}
+	var windows []time.Duration
+	for _, v := range strings.Split(value, ",") {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxStatsWindow || d%statsResolution != 0 {
+			return nil, fmt.Errorf("bad parameter: invalid window %q, must be a multiple of %s up to %s", v, statsResolution, maxStatsWindow)
+		}
+		windows = append(windows, d)
+	}
+	return windows, nil
+}
+
+// Stats returns the number of events logged by type and action during
+// each window, ending now. The counts are accurate to 10 seconds.
+func (e *Events) Stats(windows []time.Duration) []eventtypes.WindowStats {
+	now := time.Now()
+	stats := make([]eventtypes.WindowStats, 0, len(windows))
+	for _, w := range windows {
+		stats = append(stats, e.stats.window(now, w))
+	}
+	return stats
+}
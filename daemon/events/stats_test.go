@@ -0,0 +1,52 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+)
+
+func TestEventStats(t *testing.T) {
+	var s eventStats
+	now := time.Unix(1442421700, 0)
+	s.add(now.Add(-2*time.Hour), events.ContainerEventType, "start")
+	s.add(now.Add(-30*time.Minute), events.ContainerEventType, "start")
+	s.add(now.Add(-3*time.Minute), events.ContainerEventType, "die")
+	s.add(now.Add(-5*time.Second), events.ContainerEventType, "exec_start: sh -c true")
+	s.add(now, events.ImageEventType, "pull")
+
+	for _, c := range []struct {
+		window time.Duration
+		total  uint64
+		exec   uint64
+	}{
+		{time.Minute, 2, 1},
+		{5 * time.Minute, 3, 1},
+		{time.Hour, 4, 1},
+	} {
+		stats := s.window(now, c.window)
+		if stats.Total != c.total || stats.Counts["container"]["exec_start"] != c.exec || stats.Counts["image"]["pull"] != 1 {
+			t.Fatalf("Unexpected stats of %s: %+v", c.window, stats)
+		}
+	}
+
+	if _, err := ParseStatsWindows("1m,5m,1h"); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"2h", "15s", "0s", "1m,"} {
+		if _, err := ParseStatsWindows(w); err == nil {
+			t.Fatalf("Expected an error parsing %q", w)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	e := New(0)
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	stats := e.Stats(DefaultStatsWindows)
+	if len(stats) != 3 || stats[0].Window != "1m0s" || stats[0].Counts["container"]["start"] != 2 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+}
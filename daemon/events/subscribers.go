@@ -0,0 +1,193 @@
+package events
+
+import (
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+// subscriber describes a subscription to the events.
+type subscriber struct {
+	// dropped and wait are accessed atomically, they are kept first to
+	// be 64-bit aligned on 32-bit platforms.
+	dropped uint64
+	// wait is how long the subscriber is waited for before applying its
+	// policy, in nanoseconds, when its timeout is adaptive.
+	wait int64
+	id   uint64
+	ch   chan eventtypes.Message
+	// evicted is closed when the subscriber is evicted.
+	evicted chan struct{}
+	topic   func(eventtypes.Message) bool
+	// match is topic without the sampling, it selects the verbose events
+	// shed that are counted as dropped for the subscriber.
+	match   func(eventtypes.Message) bool
+	policy  Policy
+	timeout time.Duration
+	// adaptive is true if the subscriber is waited for less than its
+	// timeout when it is slow to receive the events.
+	adaptive bool
+	// priority is the minimum priority of the events the subscriber
+	// receives.
+	priority Priority
+	client   string
+	// owner is the tenant subscribing when the event stream is scoped
+	// per tenant.
+	owner   string
+	filters map[string][]string
+	created time.Time
+	pause   pause
+}
+
+// stats returns the delivery statistics of the subscriber.
+func (s *subscriber) stats() SubscriberStats {
+	return SubscriberStats{
+		ID:      s.id,
+		Dropped: atomic.LoadUint64(&s.dropped),
+		Queued:  len(s.ch) + s.pause.queued(),
+	}
+}
+
+// currentTimeout returns how long the subscriber is waited for before
+// applying its policy.
+func (s *subscriber) currentTimeout() time.Duration {
+	if !s.adaptive {
+		return s.timeout
+	}
+	return time.Duration(atomic.LoadInt64(&s.wait))
+}
+
+// observe adapts the timeout of the subscriber after it received an event
+// it was waited for d, or failed to receive it in time. The timeout is
+// halved each time the subscriber is not ready in time, down to
+// minPublishTimeout, so that a slow subscriber does not hold the events
+// of the others, and grows back to the timeout of the subscriber, and at
+// least to twice the time it took, as it receives the events in time.
+func (s *subscriber) observe(sent bool, d time.Duration) {
+	if !s.adaptive {
+		return
+	}
+	wait := s.currentTimeout()
+	if sent {
+		wait *= 2
+		if wait < 2*d {
+			wait = 2 * d
+		}
+		if wait > s.timeout {
+			wait = s.timeout
+		}
+	} else {
+		wait /= 2
+		if wait < minPublishTimeout {
+			wait = minPublishTimeout
+		}
+	}
+	atomic.StoreInt64(&s.wait, int64(wait))
+}
+
+// waits returns true if events are sent to the subscriber with a
+// timeout when it is not ready to receive them.
+func (s *subscriber) waits() bool {
+	if s.policy == DropOldest && cap(s.ch) > 0 {
+		return false
+	}
+	return s.policy != DropNewest && s.timeout > 0
+}
+
+// timeoutName returns the current timeout of the subscriber, or an empty
+// string if it is not waited for.
+func (s *subscriber) timeoutName() string {
+	if !s.waits() {
+		return ""
+	}
+	return s.currentTimeout().String()
+}
+
+// Subscribers returns the description of every current subscriber of the
+// tenant owner, or of all the subscribers when owner is empty, in the order
+// they subscribed.
+func (e *Events) Subscribers(owner string) []eventtypes.Subscriber {
+	e.pub.mu.RLock()
+	defer e.pub.mu.RUnlock()
+
+	list := make([]eventtypes.Subscriber, 0, len(e.pub.subscribers))
+	for _, s := range e.pub.subscribers {
+		if owner != "" && s.owner != owner {
+			continue
+		}
+		stats := s.stats()
+		d := eventtypes.Subscriber{
+			ID:      stats.ID,
+			Client:  s.client,
+			Filters: s.filters,
+			Policy:  policyName(s.policy),
+			Created: s.created.Format(time.RFC3339Nano),
+			Queued:  stats.Queued,
+			Dropped: stats.Dropped,
+			Paused:  s.pause.holding(),
+			Timeout: s.timeoutName(),
+		}
+		if s.priority > PriorityVerbose {
+			d.Priority = s.priority.String()
+		}
+		list = append(list, d)
+	}
+	sort.Sort(byID(list))
+	return list
+}
+
+// EvictSubscriber evicts the subscriber with the given ID, among the
+// subscribers of the tenant owner when it is set, closing its channel,
+// which ends its stream of events.
+func (e *Events) EvictSubscriber(id uint64, owner string) error {
+	s, err := e.findSubscriber(id, owner)
+	if err != nil {
+		return err
+	}
+	e.Evict(s.ch)
+	return nil
+}
+
+// byID sorts subscribers by ID.
+type byID []eventtypes.Subscriber
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// policyName returns the name of a delivery policy.
+func policyName(p Policy) string {
+	for name, policy := range policies {
+		if policy == p {
+			return name
+		}
+	}
+	return ""
+}
+
+// filterValues returns the values of each filter of args.
+func filterValues(args filters.Args) map[string][]string {
+	if args.Len() == 0 {
+		return nil
+	}
+	param, err := filters.ToParam(args)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]map[string]bool
+	if err := json.Unmarshal([]byte(param), &fields); err != nil {
+		return nil
+	}
+	values := make(map[string][]string, len(fields))
+	for field, v := range fields {
+		for value := range v {
+			values[field] = append(values[field], value)
+		}
+		sort.Strings(values[field])
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestSubscribers(t *testing.T) {
+	e := New(0)
+	_, l1 := e.Subscribe(context.Background())
+
+	ef := filters.NewArgs()
+	ef.Add("type", "container")
+	ef.Add("event", "start")
+	ef.Add("event", "die")
+	_, l2 := e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Filter: NewFilter(ef),
+		Policy: DropNewest,
+		Client: "alice",
+	})
+	defer e.Evict(l2)
+
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	<-l1
+
+	subscribers := e.Subscribers("")
+	if len(subscribers) != 2 {
+		t.Fatalf("Expected 2 subscribers, got %v", subscribers)
+	}
+	s := subscribers[1]
+	if s.ID <= subscribers[0].ID || s.Client != "alice" || s.Policy != "drop-newest" || s.Queued != 1 {
+		t.Fatalf("Unexpected subscriber %+v", s)
+	}
+	expected := map[string][]string{"type": {"container"}, "event": {"die", "start"}}
+	if !reflect.DeepEqual(s.Filters, expected) {
+		t.Fatalf("Expected filters %v, got %v", expected, s.Filters)
+	}
+	if created, err := time.Parse(time.RFC3339Nano, s.Created); err != nil || time.Since(created) > time.Minute {
+		t.Fatalf("Unexpected creation time %s: %v", s.Created, err)
+	}
+	if p := subscribers[0].Policy; p != "block" {
+		t.Fatalf("Expected block policy, got %s", p)
+	}
+
+	if err := e.EvictSubscriber(subscribers[0].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, open := <-l1; open {
+		t.Fatal("Expected the channel of the evicted subscriber to be closed")
+	}
+	if err := e.EvictSubscriber(subscribers[0].ID, ""); err == nil {
+		t.Fatal("Expected an error evicting a subscriber twice")
+	}
+	if subscribers := e.Subscribers(""); len(subscribers) != 1 || subscribers[0].Client != "alice" {
+		t.Fatalf("Expected the subscriber of alice, got %v", subscribers)
+	}
+}
+
+func TestAdaptiveTimeout(t *testing.T) {
+	e := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, slow := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Client: "dashboard"})
+	_, fixed := e.SubscribeWithOptions(ctx, SubscribeOptions{Since: -1, Until: -1, Timeout: 10 * time.Millisecond, Client: "exporter"})
+	go func() {
+		for range fixed {
+		}
+	}()
+
+	// Once the queue of the slow subscriber is full, it is waited for
+	// less and less.
+	for i := 0; i < bufferSize+10; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	e.Flush()
+	subscribers := e.Subscribers("")
+	if len(subscribers) != 2 || subscribers[0].Timeout != minPublishTimeout.String() || subscribers[1].Timeout != "10ms" {
+		t.Fatalf("Expected the timeout of the slow subscriber shortened, got %+v", subscribers)
+	}
+	start := time.Now()
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Flush()
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("Expected the slow subscriber not to hold the events, took %s", d)
+	}
+
+	// It is waited for as long as before once it receives the events.
+	go func() {
+		for range slow {
+		}
+	}()
+	waitFor(t, "the events received", func() bool { return e.Subscribers("")[0].Queued == 0 })
+	for i := 0; i < 20; i++ {
+		e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+		time.Sleep(time.Millisecond)
+	}
+	e.Flush()
+	if timeout := e.Subscribers("")[0].Timeout; timeout != publishTimeout.String() {
+		t.Fatalf("Expected the timeout of the subscriber restored, got %s", timeout)
+	}
+}
+
+func TestSubscribersTenants(t *testing.T) {
+	e := New(0)
+	defer e.Close()
+	e.SetTenantScoping(true, "")
+	ids := make(map[string]uint64)
+	for _, tenant := range []string{"alice", "bob"} {
+		_, l := e.SubscribeWithOptions(context.Background(), SubscribeOptions{Since: -1, Until: -1, Owner: tenant})
+		defer e.Evict(l)
+		subscribers := e.Subscribers(tenant)
+		if len(subscribers) != 1 {
+			t.Fatalf("Expected only the subscriber of %s, got %+v", tenant, subscribers)
+		}
+		ids[tenant] = subscribers[0].ID
+	}
+	if subscribers := e.Subscribers(""); len(subscribers) != 2 {
+		t.Fatalf("Expected 2 subscribers, got %+v", subscribers)
+	}
+	if e.Admin("alice") {
+		t.Fatal("Expected no admin when the stream is scoped per tenant without access control lists")
+	}
+
+	if err := e.EvictSubscriber(ids["bob"], "alice"); err == nil {
+		t.Fatal("Expected an error evicting the subscriber of another tenant")
+	}
+	if err := e.PauseSubscriber(ids["bob"], 0, "alice"); err == nil {
+		t.Fatal("Expected an error pausing the subscriber of another tenant")
+	}
+	if err := e.ResumeSubscriber(ids["bob"], "alice"); err == nil {
+		t.Fatal("Expected an error resuming the subscriber of another tenant")
+	}
+	if err := e.EvictSubscriber(ids["bob"], "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if subscribers := e.Subscribers(""); len(subscribers) != 1 || subscribers[0].ID != ids["alice"] {
+		t.Fatalf("Expected only the subscriber of alice left, got %+v", subscribers)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+// containerStates are the states the containers enter on each action of
+// their events. The other actions do not change the state.
+var containerStates = map[string]string{
+	"create":          "created",
+	"start":           "running",
+	"unpause":         "running",
+	"pause":           "paused",
+	"die":             "exited",
+	"restart_backoff": "restarting",
+}
+
+// stateSpan is a period of time a container spent in a state, until is
+// zero when it is still in the state.
+type stateSpan struct {
+	state        string
+	since, until time.Time
+	exitCode     string
+}
+
+// foldStates folds the events of the container id, from the oldest, into
+// the states it went through, and returns whether it was removed.
+func foldStates(id string, evs []eventtypes.Message) ([]stateSpan, bool) {
+	var spans []stateSpan
+	for _, ev := range evs {
+		if ev.Type != eventtypes.ContainerEventType || ev.Actor.ID != id {
+			continue
+		}
+		t := time.Unix(0, ev.TimeNano).UTC()
+		n := len(spans)
+		if ev.Action == "destroy" {
+			if n > 0 {
+				spans[n-1].until = t
+			}
+			return spans, true
+		}
+		state, ok := containerStates[ev.Action]
+		if !ok || n > 0 && spans[n-1].state == state {
+			continue
+		}
+		if n > 0 {
+			spans[n-1].until = t
+		}
+		span := stateSpan{state: state, since: t}
+		if state == "exited" {
+			span.exitCode = ev.Actor.Attributes["exitCode"]
+		}
+		spans = append(spans, span)
+	}
+	return spans, false
+}
+
+// FoldTimeline folds the events of the container id, from the oldest, into
+// the states it went through. The duration of the last state runs until
+// now, unless the container was removed.
+func FoldTimeline(id string, evs []eventtypes.Message, now time.Time) eventtypes.Timeline {
+	spans, removed := foldStates(id, evs)
+	timeline := eventtypes.Timeline{ID: id, States: []eventtypes.StateSpan{}, Removed: removed}
+	for _, span := range spans {
+		s := eventtypes.StateSpan{
+			State:    span.state,
+			Since:    span.since.Format(time.RFC3339Nano),
+			ExitCode: span.exitCode,
+		}
+		until := now
+		if !span.until.IsZero() {
+			until = span.until
+			s.Until = until.Format(time.RFC3339Nano)
+		}
+		s.Duration = until.Sub(span.since).String()
+		timeline.States = append(timeline.States, s)
+	}
+	return timeline
+}
+
+// ContainerTimeline returns the states the container id went through,
+// folded from its events kept in memory and stored in the journal. Only
+// the events of the objects owned by owner, when it is not empty, and
+// included by acl, if it is not nil, are folded.
+func (e *Events) ContainerTimeline(id, owner string, acl *ACL) (eventtypes.Timeline, error) {
+	evs, err := e.containerEvents(id, owner, acl)
+	if err != nil {
+		return eventtypes.Timeline{}, err
+	}
+	return FoldTimeline(id, evs, time.Now()), nil
+}
+
+// containerEvents returns the events of the container id kept in memory
+// and stored in the journal, of the objects owned by owner when it is not
+// empty, and included by acl if it is not nil.
+func (e *Events) containerEvents(id, owner string, acl *ACL) ([]eventtypes.Message, error) {
+	args := filters.NewArgs()
+	args.Add("type", eventtypes.ContainerEventType)
+	args.Add("container", id)
+	evs, err := e.eventsAfter(0, NewFilter(args))
+	if err != nil {
+		return nil, err
+	}
+	var allowed []eventtypes.Message
+	for _, ev := range evs {
+		if owner != "" && !ownedBy(ev, owner) {
+			continue
+		}
+		if acl != nil && !acl.Include(ev) {
+			continue
+		}
+		allowed = append(allowed, ev)
+	}
+	return allowed, nil
+}
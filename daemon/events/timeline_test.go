@@ -0,0 +1,83 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestFoldTimeline(t *testing.T) {
+	start := time.Date(2016, 1, 27, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) int64 {
+		return start.Add(d).UnixNano()
+	}
+	ev := func(action string, d time.Duration, attrs map[string]string) events.Message {
+		return events.Message{
+			Message: eventtypes.Message{
+				Type:     events.ContainerEventType,
+				Action:   action,
+				Actor:    eventtypes.Actor{ID: "web", Attributes: attrs},
+				TimeNano: at(d),
+			},
+		}
+	}
+	evs := []events.Message{
+		ev("create", 0, nil),
+		ev("start", time.Second, nil),
+		ev("exec_start: sh", 2*time.Second, nil),
+		ev("pause", 10*time.Second, nil),
+		ev("unpause", 15*time.Second, nil),
+		ev("die", time.Minute, map[string]string{"exitCode": "137"}),
+		ev("restart_backoff", time.Minute, nil),
+		ev("start", 2*time.Minute, nil),
+		{Message: eventtypes.Message{Type: events.ContainerEventType, Action: "die", Actor: eventtypes.Actor{ID: "db"}, TimeNano: at(3 * time.Minute)}},
+	}
+
+	timeline := FoldTimeline("web", evs, start.Add(time.Hour))
+	ts := func(d time.Duration) string {
+		return start.Add(d).Format(time.RFC3339Nano)
+	}
+	expected := events.Timeline{
+		ID: "web",
+		States: []events.StateSpan{
+			{State: "created", Since: ts(0), Until: ts(time.Second), Duration: "1s"},
+			{State: "running", Since: ts(time.Second), Until: ts(10 * time.Second), Duration: "9s"},
+			{State: "paused", Since: ts(10 * time.Second), Until: ts(15 * time.Second), Duration: "5s"},
+			{State: "running", Since: ts(15 * time.Second), Until: ts(time.Minute), Duration: "45s"},
+			{State: "exited", Since: ts(time.Minute), Until: ts(time.Minute), Duration: "0s", ExitCode: "137"},
+			{State: "restarting", Since: ts(time.Minute), Until: ts(2 * time.Minute), Duration: "1m0s"},
+			{State: "running", Since: ts(2 * time.Minute), Duration: "58m0s"},
+		},
+	}
+	if !reflect.DeepEqual(timeline, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, timeline)
+	}
+
+	// The timeline ends when the container is removed.
+	evs = append(evs, ev("die", 5*time.Minute, map[string]string{"exitCode": "0"}), ev("destroy", 6*time.Minute, nil))
+	timeline = FoldTimeline("web", evs, start.Add(time.Hour))
+	if !timeline.Removed || len(timeline.States) != 8 {
+		t.Fatalf("Expected 8 states and the container removed, got %+v", timeline)
+	}
+	if last := timeline.States[7]; last.State != "exited" || last.Until != ts(6*time.Minute) || last.Duration != "1m0s" {
+		t.Fatalf("Unexpected last state %+v", last)
+	}
+}
+
+func TestContainerTimeline(t *testing.T) {
+	e := New(0)
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "web"})
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "db"})
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "web"})
+
+	timeline, err := e.ContainerTimeline("web", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline.States) != 2 || timeline.States[0].State != "created" || timeline.States[1].State != "running" {
+		t.Fatalf("Unexpected timeline %+v", timeline)
+	}
+}
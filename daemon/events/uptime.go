@@ -0,0 +1,80 @@
+package events
+
+import (
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// ComputeUptime computes the availability of the container id between
+// since and until from its events, from the oldest. The container is
+// available while it is running, and the time it spends in any other
+// state is an outage. A zero since starts at the creation of the
+// container.
+func ComputeUptime(id string, evs []eventtypes.Message, since, until time.Time) eventtypes.Uptime {
+	uptime := eventtypes.Uptime{ID: id, LongestOutage: "0s"}
+	spans, _ := foldStates(id, evs)
+	if len(spans) == 0 {
+		return uptime
+	}
+	if first := spans[0].since; since.Before(first) {
+		since = first
+	}
+	if last := spans[len(spans)-1].until; !last.IsZero() && last.Before(until) {
+		until = last
+	}
+	if !since.Before(until) {
+		return uptime
+	}
+	uptime.Since = since.Format(time.RFC3339Nano)
+	uptime.Until = until.Format(time.RFC3339Nano)
+
+	var (
+		up, outage, longest time.Duration
+		prev                string
+	)
+	for _, span := range spans {
+		start, end := span.since, span.until
+		if end.IsZero() || end.After(until) {
+			end = until
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if !start.Before(end) {
+			prev = span.state
+			continue
+		}
+		d := end.Sub(start)
+		if span.state == "running" {
+			up += d
+			if (prev == "exited" || prev == "restarting") && !span.since.Before(since) {
+				uptime.Restarts++
+			}
+			outage = 0
+		} else {
+			if outage == 0 {
+				uptime.Outages++
+			}
+			if outage += d; outage > longest {
+				longest = outage
+			}
+		}
+		prev = span.state
+	}
+	uptime.Availability = 100 * float64(up) / float64(until.Sub(since))
+	uptime.LongestOutage = longest.String()
+	return uptime
+}
+
+// ContainerUptime returns the availability of the container id between
+// since and until, computed from its events kept in memory and stored in
+// the journal. Only the events of the objects owned by owner, when it is
+// not empty, and included by acl, if it is not nil, are used.
+func (e *Events) ContainerUptime(id string, since, until time.Time, owner string, acl *ACL) (eventtypes.Uptime, error) {
+	evs, err := e.containerEvents(id, owner, acl)
+	if err != nil {
+		return eventtypes.Uptime{}, err
+	}
+	return ComputeUptime(id, evs, since, until), nil
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestComputeUptime(t *testing.T) {
+	start := time.Date(2016, 1, 27, 10, 0, 0, 0, time.UTC)
+	ev := func(action string, d time.Duration) events.Message {
+		return events.Message{
+			Message: eventtypes.Message{
+				Type:     events.ContainerEventType,
+				Action:   action,
+				Actor:    eventtypes.Actor{ID: "web"},
+				TimeNano: start.Add(d).UnixNano(),
+			},
+		}
+	}
+	evs := []events.Message{
+		ev("create", 0),
+		ev("start", 0),
+		// A 10 minutes outage.
+		ev("die", 30*time.Minute),
+		ev("restart_backoff", 31*time.Minute),
+		ev("start", 40*time.Minute),
+		// A 5 minutes outage.
+		ev("pause", 50*time.Minute),
+		ev("unpause", 55*time.Minute),
+		ev("die", 90*time.Minute),
+		ev("start", 95*time.Minute),
+	}
+
+	uptime := ComputeUptime("web", evs, time.Time{}, start.Add(100*time.Minute))
+	if uptime.Since != start.Format(time.RFC3339Nano) || uptime.Until != start.Add(100*time.Minute).Format(time.RFC3339Nano) {
+		t.Fatalf("Unexpected period %s to %s", uptime.Since, uptime.Until)
+	}
+	if uptime.Availability != 80 || uptime.Restarts != 2 || uptime.Outages != 3 || uptime.LongestOutage != "10m0s" {
+		t.Fatalf("Unexpected uptime %+v", uptime)
+	}
+
+	// The period can start after the creation of the container.
+	uptime = ComputeUptime("web", evs, start.Add(45*time.Minute), start.Add(100*time.Minute))
+	// 45 minutes running out of 55.
+	if int(uptime.Availability*100) != 8181 || uptime.Restarts != 1 || uptime.Outages != 2 || uptime.LongestOutage != "5m0s" {
+		t.Fatalf("Unexpected uptime %+v", uptime)
+	}
+
+	// It ends when the container is removed.
+	evs = append(evs, ev("die", 100*time.Minute), ev("destroy", 110*time.Minute))
+	uptime = ComputeUptime("web", evs, time.Time{}, start.Add(time.Hour*24))
+	if uptime.Until != start.Add(110*time.Minute).Format(time.RFC3339Nano) || uptime.LongestOutage != "10m0s" || uptime.Outages != 4 {
+		t.Fatalf("Unexpected uptime %+v", uptime)
+	}
+
+	// The containers without events have no period.
+	if uptime := ComputeUptime("db", evs, time.Time{}, start.Add(time.Hour)); uptime.Since != "" || uptime.Availability != 0 {
+		t.Fatalf("Unexpected uptime %+v", uptime)
+	}
+}
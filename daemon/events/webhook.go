@@ -0,0 +1,190 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"golang.org/x/net/context"
+)
+
+const (
+	// WebhookSignatureHeader is the header holding the HMAC-SHA256
+	// signature of the request body, when the webhook has a secret.
+	WebhookSignatureHeader = "X-Docker-Event-Signature"
+
+	defaultWebhookRetries = 3
+	webhookRequestTimeout = 10 * time.Second
+	webhookStopTimeout    = 5 * time.Second
+	webhookMaxBackoff     = 30 * time.Second
+)
+
+// webhookBackoff is the delay before the first retry of a failed
+// delivery, it doubles after every attempt.
+var webhookBackoff = 500 * time.Millisecond
+
+// WebhookConfig is the configuration of an HTTP endpoint the daemon
+// posts events to.
+type WebhookConfig struct {
+	// URL is the http or https endpoint events are posted to.
+	URL string `json:"url"`
+	// Secret is the key used to sign the requests, they are not
+	// signed when it is empty.
+	Secret string `json:"secret,omitempty"`
+	// Filters selects the events posted to the endpoint, using the
+	// same filters as the events API.
+	Filters map[string][]string `json:"filters,omitempty"`
+	// MaxRetries is the number of times a failed delivery is retried,
+	// it defaults to 3. Use -1 to never retry.
+	MaxRetries int `json:"max-retries,omitempty"`
+	// Format is the encoding of the events posted, json, the default,
+	// or cloudevents.
+	Format string `json:"format,omitempty"`
+}
+
+// Webhook posts the events matching its filters to an HTTP endpoint,
+// one JSON encoded message per request.
+type Webhook struct {
+	config WebhookConfig
+	filter *Filter
+	// marshal encodes the events in the format of the webhook, posted
+	// with the media type contentType.
+	marshal     func(eventtypes.Message) ([]byte, error)
+	contentType string
+	client      *http.Client
+	events      *Events
+	l           <-chan eventtypes.Message
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewWebhook validates config and returns a webhook for it. The webhook
+// does not receive events until it is started.
+func NewWebhook(config WebhookConfig) (*Webhook, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events webhook url %q: %v", config.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid events webhook url %q: scheme must be http or https", config.URL)
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultWebhookRetries
+	}
+	marshal, err := Marshaler(config.Format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events webhook %q: %v", config.URL, err)
+	}
+	contentType := "application/json"
+	if config.Format == CloudEventsFormat {
+		contentType = CloudEventsType
+	}
+
+	return &Webhook{
+		config:      config,
+		marshal:     marshal,
+		contentType: contentType,
+		filter:      NewFilterFromMap(config.Filters),
+		client:      &http.Client{Timeout: webhookRequestTimeout},
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Start subscribes the webhook to e and posts new events in the
+// background. Events that arrive faster than the endpoint accepts
+// them are dropped, oldest first.
+func (w *Webhook) Start(e *Events) {
+	w.events = e
+	_, w.l = e.SubscribeWithOptions(context.Background(), SubscribeOptions{
+		Since:  -1,
+		Until:  -1,
+		Filter: w.filter,
+		Policy: DropOldest,
+	})
+	go w.run()
+}
+
+// Stop unsubscribes the webhook. The events already received are still
+// posted, without retries, for a short while.
+func (w *Webhook) Stop() {
+	w.events.Evict(w.l)
+	close(w.stop)
+	select {
+	case <-w.done:
+	case <-time.After(webhookStopTimeout):
+		logrus.Warnf("Timeout posting pending events to webhook %s", w.config.URL)
+	}
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+	for ev := range w.l {
+		if err := w.deliver(ev); err != nil {
+			logrus.Errorf("Error posting event to webhook %s: %v", w.config.URL, err)
+			w.events.deadLetter("webhook "+w.config.URL, ev, err)
+		}
+	}
+}
+
+// deliver posts ev to the endpoint, retrying with an exponential
+// backoff until it succeeds or the retries are exhausted.
+func (w *Webhook) deliver(ev eventtypes.Message) error {
+	body, err := w.marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	backoff := webhookBackoff
+	for attempt := 0; ; attempt++ {
+		err = w.post(body)
+		if err == nil || attempt >= w.config.MaxRetries {
+			return err
+		}
+		logrus.Debugf("Retrying event delivery to webhook %s in %s: %v", w.config.URL, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			return err
+		}
+		if backoff *= 2; backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+}
+
+func (w *Webhook) post(body []byte) error {
+	req, err := http.NewRequest("POST", w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	if w.config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignPayload(w.config.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// SignPayload returns the hex encoded HMAC-SHA256 of body with secret,
+// as sent by webhooks in the X-Docker-Event-Signature header.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestWebhookRetryAndSignature(t *testing.T) {
+	webhookBackoff = time.Millisecond
+
+	received := make(chan events.Message, 1)
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig := r.Header.Get(WebhookSignatureHeader); sig != "sha256="+SignPayload("s3cr3t", body) {
+			t.Fatalf("Unexpected signature %q", sig)
+		}
+		var m events.Message
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		received <- m
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{
+		URL:     srv.URL,
+		Secret:  "s3cr3t",
+		Filters: map[string][]string{"event": {"start"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(0)
+	w.Start(e)
+	defer w.Stop()
+
+	e.Log("create", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	e.Log("start", events.ContainerEventType, eventtypes.Actor{ID: "cont"})
+
+	select {
+	case m := <-received:
+		if m.Action != "start" || m.Actor.ID != "cont" {
+			t.Fatalf("Unexpected event %v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the webhook delivery")
+	}
+	if attempts != 2 {
+		t.Fatalf("Expected 2 delivery attempts, got %d", attempts)
+	}
+}
+
+func TestNewWebhookInvalidURL(t *testing.T) {
+	for _, u := range []string{"", "ftp://example.com", "://bad"} {
+		if _, err := NewWebhook(WebhookConfig{URL: u}); err == nil {
+			t.Fatalf("Expected an error for url %q", u)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package daemon
+
+import (
+	// Importing packages here only to make sure their init gets called and
+	// therefore they register themselves to the events exporter factory.
+	_ "github.com/docker/docker/daemon/events/awslogs"
+	_ "github.com/docker/docker/daemon/events/fluentd"
+	_ "github.com/docker/docker/daemon/events/siem"
+)
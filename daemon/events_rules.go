@@ -0,0 +1,11 @@
+package daemon
+
+// ruleActions performs the actions of the events rules on the containers
+// of the daemon.
+type ruleActions struct {
+	daemon *Daemon
+}
+
+func (a ruleActions) RestartContainer(id string, seconds int) error {
+	return a.daemon.ContainerRestart(id, seconds)
+}
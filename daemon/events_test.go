@@ -1,18 +1,27 @@
 package daemon
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
 	"testing"
 	"time"
 
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/events"
 	containertypes "github.com/docker/engine-api/types/container"
-	eventtypes "github.com/docker/engine-api/types/events"
+	engineevents "github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/libnetwork"
+	"golang.org/x/net/context"
 )
 
 func TestLogContainerEventCopyLabels(t *testing.T) {
-	e := events.New()
-	_, l, _ := e.Subscribe()
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
 	defer e.Evict(l)
 
 	container := &container.Container{
@@ -44,8 +53,8 @@ func TestLogContainerEventCopyLabels(t *testing.T) {
 }
 
 func TestLogContainerEventWithAttributes(t *testing.T) {
-	e := events.New()
-	_, l, _ := e.Subscribe()
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
 	defer e.Evict(l)
 
 	container := &container.Container{
@@ -75,13 +84,253 @@ func TestLogContainerEventWithAttributes(t *testing.T) {
 	})
 }
 
-func validateTestAttributes(t *testing.T, l chan interface{}, expectedAttributesToTest map[string]string) {
-	select {
-	case ev := <-l:
-		event, ok := ev.(eventtypes.Message)
-		if !ok {
-			t.Fatalf("Unexpected event message: %q", ev)
+func TestLogDaemonEventWithAttributes(t *testing.T) {
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	daemon := &Daemon{
+		ID:            "daemon_id",
+		EventsService: e,
+	}
+	daemon.LogDaemonEventWithAttributes("oom", map[string]string{"container": "container_id"})
+
+	validateTestAttributes(t, l, map[string]string{
+		"container": "container_id",
+	})
+}
+
+func TestLogRestoreEvent(t *testing.T) {
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	container := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:     "container_id",
+			Name:   "container_name",
+			Config: &containertypes.Config{Image: "image_name"},
+		},
+	}
+	daemon := &Daemon{
+		EventsService: e,
+	}
+	for _, c := range []struct {
+		outcome    string
+		wasRunning bool
+		attributes map[string]string
+		expected   map[string]string
+	}{
+		{"restarted", false, map[string]string{}, map[string]string{"outcome": "restarted", "wasRunning": "false"}},
+		{"restart_failed", true, map[string]string{"error": "no such image"}, map[string]string{"outcome": "restart_failed", "wasRunning": "true", "error": "no such image"}},
+		{"dead", true, map[string]string{"exitCode": "137"}, map[string]string{"outcome": "dead", "wasRunning": "true", "exitCode": "137"}},
+	} {
+		daemon.logRestoreEvent(container, c.outcome, c.wasRunning, c.attributes)
+		select {
+		case ev := <-l:
+			if ev.Type != eventtypes.ContainerEventType || ev.Action != "restore" || ev.Actor.ID != "container_id" {
+				t.Fatalf("Expected a restore event of container_id, got %v", ev)
+			}
+			c.expected["name"] = "container_name"
+			c.expected["image"] = "image_name"
+			if !reflect.DeepEqual(ev.Actor.Attributes, c.expected) {
+				t.Fatalf("Expected attributes %v, got %v", c.expected, ev.Actor.Attributes)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("LogEvent test timed out")
 		}
+	}
+}
+
+type fakeNetwork struct {
+	libnetwork.Network
+}
+
+func (n fakeNetwork) ID() string   { return "network_id" }
+func (n fakeNetwork) Name() string { return "network_name" }
+func (n fakeNetwork) Type() string { return "bridge" }
+
+type fakeEndpoint struct {
+	libnetwork.Endpoint
+	iface libnetwork.InterfaceInfo
+}
+
+func (ep fakeEndpoint) ID() string                    { return "endpoint_id" }
+func (ep fakeEndpoint) Info() libnetwork.EndpointInfo { return fakeEndpointInfo{iface: ep.iface} }
+
+type fakeEndpointInfo struct {
+	libnetwork.EndpointInfo
+	iface libnetwork.InterfaceInfo
+}
+
+func (info fakeEndpointInfo) Iface() libnetwork.InterfaceInfo { return info.iface }
+
+type fakeInterfaceInfo struct {
+	libnetwork.InterfaceInfo
+}
+
+func (i fakeInterfaceInfo) Address() *net.IPNet {
+	return &net.IPNet{IP: net.ParseIP("172.17.0.2"), Mask: net.CIDRMask(16, 32)}
+}
+
+func (i fakeInterfaceInfo) MacAddress() net.HardwareAddr {
+	return net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}
+}
+
+func TestLogEndpointEvent(t *testing.T) {
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	daemon := &Daemon{
+		EventsService: e,
+		configStore:   &Config{},
+	}
+	ep := fakeEndpoint{iface: fakeInterfaceInfo{}}
+	// The endpoint events are only logged when verbose.
+	daemon.logEndpointEvent(fakeNetwork{}, ep, "container_id", "endpoint_join")
+	daemon.configStore.EventsNetworkVerbose = true
+
+	for _, c := range []struct {
+		action   string
+		ep       libnetwork.Endpoint
+		expected map[string]string
+	}{
+		{"endpoint_join", ep, map[string]string{"address": "172.17.0.2/16", "macAddress": "02:42:ac:11:00:02"}},
+		// The addresses are omitted when the endpoint has no interface.
+		{"endpoint_leave", fakeEndpoint{}, map[string]string{}},
+	} {
+		daemon.logEndpointEvent(fakeNetwork{}, c.ep, "container_id", c.action)
+		select {
+		case ev := <-l:
+			if ev.Type != eventtypes.NetworkEventType || ev.Action != c.action || ev.Actor.ID != "network_id" {
+				t.Fatalf("Expected a %s event of network_id, got %v", c.action, ev)
+			}
+			c.expected["container"] = "container_id"
+			c.expected["endpoint"] = "endpoint_id"
+			c.expected["name"] = "network_name"
+			c.expected["type"] = "bridge"
+			if !reflect.DeepEqual(ev.Actor.Attributes, c.expected) {
+				t.Fatalf("Expected attributes %v, got %v", c.expected, ev.Actor.Attributes)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("LogEvent test timed out")
+		}
+	}
+}
+
+func TestLogLoginEvent(t *testing.T) {
+	e := events.New(0)
+	_, l := e.Subscribe(context.Background())
+	defer e.Evict(l)
+
+	daemon := &Daemon{
+		EventsService: e,
+	}
+	username := e.Anonymizer().Hash("janedoe")
+	for _, c := range []struct {
+		username string
+		err      error
+		action   string
+		expected map[string]string
+	}{
+		{"janedoe", nil, "login", map[string]string{"username": username}},
+		{"janedoe", errors.New("unauthorized"), "login_failure", map[string]string{"username": username, "error": "unauthorized"}},
+		// The logins with an identity token have no username.
+		{"", nil, "login", map[string]string{}},
+	} {
+		daemon.logLoginEvent("registry.example.com", c.username, c.err)
+		select {
+		case ev := <-l:
+			if ev.Type != eventtypes.RegistryEventType || ev.Action != c.action || ev.Actor.ID != "registry.example.com" {
+				t.Fatalf("Expected a %s event of registry.example.com, got %v", c.action, ev)
+			}
+			if !reflect.DeepEqual(ev.Actor.Attributes, c.expected) {
+				t.Fatalf("Expected attributes %v, got %v", c.expected, ev.Actor.Attributes)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("LogEvent test timed out")
+		}
+	}
+	if username == "" || username == "janedoe" {
+		t.Fatalf("Expected the username to be hashed, got %q", username)
+	}
+}
+
+func TestContainerEvents(t *testing.T) {
+	e := events.New(0)
+	defer e.Close()
+	store := container.NewMemoryStore()
+	for _, id := range []string{"container_a", "container_b"} {
+		store.Add(id, &container.Container{CommonContainer: container.CommonContainer{ID: id}})
+		for _, action := range []string{"create", "start"} {
+			e.Log(action, eventtypes.ContainerEventType, engineevents.Actor{ID: id})
+		}
+	}
+	e.Log("start", eventtypes.DaemonEventType, engineevents.Actor{ID: "daemon_id"})
+	e.Flush()
+	daemon := &Daemon{containers: store, EventsService: e}
+
+	stop := make(chan bool)
+	close(stop)
+	for _, c := range []struct {
+		filters map[string][]string
+		actions []string
+	}{
+		{nil, []string{"create", "start"}},
+		{map[string][]string{"event": {"start"}}, []string{"start"}},
+		// The filters of the client cannot select the events of
+		// another container, nor of another type.
+		{map[string][]string{"container": {"container_b"}}, nil},
+		{map[string][]string{"type": {"daemon"}}, nil},
+	} {
+		args := filters.NewArgs()
+		for name, values := range c.filters {
+			for _, value := range values {
+				args.Add(name, value)
+			}
+		}
+		ef, err := events.ParseFilter(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := daemon.ContainerEvents("container_a", &ContainerEventsConfig{
+			Since:     0,
+			Until:     -1,
+			Filter:    ef,
+			OutStream: &buf,
+			Stop:      stop,
+			Schema:    events.LegacySchema,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var ev eventtypes.Message
+			if err := dec.Decode(&ev); err != nil {
+				t.Fatal(err)
+			}
+			if ev.Actor.ID != "container_a" || ev.Status != ev.Action || ev.ID != "container_a" {
+				t.Fatalf("Unexpected event %+v for the filters %v", ev, c.filters)
+			}
+			actions = append(actions, ev.Action)
+		}
+		if len(actions) != len(c.actions) {
+			t.Fatalf("Expected the events %v for the filters %v, got %v", c.actions, c.filters, actions)
+		}
+		for i := range actions {
+			if actions[i] != c.actions[i] {
+				t.Fatalf("Expected the events %v for the filters %v, got %v", c.actions, c.filters, actions)
+			}
+		}
+	}
+}
+
+func validateTestAttributes(t *testing.T, l <-chan eventtypes.Message, expectedAttributesToTest map[string]string) {
+	select {
+	case event := <-l:
 		for key, expected := range expectedAttributesToTest {
 			actual, ok := event.Actor.Attributes[key]
 			if !ok || actual != expected {
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -119,10 +120,14 @@ func (d *Daemon) ContainerExecCreate(config *types.ExecConfig) (string, error) {
 	execConfig.ProcessConfig = processConfig
 	execConfig.ContainerID = container.ID
 	execConfig.DetachKeys = keys
+	execConfig.User = config.User
+	if execConfig.User == "" {
+		execConfig.User = container.Config.User
+	}
 
 	d.registerExecCommand(container, execConfig)
 
-	d.LogContainerEvent(container, "exec_create: "+execConfig.ProcessConfig.Entrypoint+" "+strings.Join(execConfig.ProcessConfig.Arguments, " "))
+	d.logExecEvent(container, execConfig, "exec_create: "+execCommand(execConfig), map[string]string{})
 
 	return execConfig.ID, nil
 }
@@ -155,7 +160,7 @@ func (d *Daemon) ContainerExecStart(name string, stdin io.ReadCloser, stdout io.
 
 	c := d.containers.Get(ec.ContainerID)
 	logrus.Debugf("starting exec command %s in container %s", ec.ID, c.ID)
-	d.LogContainerEvent(c, "exec_start: "+ec.ProcessConfig.Entrypoint+" "+strings.Join(ec.ProcessConfig.Arguments, " "))
+	d.logExecEvent(c, ec, "exec_start: "+execCommand(ec), map[string]string{})
 
 	if ec.OpenStdin {
 		r, w := io.Pipe()
@@ -297,6 +302,9 @@ func (d *Daemon) monitorExec(container *container.Container, execConfig *exec.Co
 		logrus.Errorf("Error running command in existing container %s: %s", container.ID, err)
 	}
 	logrus.Debugf("Exec task in container %s exited with code %d", container.ID, exitCode)
+	d.logExecEvent(container, execConfig, "exec_die", map[string]string{
+		"exitCode": strconv.Itoa(exitCode),
+	})
 
 	if err := execConfig.CloseStreams(); err != nil {
 		logrus.Errorf("%s: %s", container.ID, err)
@@ -312,3 +320,17 @@ func (d *Daemon) monitorExec(container *container.Container, execConfig *exec.Co
 	container.ExecCommands.Delete(execConfig.ID)
 	return err
 }
+
+// logExecEvent generates an event related to an exec in a container, with
+// the exec ID, command and user among its attributes.
+func (d *Daemon) logExecEvent(c *container.Container, ec *exec.Config, action string, attributes map[string]string) {
+	attributes["execID"] = ec.ID
+	attributes["command"] = execCommand(ec)
+	attributes["user"] = ec.User
+	d.LogContainerEventWithAttributes(c, action, attributes)
+}
+
+// execCommand returns the command line of an exec.
+func execCommand(ec *exec.Config) string {
+	return ec.ProcessConfig.Entrypoint + " " + strings.Join(ec.ProcessConfig.Arguments, " ")
+}
@@ -30,7 +30,7 @@ func (daemon *Daemon) removeMountPoints(container *container.Container, rm bool)
 			if m.Named {
 				continue
 			}
-			err := daemon.volumes.Remove(m.Volume)
+			err := daemon.removeVolume(m.Volume)
 			// Ignore volume in use errors because having this
 			// volume being referenced by other container is
 			// not an error, but an implementation detail.
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// resourceThreshold tracks the utilization of a resource by the containers
+// against a threshold. A container crosses the threshold when its
+// utilization reaches it, and is back to normal when its utilization falls
+// below the threshold minus the hysteresis, so that a utilization hovering
+// around the threshold does not generate a flood of events.
+type resourceThreshold struct {
+	// high and normal are the actions of the events logged when a
+	// container crosses the threshold and when it is back to normal.
+	high, normal string
+	threshold    float64
+	hysteresis   float64
+
+	mu    sync.Mutex
+	above map[string]bool
+}
+
+func newResourceThreshold(high, normal string, threshold, hysteresis float64) *resourceThreshold {
+	return &resourceThreshold{
+		high:       high,
+		normal:     normal,
+		threshold:  threshold,
+		hysteresis: hysteresis,
+		above:      make(map[string]bool),
+	}
+}
+
+// update records the utilization of the container id, in percent, and
+// returns the action of the event to log, or an empty string. The container
+// is back to normal without an event when the normal action is empty.
+func (t *resourceThreshold) update(id string, utilization float64) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	above := crossThreshold(t.above[id], utilization, t.threshold, t.hysteresis)
+	if above == t.above[id] {
+		return ""
+	}
+	if above {
+		t.above[id] = true
+		return t.high
+	}
+	delete(t.above, id)
+	return t.normal
+}
+
+// crossThreshold returns whether a utilization is above the threshold,
+// given whether the previous one was: it rises above when it reaches the
+// threshold, and only falls below under the threshold minus the hysteresis.
+func crossThreshold(above bool, utilization, threshold, hysteresis float64) bool {
+	if above {
+		return utilization >= threshold-hysteresis
+	}
+	return utilization >= threshold
+}
+
+// forget discards the state of the container id, when it stops.
+func (t *resourceThreshold) forget(id string) {
+	t.mu.Lock()
+	delete(t.above, id)
+	t.mu.Unlock()
+}
+
+// thresholdAttributes returns the attributes of the events of threshold t
+// for the given utilization.
+func thresholdAttributes(t *resourceThreshold, utilization float64) map[string]string {
+	return map[string]string{
+		"utilization": fmt.Sprintf("%.2f", utilization),
+		"threshold":   fmt.Sprintf("%.2f", t.threshold),
+	}
+}
+
+// oomWarning records the memory usage of the container id against its
+// limit, and returns the action and the attributes of the event to log, or
+// an empty action.
+func oomWarning(t *resourceThreshold, id string, usage uint64, limit int64) (string, map[string]string) {
+	utilization := memoryPercent(usage, limit)
+	action := t.update(id, utilization)
+	if action == "" {
+		return "", nil
+	}
+	attributes := thresholdAttributes(t, utilization)
+	attributes["usage"] = strconv.FormatUint(usage, 10)
+	attributes["limit"] = strconv.FormatInt(limit, 10)
+	return action, attributes
+}
+
+// diskThreshold records the usage of the filesystem of path, and returns
+// the action and the attributes of the event to log, or an empty action.
+func diskThreshold(t *resourceThreshold, path string, utilization float64) (string, map[string]string) {
+	action := t.update(path, utilization)
+	if action == "" {
+		return "", nil
+	}
+	attributes := thresholdAttributes(t, utilization)
+	attributes["path"] = path
+	return action, attributes
+}
+
+// diskUtilization returns the usage of a filesystem from its total, free
+// and available blocks, as reported by df: the blocks reserved to root are
+// not counted as available.
+func diskUtilization(blocks, free, available uint64) float64 {
+	used := blocks - free
+	if used+available == 0 {
+		return 0
+	}
+	return float64(used) / float64(used+available) * 100
+}
+
+// cpuPercent returns the CPU utilization of a container between two
+// samples of its CPU usage and of the CPU usage of the host, in the same
+// unit as docker stats: 100% is one CPU fully used.
+func cpuPercent(previousCPU, previousSystem, cpu, system uint64, cpus int) float64 {
+	if cpu < previousCPU || system <= previousSystem {
+		return 0
+	}
+	return float64(cpu-previousCPU) / float64(system-previousSystem) * float64(cpus) * 100
+}
+
+// memoryPercent returns the memory utilization of a container relative to
+// its limit.
+func memoryPercent(usage uint64, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(usage) / float64(limit) * 100
+}
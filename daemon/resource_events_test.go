@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCrossThreshold(t *testing.T) {
+	cases := []struct {
+		above       bool
+		utilization float64
+		expected    bool
+	}{
+		// Rising edge: only at the threshold.
+		{false, 0, false},
+		{false, 79.99, false},
+		{false, 80, true},
+		{false, 95, true},
+		// Falling edge: only below the threshold minus the hysteresis.
+		{true, 100, true},
+		{true, 80, true},
+		{true, 75, true},
+		{true, 74.99, false},
+		{true, 0, false},
+	}
+	for _, c := range cases {
+		if above := crossThreshold(c.above, c.utilization, 80, 5); above != c.expected {
+			t.Fatalf("Expected %.2f%% with above=%v to be above=%v, got %v", c.utilization, c.above, c.expected, above)
+		}
+	}
+}
+
+func TestResourceThresholdUpdate(t *testing.T) {
+	th := newResourceThreshold("cpu_high", "cpu_normal", 80, 5)
+	steps := []struct {
+		id          string
+		utilization float64
+		action      string
+	}{
+		{"a", 50, ""},
+		{"a", 80, "cpu_high"},
+		// Hovering around the threshold logs no events.
+		{"a", 79, ""},
+		{"a", 81, ""},
+		{"a", 76, ""},
+		// Each container has its own state.
+		{"b", 90, "cpu_high"},
+		{"a", 74, "cpu_normal"},
+		{"a", 79, ""},
+		{"a", 80, "cpu_high"},
+		{"b", 10, "cpu_normal"},
+	}
+	for i, s := range steps {
+		if action := th.update(s.id, s.utilization); action != s.action {
+			t.Fatalf("Expected step %d (%s at %.2f%%) to log %q, got %q", i, s.id, s.utilization, s.action, action)
+		}
+	}
+
+	// A container forgotten while above the threshold crosses it again.
+	th.forget("a")
+	if action := th.update("a", 90); action != "cpu_high" {
+		t.Fatalf("Expected a forgotten container to log cpu_high, got %q", action)
+	}
+}
+
+func TestOOMWarning(t *testing.T) {
+	th := newResourceThreshold("oom_warning", "", 90, 5)
+	limit := int64(1000)
+
+	if action, _ := oomWarning(th, "a", 899, limit); action != "" {
+		t.Fatalf("Expected no warning under the threshold, got %q", action)
+	}
+	action, attributes := oomWarning(th, "a", 950, limit)
+	if action != "oom_warning" {
+		t.Fatalf("Expected oom_warning, got %q", action)
+	}
+	expected := map[string]string{"utilization": "95.00", "threshold": "90.00", "usage": "950", "limit": "1000"}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Fatalf("Expected attributes %v, got %v", expected, attributes)
+	}
+
+	// The warning is not repeated while the usage stays high, and there is
+	// no event when it is back to normal.
+	for _, usage := range []uint64{999, 860, 849, 851} {
+		if action, _ := oomWarning(th, "a", usage, limit); action != "" {
+			t.Fatalf("Expected no event at %d, got %q", usage, action)
+		}
+	}
+	if action, _ := oomWarning(th, "a", 900, limit); action != "oom_warning" {
+		t.Fatalf("Expected a new warning once back under the hysteresis, got %q", action)
+	}
+}
+
+func TestMemoryPercent(t *testing.T) {
+	if p := memoryPercent(512, 1024); p != 50 {
+		t.Fatalf("Expected 50%%, got %.2f", p)
+	}
+	if p := memoryPercent(512, 0); p != 0 {
+		t.Fatalf("Expected 0%% without a limit, got %.2f", p)
+	}
+}
+
+func TestDiskThreshold(t *testing.T) {
+	th := newResourceThreshold("disk_high", "disk_normal", 90, 5)
+	steps := []struct {
+		utilization float64
+		action      string
+	}{
+		{89, ""},
+		{90, "disk_high"},
+		{86, ""},
+		{91, ""},
+		{84, "disk_normal"},
+		{89, ""},
+	}
+	for i, s := range steps {
+		action, attributes := diskThreshold(th, "/var/lib/docker", s.utilization)
+		if action != s.action {
+			t.Fatalf("Expected step %d at %.2f%% to log %q, got %q", i, s.utilization, s.action, action)
+		}
+		if action != "" && attributes["path"] != "/var/lib/docker" {
+			t.Fatalf("Expected the path in the attributes, got %v", attributes)
+		}
+	}
+}
+
+func TestDiskUtilization(t *testing.T) {
+	// 60 blocks used, 10 reserved to root and 30 available.
+	if p := diskUtilization(100, 40, 30); p != float64(60)/90*100 {
+		t.Fatalf("Expected the reserved blocks to not count as available, got %.2f", p)
+	}
+	if p := diskUtilization(0, 0, 0); p != 0 {
+		t.Fatalf("Expected 0%% for an empty filesystem, got %.2f", p)
+	}
+}
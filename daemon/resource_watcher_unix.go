@@ -0,0 +1,193 @@
+// +build !windows
+
+package daemon
+
+import (
+	"bufio"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// resourceWatchInterval is how often the utilization of the containers is
+// checked against the thresholds.
+const resourceWatchInterval = 5 * time.Second
+
+// cpuSample is a sample of the CPU usage of a container and of the host,
+// in nanoseconds.
+type cpuSample struct {
+	cpu, system uint64
+}
+
+// resourceWatcher logs the events of the containers crossing the CPU and
+// memory utilization thresholds.
+type resourceWatcher struct {
+	daemon *Daemon
+	// cpu, memory, oom and disk are nil when they are not watched.
+	cpu, memory *resourceThreshold
+	// oom warns of the containers about to reach their memory limit, it
+	// only watches the containers that have one.
+	oom *resourceThreshold
+	// disk watches the usage of the filesystem of the Docker root.
+	disk *resourceThreshold
+	// system reads the CPU usage of the host, it is not shared with the
+	// stats collector since it is not safe for concurrent use.
+	system  *statsCollector
+	samples map[string]cpuSample
+	// running are the containers seen running at the last check.
+	running map[string]bool
+	stop    chan struct{}
+}
+
+// newResourceWatcher starts watching the CPU and memory utilization of the
+// running containers, against the thresholds in percent of one CPU and of
+// the memory limit of the container, and the usage of the filesystem of the
+// Docker root. A threshold of 0 is not watched.
+func (daemon *Daemon) newResourceWatcher(config *Config) *resourceWatcher {
+	hysteresis := float64(config.EventsHysteresis)
+	w := &resourceWatcher{
+		daemon: daemon,
+		system: &statsCollector{
+			clockTicksPerSecond: uint64(system.GetClockTicks()),
+			bufReader:           bufio.NewReaderSize(nil, 128),
+		},
+		samples: make(map[string]cpuSample),
+		running: make(map[string]bool),
+		stop:    make(chan struct{}),
+	}
+	if config.EventsCPUThreshold > 0 {
+		w.cpu = newResourceThreshold("cpu_high", "cpu_normal", float64(config.EventsCPUThreshold), hysteresis)
+	}
+	if config.EventsMemThreshold > 0 {
+		w.memory = newResourceThreshold("memory_high", "memory_normal", float64(config.EventsMemThreshold), hysteresis)
+	}
+	if config.EventsOOMWarning > 0 {
+		// There is no event when the container is back to normal, the
+		// warning is only logged again once it went below the hysteresis.
+		w.oom = newResourceThreshold("oom_warning", "", float64(config.EventsOOMWarning), hysteresis)
+	}
+	if config.EventsDiskThreshold > 0 {
+		w.disk = newResourceThreshold("disk_high", "disk_normal", float64(config.EventsDiskThreshold), hysteresis)
+	}
+	go w.run()
+	return w
+}
+
+// Close stops the watcher.
+func (w *resourceWatcher) Close() {
+	close(w.stop)
+}
+
+func (w *resourceWatcher) run() {
+	ticker := time.NewTicker(resourceWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if w.disk != nil {
+			w.checkDisk()
+		}
+		if w.cpu == nil && w.memory == nil && w.oom == nil {
+			continue
+		}
+		systemUsage, err := w.system.getSystemCPUUsage()
+		if err != nil {
+			logrus.Errorf("collecting system cpu usage: %v", err)
+			continue
+		}
+		running := make(map[string]bool)
+		for _, c := range w.daemon.List() {
+			if !c.IsRunning() {
+				continue
+			}
+			stats, err := w.daemon.GetContainerStats(c)
+			if err != nil {
+				if err != execdriver.ErrNotRunning {
+					logrus.Errorf("collecting stats for %s: %v", c.ID, err)
+				}
+				continue
+			}
+			running[c.ID] = true
+			w.check(c, stats, systemUsage)
+		}
+		for id := range w.running {
+			if !running[id] {
+				w.forget(id)
+			}
+		}
+		w.running = running
+	}
+}
+
+// check logs the events of the thresholds the container crossed.
+func (w *resourceWatcher) check(c *container.Container, stats *execdriver.ResourceStats, systemUsage uint64) {
+	cs := stats.CgroupStats
+	if cs == nil {
+		return
+	}
+	if w.cpu != nil {
+		sample := cpuSample{cs.CpuStats.CpuUsage.TotalUsage, systemUsage}
+		if previous, ok := w.samples[c.ID]; ok {
+			utilization := cpuPercent(previous.cpu, previous.system, sample.cpu, sample.system, len(cs.CpuStats.CpuUsage.PercpuUsage))
+			w.log(c, w.cpu, utilization)
+		}
+		w.samples[c.ID] = sample
+	}
+	if w.memory != nil {
+		w.log(c, w.memory, memoryPercent(cs.MemoryStats.Usage.Usage, stats.MemoryLimit))
+	}
+	if w.oom != nil && c.HostConfig.Memory > 0 {
+		usage := cs.MemoryStats.Usage.Usage
+		if action, attributes := oomWarning(w.oom, c.ID, usage, c.HostConfig.Memory); action != "" {
+			w.daemon.LogContainerEventWithAttributes(c, action, attributes)
+		}
+	}
+}
+
+// log logs the event of threshold t for the container, if any.
+func (w *resourceWatcher) log(c *container.Container, t *resourceThreshold, utilization float64) {
+	if action := t.update(c.ID, utilization); action != "" {
+		w.daemon.LogContainerEventWithAttributes(c, action, thresholdAttributes(t, utilization))
+	}
+}
+
+// forget discards the state of a container that is no longer running.
+func (w *resourceWatcher) forget(id string) {
+	delete(w.samples, id)
+	for _, t := range []*resourceThreshold{w.cpu, w.memory, w.oom} {
+		if t != nil {
+			t.forget(id)
+		}
+	}
+}
+
+// checkDisk logs the events of the usage of the filesystem of the Docker
+// root crossing the threshold.
+func (w *resourceWatcher) checkDisk() {
+	root := w.daemon.configStore.Root
+	utilization, err := diskPercent(root)
+	if err != nil {
+		logrus.Errorf("collecting disk usage of %s: %v", root, err)
+		return
+	}
+	if action, attributes := diskThreshold(w.disk, root, utilization); action != "" {
+		w.daemon.LogDaemonEventWithAttributes(action, attributes)
+	}
+}
+
+// diskPercent returns the usage of the filesystem of path.
+func diskPercent(path string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return diskUtilization(uint64(st.Blocks), uint64(st.Bfree), uint64(st.Bavail)), nil
+}
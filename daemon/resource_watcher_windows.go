@@ -0,0 +1,19 @@
+package daemon
+
+import (
+	"github.com/Sirupsen/logrus"
+)
+
+// resourceWatcher is not supported on Windows, where the stats of the
+// containers are not collected.
+type resourceWatcher struct{}
+
+// newResourceWatcher warns that the utilization thresholds are ignored.
+func (daemon *Daemon) newResourceWatcher(config *Config) *resourceWatcher {
+	logrus.Warn("The events of the utilization thresholds and the OOM warnings are not supported on Windows")
+	return &resourceWatcher{}
+}
+
+// Close does nothing.
+func (w *resourceWatcher) Close() {
+}
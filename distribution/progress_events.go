@@ -0,0 +1,117 @@
+package distribution
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/pkg/progress"
+)
+
+// ProgressEventLogger notifies events for a given image, with attributes.
+type ProgressEventLogger func(id, name, action string, attributes map[string]string)
+
+// layerPhases maps the progress actions of the layers to the actions of
+// the events logged when a layer enters them.
+var layerPhases = map[string]string{
+	"Pulling fs layer":     "layer_pull_start",
+	"Downloading":          "layer_download_start",
+	"Verifying Checksum":   "layer_verify",
+	"Download complete":    "layer_download_complete",
+	"Extracting":           "layer_extract_start",
+	"Pull complete":        "layer_pull_complete",
+	"Already exists":       "layer_exists",
+	"Pushing":              "layer_push_start",
+	"Pushed":               "layer_push_complete",
+	"Layer already exists": "layer_exists",
+	"Image push failed":    "layer_push_failed",
+}
+
+// resolveMessage is the prefix of the progress message written once the
+// manifest of the image is resolved.
+const resolveMessage = "Pulling from "
+
+// layerProgress is the last progress of a layer.
+type layerProgress struct {
+	action  string
+	current int64
+	total   int64
+}
+
+// progressEvents is a progress.Output writing to out, which logs an event
+// each time a layer enters a new phase of its transfer, so that the
+// progress of a pull or a push can be followed from the events.
+type progressEvents struct {
+	out  progress.Output
+	id   string
+	name string
+	log  ProgressEventLogger
+
+	mu     sync.Mutex
+	layers map[string]*layerProgress
+}
+
+func newProgressEvents(out progress.Output, id, name string, log ProgressEventLogger) *progressEvents {
+	return &progressEvents{
+		out:    out,
+		id:     id,
+		name:   name,
+		log:    log,
+		layers: make(map[string]*layerProgress),
+	}
+}
+
+// WriteProgress writes p to the underlying output, after logging the
+// event of the phase p starts, if any.
+func (pe *progressEvents) WriteProgress(p progress.Progress) error {
+	if action, attributes := pe.event(p); action != "" {
+		pe.log(pe.id, pe.name, action, attributes)
+	}
+	return pe.out.WriteProgress(p)
+}
+
+// event returns the action and the attributes of the event logged for p,
+// or an empty action.
+func (pe *progressEvents) event(p progress.Progress) (string, map[string]string) {
+	if p.Message != "" {
+		if strings.HasPrefix(p.Message, resolveMessage) && p.ID != "" {
+			return "pull_resolve", map[string]string{"tag": p.ID}
+		}
+		return "", nil
+	}
+	if p.ID == "" {
+		return "", nil
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	l, ok := pe.layers[p.ID]
+	if !ok {
+		l = &layerProgress{}
+		pe.layers[p.ID] = l
+	}
+	previous := *l
+	l.action = p.Action
+	if p.Total > 0 {
+		l.current, l.total = p.Current, p.Total
+	}
+
+	action, ok := layerPhases[p.Action]
+	if !ok || previous.action == p.Action {
+		return "", nil
+	}
+	attributes := map[string]string{"layer": p.ID}
+	switch p.Action {
+	case "Downloading", "Extracting", "Pushing":
+		if p.Total > 0 {
+			attributes["size"] = strconv.FormatInt(p.Total, 10)
+		}
+	case "Download complete", "Pull complete", "Pushed", "Verifying Checksum":
+		// the phase ends the transfer of the bytes of the previous one
+		if previous.total > 0 {
+			attributes["bytes"] = strconv.FormatInt(previous.current, 10)
+			attributes["size"] = strconv.FormatInt(previous.total, 10)
+		}
+	}
+	return action, attributes
+}
@@ -0,0 +1,70 @@
+package distribution
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/pkg/progress"
+)
+
+type discardOutput struct {
+	written int
+}
+
+func (o *discardOutput) WriteProgress(progress.Progress) error {
+	o.written++
+	return nil
+}
+
+type loggedEvent struct {
+	action     string
+	attributes map[string]string
+}
+
+func TestProgressEvents(t *testing.T) {
+	out := &discardOutput{}
+	var logged []loggedEvent
+	pe := newProgressEvents(out, "busybox:latest", "busybox", func(id, name, action string, attributes map[string]string) {
+		if id != "busybox:latest" || name != "busybox" {
+			t.Fatalf("Unexpected image %s %s", id, name)
+		}
+		logged = append(logged, loggedEvent{action, attributes})
+	})
+
+	updates := []progress.Progress{
+		{ID: "latest", Message: "Pulling from library/busybox"},
+		{ID: "a3ed95caeb02", Action: "Pulling fs layer"},
+		{ID: "a3ed95caeb02", Action: "Downloading", Current: 100, Total: 300},
+		{ID: "a3ed95caeb02", Action: "Downloading", Current: 300, Total: 300},
+		{ID: "a3ed95caeb02", Action: "Verifying Checksum"},
+		{ID: "a3ed95caeb02", Action: "Download complete"},
+		{ID: "a3ed95caeb02", Action: "Extracting", Current: 32, Total: 1024},
+		{ID: "a3ed95caeb02", Action: "Extracting", Current: 1024, Total: 1024},
+		{ID: "a3ed95caeb02", Action: "Pull complete"},
+		{ID: "5f70bf18a086", Action: "Already exists"},
+		{ID: "5f70bf18a086", Action: "Retrying in 5 seconds"},
+		{Message: "Digest: sha256:e4f93f6ed15a0cdd342f5aae387886fba0ab98af0a102da6276eaf24d6e6ade0"},
+	}
+	for _, p := range updates {
+		if err := pe.WriteProgress(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.written != len(updates) {
+		t.Fatalf("Expected %d updates to be written, got %d", len(updates), out.written)
+	}
+
+	expected := []loggedEvent{
+		{"pull_resolve", map[string]string{"tag": "latest"}},
+		{"layer_pull_start", map[string]string{"layer": "a3ed95caeb02"}},
+		{"layer_download_start", map[string]string{"layer": "a3ed95caeb02", "size": "300"}},
+		{"layer_verify", map[string]string{"layer": "a3ed95caeb02", "bytes": "300", "size": "300"}},
+		{"layer_download_complete", map[string]string{"layer": "a3ed95caeb02", "bytes": "300", "size": "300"}},
+		{"layer_extract_start", map[string]string{"layer": "a3ed95caeb02", "size": "1024"}},
+		{"layer_pull_complete", map[string]string{"layer": "a3ed95caeb02", "bytes": "1024", "size": "1024"}},
+		{"layer_exists", map[string]string{"layer": "5f70bf18a086"}},
+	}
+	if !reflect.DeepEqual(logged, expected) {
+		t.Fatalf("Expected events\n%v\ngot\n%v", expected, logged)
+	}
+}
@@ -31,6 +31,12 @@ type ImagePullConfig struct {
 	RegistryService *registry.Service
 	// ImageEventLogger notifies events for a given image
 	ImageEventLogger func(id, name, action string)
+	// ProgressEventLogger, when set, notifies events for the phases of
+	// the transfer of each layer of the image.
+	ProgressEventLogger ProgressEventLogger
+	// RegistryEventLogger, when set, notifies events for the registries
+	// refusing to authorize the pull or limiting its rate.
+	RegistryEventLogger RegistryEventLogger
 	// MetadataStore is the storage backend for distribution-specific
 	// metadata.
 	MetadataStore metadata.Store
@@ -94,6 +100,12 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 		return err
 	}
 
+	if imagePullConfig.ProgressEventLogger != nil {
+		config := *imagePullConfig
+		config.ProgressOutput = newProgressEvents(config.ProgressOutput, ref.String(), repoInfo.Name(), config.ProgressEventLogger)
+		imagePullConfig = &config
+	}
+
 	var (
 		lastErr error
 
@@ -124,6 +136,9 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 			continue
 		}
 		if err := puller.Pull(ctx, ref); err != nil {
+			if imagePullConfig.RegistryEventLogger != nil {
+				logRegistryError(imagePullConfig.RegistryEventLogger, endpoint, ref, err)
+			}
 			// Was this pull cancelled? If so, don't try to fall
 			// back.
 			fallback := false
@@ -34,6 +34,9 @@ type ImagePushConfig struct {
 	RegistryService *registry.Service
 	// ImageEventLogger notifies events for a given image
 	ImageEventLogger func(id, name, action string)
+	// ProgressEventLogger, when set, notifies events for the phases of
+	// the transfer of each layer of the image.
+	ProgressEventLogger ProgressEventLogger
 	// MetadataStore is the storage backend for distribution-specific
 	// metadata.
 	MetadataStore metadata.Store
@@ -105,6 +108,12 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 		return err
 	}
 
+	if imagePushConfig.ProgressEventLogger != nil {
+		config := *imagePushConfig
+		config.ProgressOutput = newProgressEvents(config.ProgressOutput, ref.String(), repoInfo.Name(), config.ProgressEventLogger)
+		imagePushConfig = &config
+	}
+
 	progress.Messagef(imagePushConfig.ProgressOutput, "", "The push refers to a repository [%s]", repoInfo.FullName())
 
 	associations := imagePushConfig.ReferenceStore.ReferencesByName(repoInfo)
@@ -171,6 +171,62 @@ func (th *existingTokenHandler) AuthorizeRequest(req *http.Request, params map[s
 	return nil
 }
 
+// RegistryEventLogger notifies an event of the registry hostname.
+type RegistryEventLogger func(hostname, action string, attributes map[string]string)
+
+// logRegistryError notifies an auth_failure or a rate_limited event of the
+// registry of endpoint when err is the refusal of the registry to
+// authorize the pull of ref, or to serve it because of its rate limit.
+func logRegistryError(logger RegistryEventLogger, endpoint registry.APIEndpoint, ref fmt.Stringer, err error) {
+	action := registryErrorAction(err)
+	if action == "" {
+		return
+	}
+	hostname := endpoint.URL
+	if u, err := url.Parse(endpoint.URL); err == nil && u.Host != "" {
+		hostname = u.Host
+	}
+	logger(hostname, action, map[string]string{
+		"image": ref.String(),
+		"error": err.Error(),
+	})
+}
+
+// registryErrorAction returns the action of the registry event err is
+// the cause of, if any.
+func registryErrorAction(err error) string {
+	switch v := err.(type) {
+	case fallbackError:
+		return registryErrorAction(v.err)
+	case xfer.DoNotRetry:
+		return registryErrorAction(v.Err)
+	case *url.Error:
+		return registryErrorAction(v.Err)
+	case errcode.Errors:
+		if len(v) != 0 {
+			return registryErrorAction(v[0])
+		}
+	case errcode.ErrorCode:
+		if v == errcode.ErrorCodeUnauthorized || v == errcode.ErrorCodeDenied {
+			return "auth_failure"
+		}
+	case errcode.Error:
+		if v.Code == errcode.ErrorCodeUnauthorized || v.Code == errcode.ErrorCodeDenied {
+			return "auth_failure"
+		}
+	}
+	// The registries report their rate limits with the 429 status and the
+	// TOOMANYREQUESTS code, which the client does not know, so they are
+	// recognized from the message of the error.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"toomanyrequests", "too many requests", "rate limit"} {
+		if strings.Contains(msg, s) {
+			return "rate_limited"
+		}
+	}
+	return ""
+}
+
 // retryOnError wraps the error in xfer.DoNotRetry if we should not retry the
 // operation after this error.
 func retryOnError(err error) error {
@@ -1,6 +1,7 @@
 package distribution
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -85,3 +88,30 @@ func TestTokenPassThru(t *testing.T) {
 	}
 
 }
+
+func TestLogRegistryError(t *testing.T) {
+	endpoint := registry.APIEndpoint{URL: "https://registry.example.com:5000"}
+	ref, _ := reference.ParseNamed("registry.example.com:5000/billing/db")
+	for _, c := range []struct {
+		err    error
+		action string
+	}{
+		{errcode.ErrorCodeUnauthorized, "auth_failure"},
+		{fallbackError{err: errcode.Errors{errcode.ErrorCodeDenied.WithMessage("denied")}}, "auth_failure"},
+		{errcode.ErrorCodeUnknown.WithMessage("You have reached your pull rate limit"), "rate_limited"},
+		{xfer.DoNotRetry{Err: errors.New("toomanyrequests: too many requests")}, "rate_limited"},
+		{errors.New("manifest unknown"), ""},
+	} {
+		var logged []string
+		logger := func(hostname, action string, attributes map[string]string) {
+			if hostname != "registry.example.com:5000" || attributes["image"] != ref.String() || attributes["error"] != c.err.Error() {
+				t.Fatalf("Unexpected event %s of %s with %v", action, hostname, attributes)
+			}
+			logged = append(logged, action)
+		}
+		logRegistryError(logger, endpoint, ref, c.err)
+		if c.action == "" && len(logged) != 0 || c.action != "" && (len(logged) != 1 || logged[0] != c.action) {
+			t.Fatalf("Expected %q for %v, got %v", c.action, c.err, logged)
+		}
+	}
+}
@@ -984,4 +984,50 @@ var (
 		Description:    "A container can only be connected to one network at the time",
 		HTTPStatusCode: http.StatusBadRequest,
 	})
+
+	// ErrorCodeNoEventsJournal is generated when the events history is
+	// requested from a daemon that does not keep a journal of events.
+	ErrorCodeNoEventsJournal = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NO_EVENTS_JOURNAL",
+		Message:        "Events history is not available, the daemon is not started with --events-journal",
+		Description:    "The events history is read from the events journal, which is disabled",
+		HTTPStatusCode: http.StatusNotImplemented,
+	})
+
+	// ErrorCodeNoSuchEventsSnapshot is generated when the events of a
+	// snapshot that was not imported are requested.
+	ErrorCodeNoSuchEventsSnapshot = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NO_SUCH_EVENTS_SNAPSHOT",
+		Message:        "No such events snapshot: %s",
+		Description:    "The events snapshot requested was not imported in the daemon",
+		HTTPStatusCode: http.StatusNotFound,
+	})
+
+	// ErrorCodeEventsNotAdmin is generated when a client that the events
+	// access control lists do not allow to manage the events service
+	// tries to.
+	ErrorCodeEventsNotAdmin = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "EVENTS_NOT_ADMIN",
+		Message:        "The client is not allowed to manage the events service",
+		Description:    "Only the clients of the events access control lists with admin set can manage the events service",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
+	// ErrorCodeEventsNoTenant is generated when a client that cannot be
+	// identified subscribes to an event stream scoped per tenant.
+	ErrorCodeEventsNoTenant = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "EVENTS_NO_TENANT",
+		Message:        "The events are scoped per tenant, the client must be authenticated by an authorization plugin or a TLS certificate",
+		Description:    "The events of a daemon started with --events-tenant-scoping are only sent to the clients identified as a tenant",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
+	// ErrorCodeEventsOwnerLabel is generated when a client sets the owner
+	// label on a container while the events are scoped per tenant.
+	ErrorCodeEventsOwnerLabel = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "EVENTS_OWNER_LABEL",
+		Message:        "The %s label is set by the daemon when the events are scoped per tenant",
+		Description:    "The owner label of the containers created on a daemon started with --events-tenant-scoping is set to the tenant creating them, clients cannot set it",
+		HTTPStatusCode: http.StatusForbidden,
+	})
 )